@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
+	"github.com/ytnobody/madflow/internal/orchestrator"
+	"github.com/ytnobody/madflow/internal/project"
+)
+
+// auditOptions holds the parsed flags of `madflow audit`.
+type auditOptions struct {
+	filter audit.Filter
+	json   bool
+}
+
+// cmdAudit prints entries from the command audit log of the current project.
+func cmdAudit(args []string) error {
+	opts, err := parseAuditArgs(args, time.Now())
+	if err != nil {
+		return err
+	}
+
+	proj, err := project.Detect()
+	if err != nil {
+		return err
+	}
+
+	entries, err := audit.Query(filepath.Join(proj.DataDir, orchestrator.AuditLogFile), opts.filter)
+	if err != nil {
+		return err
+	}
+	return printAuditEntries(os.Stdout, entries, opts.json)
+}
+
+// parseAuditArgs parses `madflow audit` flags. --since and --until accept an
+// RFC 3339 timestamp, a date (2006-01-02) or a duration relative to now (e.g. 2h).
+func parseAuditArgs(args []string, now time.Time) (auditOptions, error) {
+	var opts auditOptions
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		if flag == "--json" {
+			opts.json = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return opts, fmt.Errorf("missing value for %s\n\n%s", flag, auditUsage)
+		}
+		i++
+		value := args[i]
+		switch flag {
+		case "--agent":
+			opts.filter.Agent = value
+		case "--issue":
+			opts.filter.Issue = value
+		case "--since", "--until":
+			t, err := parseAuditTime(value, now)
+			if err != nil {
+				return opts, fmt.Errorf("%s: %w", flag, err)
+			}
+			if flag == "--since" {
+				opts.filter.Since = t
+			} else {
+				opts.filter.Until = t
+			}
+		case "--status":
+			if value != audit.StatusSuccess && value != audit.StatusFailure {
+				return opts, fmt.Errorf("--status must be %q or %q", audit.StatusSuccess, audit.StatusFailure)
+			}
+			opts.filter.Status = value
+		default:
+			return opts, fmt.Errorf("unknown option %s\n\n%s", flag, auditUsage)
+		}
+	}
+	return opts, nil
+}
+
+const auditUsage = `Usage: madflow audit [--agent ID] [--issue ID] [--since T] [--until T] [--status success|failure] [--json]`
+
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use RFC 3339, YYYY-MM-DD or a duration such as 2h)", value)
+}
+
+// printAuditEntries writes entries as one line each, or as JSONL when asJSON is set.
+func printAuditEntries(w io.Writer, entries []audit.Entry, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, e := range entries {
+		issue := e.Issue
+		if issue == "" {
+			issue = "-"
+		}
+		status := fmt.Sprintf("exit=%d", e.ExitCode)
+		if e.Error != "" {
+			status += " (" + e.Error + ")"
+		}
+		fmt.Fprintf(w, "%s %s %s %s %s %s $ %s\n",
+			e.Time.Local().Format("2006-01-02T15:04:05"),
+			e.Agent,
+			issue,
+			status,
+			time.Duration(e.DurationMS)*time.Millisecond,
+			e.WorkDir,
+			strings.ReplaceAll(e.Command, "\n", "\\n"),
+		)
+	}
+	return nil
+}
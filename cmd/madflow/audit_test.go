@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
+)
+
+func TestParseAuditArgs(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts, err := parseAuditArgs([]string{
+		"--agent", "engineer-1",
+		"--issue", "gh-42",
+		"--since", "2h",
+		"--until", "2026-03-01T11:30:00Z",
+		"--status", "failure",
+		"--json",
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := opts.filter
+	if f.Agent != "engineer-1" || f.Issue != "gh-42" || f.Status != audit.StatusFailure || !opts.json {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if !f.Since.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("since = %v, want %v", f.Since, now.Add(-2*time.Hour))
+	}
+	if !f.Until.Equal(time.Date(2026, 3, 1, 11, 30, 0, 0, time.UTC)) {
+		t.Errorf("until = %v", f.Until)
+	}
+}
+
+func TestParseAuditArgsErrors(t *testing.T) {
+	tests := [][]string{
+		{"--agent"},
+		{"--status", "ok"},
+		{"--since", "yesterday"},
+		{"--bogus", "x"},
+	}
+	for _, args := range tests {
+		if _, err := parseAuditArgs(args, time.Now()); err == nil {
+			t.Errorf("parseAuditArgs(%v): expected error", args)
+		}
+	}
+}
+
+func TestPrintAuditEntries(t *testing.T) {
+	entries := []audit.Entry{
+		{Time: time.Now(), Agent: "engineer-1", Issue: "gh-1", WorkDir: "/repo", Command: "go test\n./...", ExitCode: 1, DurationMS: 1500},
+		{Time: time.Now(), Agent: "superintendent", Command: "rm -rf /", ExitCode: -1, Error: "rejected"},
+	}
+	var buf bytes.Buffer
+	if err := printAuditEntries(&buf, entries, false); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"engineer-1 gh-1 exit=1 1.5s /repo $ go test\\n./...", "superintendent - exit=-1 (rejected)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := printAuditEntries(&buf, entries, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("expected 2 JSON lines, got %d", n)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ytnobody/madflow/internal/config"
+)
+
+const configUsage = `Usage: madflow config validate [--profile NAME]
+       madflow config show [--effective] [--profile NAME]`
+
+// cmdConfig implements `madflow config <subcommand>`.
+func cmdConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand\n\n%s", configUsage)
+	}
+	sub := args[0]
+	effective := false
+	profile := ""
+	rest := args[1:]
+	for i := 0; i < len(rest); i++ {
+		switch {
+		case rest[i] == "--effective" && sub == "show":
+			effective = true
+		case rest[i] == "--profile" && i+1 < len(rest):
+			i++
+			profile = rest[i]
+		default:
+			return fmt.Errorf("unknown option %s\n\n%s", rest[i], configUsage)
+		}
+	}
+
+	configPath, err := findConfigPath()
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "validate":
+		return validateConfig(os.Stdout, configPath, profile)
+	case "show":
+		if !effective {
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return showEffectiveConfig(os.Stdout, configPath, profile)
+	default:
+		return fmt.Errorf("unknown subcommand %q\n\n%s", sub, configUsage)
+	}
+}
+
+// validateConfig checks the config file strictly and prints every finding.
+// It returns an error if any finding is not a warning.
+func validateConfig(w io.Writer, configPath, profile string) error {
+	_, findings, err := config.Check(configPath, profile)
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s: %s\n", configPath, f)
+	}
+	if config.HasErrors(findings) {
+		return fmt.Errorf("%s is invalid", configPath)
+	}
+	fmt.Fprintf(w, "%s: OK\n", configPath)
+	return nil
+}
+
+// showEffectiveConfig prints the config with every include, the profile,
+// environment overrides and defaults applied, as TOML.
+func showEffectiveConfig(w io.Writer, configPath, profile string) error {
+	cfg, findings, err := config.Check(configPath, profile)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		for _, f := range findings {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", configPath, f)
+		}
+		return fmt.Errorf("%s is invalid; run `madflow config validate`", configPath)
+	}
+	return toml.NewEncoder(w).Encode(cfg)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testConfig = `
+[project]
+name = "app"
+
+[[project.repos]]
+name = "main"
+path = "."
+`
+
+func TestValidateConfig(t *testing.T) {
+	var buf bytes.Buffer
+	if err := validateConfig(&buf, writeTestConfig(t, testConfig), ""); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), ": OK") {
+		t.Errorf("expected OK, got %q", buf.String())
+	}
+
+	buf.Reset()
+	err := validateConfig(&buf, writeTestConfig(t, testConfig+"\n[agent]\nmax_team = 8\n"), "")
+	if err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if !strings.Contains(buf.String(), `error: unknown key "agent.max_team"`) {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+func TestShowEffectiveConfig(t *testing.T) {
+	var buf bytes.Buffer
+	if err := showEffectiveConfig(&buf, writeTestConfig(t, testConfig), ""); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"max_teams = 4", `engineer = "claude-haiku-4-5"`, `develop = "develop"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("effective config missing %q:\n%s", want, out)
+		}
+	}
+}
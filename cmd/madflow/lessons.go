@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/lessons"
+	"github.com/ytnobody/madflow/internal/orchestrator"
+	"github.com/ytnobody/madflow/internal/project"
+)
+
+const lessonsUsage = `Usage: madflow lessons list [--role ROLE] [--repo NAME] [--pinned]
+       madflow lessons add [--risk RISK] [--role ROLE] [--repo NAME] [--issue ID] [--pin] <text>
+       madflow lessons remove <n>...
+       madflow lessons edit <n> [--risk RISK] [--role ROLE] [--repo NAME] [--issue ID] [<text>]
+       madflow lessons pin <n>...
+       madflow lessons unpin <n>...
+       madflow lessons export [--role ROLE] [--repo NAME] [FILE]
+       madflow lessons import FILE
+       madflow lessons report [--role ROLE] [--repo NAME]
+
+<n> is the number shown by list. RISK is high, medium or low (or 高, 中, 低);
+ROLE is superintendent (the default) or engineer.`
+
+// cmdLessons implements `madflow lessons <subcommand>` on the lessons file
+// of the current project ([lessons] file, or lessons.txt in the data
+// directory).
+func cmdLessons(args []string) error {
+	proj, err := project.Detect()
+	if err != nil {
+		return err
+	}
+	configPath, err := findConfigPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	m := &lessons.Manager{
+		DataDir:       proj.DataDir,
+		File:          cfg.Lessons.Path(),
+		FeaturePrefix: cfg.Branches.FeaturePrefix,
+		Completer:     orchestrator.LessonsCompleter(cfg),
+		RetireAfter:   cfg.Lessons.RetireAfter,
+	}
+	return runLessons(os.Stdout, m, args, time.Now())
+}
+
+// lessonArgs holds the parsed arguments of a `madflow lessons` subcommand.
+type lessonArgs struct {
+	values     map[string]string
+	pin        bool
+	pinned     bool
+	positional []string
+}
+
+// parseLessonArgs parses the options of a subcommand. Options take a value
+// except --pin and --pinned; the other arguments are positional.
+func parseLessonArgs(args []string) (lessonArgs, error) {
+	a := lessonArgs{values: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--pin":
+			a.pin = true
+		case "--pinned":
+			a.pinned = true
+		case "--risk", "--role", "--repo", "--issue":
+			if i+1 >= len(args) {
+				return a, fmt.Errorf("missing value for %s\n\n%s", arg, lessonsUsage)
+			}
+			i++
+			a.values[strings.TrimPrefix(arg, "--")] = args[i]
+		default:
+			if strings.HasPrefix(arg, "--") {
+				return a, fmt.Errorf("unknown option %s\n\n%s", arg, lessonsUsage)
+			}
+			a.positional = append(a.positional, arg)
+		}
+	}
+	return a, nil
+}
+
+// runLessons runs a `madflow lessons` subcommand on m's lessons file.
+func runLessons(w io.Writer, m *lessons.Manager, args []string, now time.Time) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand\n\n%s", lessonsUsage)
+	}
+	sub := args[0]
+	a, err := parseLessonArgs(args[1:])
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "list":
+		all, err := lessons.LoadLessons(m.LessonsPath())
+		if err != nil {
+			return err
+		}
+		for i, l := range all {
+			if matchLesson(l, a) {
+				fmt.Fprintf(w, "%3d. %s\n", i+1, lessons.FormatLesson(l))
+			}
+		}
+		return nil
+
+	case "add":
+		text := strings.Join(a.positional, " ")
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("missing lesson text\n\n%s", lessonsUsage)
+		}
+		l := lessons.Lesson{Risk: lessons.RiskMedium, Role: agent.RoleSuperintendent, Pinned: a.pin, Date: now.Format("2006-01-02")}
+		if err := applyLessonArgs(&l, a); err != nil {
+			return err
+		}
+		l.Text = text
+		if err := m.Update(func(all []lessons.Lesson) ([]lessons.Lesson, error) {
+			return append(all, l), nil
+		}); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Added: %s\n", lessons.FormatLesson(l))
+		return nil
+
+	case "remove":
+		nums, err := lessonNumbers(a.positional, 0)
+		if err != nil {
+			return err
+		}
+		return m.Update(func(all []lessons.Lesson) ([]lessons.Lesson, error) {
+			if err := checkLessonNumbers(nums, len(all)); err != nil {
+				return nil, err
+			}
+			var kept []lessons.Lesson
+			for i, l := range all {
+				if slices.Contains(nums, i+1) {
+					fmt.Fprintf(w, "Removed: %s\n", lessons.FormatLesson(l))
+					continue
+				}
+				kept = append(kept, l)
+			}
+			return kept, nil
+		})
+
+	case "edit":
+		if len(a.positional) == 0 {
+			return fmt.Errorf("missing lesson number\n\n%s", lessonsUsage)
+		}
+		nums, err := lessonNumbers(a.positional[:1], 1)
+		if err != nil {
+			return err
+		}
+		text := strings.Join(a.positional[1:], " ")
+		return m.Update(func(all []lessons.Lesson) ([]lessons.Lesson, error) {
+			if err := checkLessonNumbers(nums, len(all)); err != nil {
+				return nil, err
+			}
+			l := &all[nums[0]-1]
+			if err := applyLessonArgs(l, a); err != nil {
+				return nil, err
+			}
+			if a.pin {
+				l.Pinned = true
+			}
+			if strings.TrimSpace(text) != "" {
+				l.Text = text
+			}
+			fmt.Fprintf(w, "Edited: %s\n", lessons.FormatLesson(*l))
+			return all, nil
+		})
+
+	case "pin", "unpin":
+		nums, err := lessonNumbers(a.positional, 0)
+		if err != nil {
+			return err
+		}
+		return m.Update(func(all []lessons.Lesson) ([]lessons.Lesson, error) {
+			if err := checkLessonNumbers(nums, len(all)); err != nil {
+				return nil, err
+			}
+			for _, n := range nums {
+				all[n-1].Pinned = sub == "pin"
+				fmt.Fprintf(w, "%s\n", lessons.FormatLesson(all[n-1]))
+			}
+			return all, nil
+		})
+
+	case "export":
+		if len(a.positional) > 1 {
+			return fmt.Errorf("too many arguments\n\n%s", lessonsUsage)
+		}
+		all, err := lessons.LoadLessons(m.LessonsPath())
+		if err != nil {
+			return err
+		}
+		var out []lessons.Lesson
+		for _, l := range all {
+			if matchLesson(l, a) {
+				out = append(out, l)
+			}
+		}
+		if len(a.positional) == 0 {
+			for _, l := range out {
+				fmt.Fprintln(w, lessons.FormatLesson(l))
+			}
+			return nil
+		}
+		if err := lessons.SaveLessons(a.positional[0], out); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Exported %d lessons to %s\n", len(out), a.positional[0])
+		return nil
+
+	case "import":
+		if len(a.positional) != 1 {
+			return fmt.Errorf("import needs one file\n\n%s", lessonsUsage)
+		}
+		added, err := m.Import(a.positional[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Imported %d new lessons from %s\n", added, a.positional[0])
+		return nil
+
+	case "report":
+		effects, err := m.Effects()
+		if err != nil {
+			return err
+		}
+		for i, e := range effects {
+			if !matchLesson(e.Lesson, a) {
+				continue
+			}
+			change := "-"
+			if c, ok := e.Change(); ok {
+				change = fmt.Sprintf("%+.1f", c)
+			}
+			fmt.Fprintf(w, "%3d. %s\n     before: %s | after: %s | change: %s\n",
+				i+1, lessons.FormatLesson(e.Lesson), formatScoreStats(e.Before), formatScoreStats(e.After), change)
+		}
+		if m.RetireAfter > 0 {
+			fmt.Fprintf(w, "\nUnpinned lessons are retired after %d issues without improvement.\n", m.RetireAfter)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown subcommand %q\n\n%s", sub, lessonsUsage)
+	}
+}
+
+// formatScoreStats formats the scores of the issues on one side of a
+// lesson's report line.
+func formatScoreStats(s lessons.ScoreStats) string {
+	if s.Issues == 0 {
+		return "no issues"
+	}
+	return fmt.Sprintf("%d issues, avg %.1f", s.Issues, s.Mean())
+}
+
+// matchLesson reports whether l passes the --role, --repo and --pinned
+// filters of list, export and report.
+func matchLesson(l lessons.Lesson, a lessonArgs) bool {
+	if role, ok := a.values["role"]; ok && string(l.Role) != role {
+		return false
+	}
+	if repo, ok := a.values["repo"]; ok && l.Repo != repo {
+		return false
+	}
+	return !a.pinned || l.Pinned
+}
+
+// applyLessonArgs sets the fields of l given by --risk, --role, --repo and
+// --issue. An empty --repo or --issue clears the field.
+func applyLessonArgs(l *lessons.Lesson, a lessonArgs) error {
+	if v, ok := a.values["risk"]; ok {
+		risk, err := parseRisk(v)
+		if err != nil {
+			return err
+		}
+		l.Risk = risk
+	}
+	if v, ok := a.values["role"]; ok {
+		role := agent.Role(v)
+		if role != agent.RoleSuperintendent && role != agent.RoleEngineer {
+			return fmt.Errorf("--role must be %s or %s, got %q", agent.RoleSuperintendent, agent.RoleEngineer, v)
+		}
+		l.Role = role
+	}
+	if v, ok := a.values["repo"]; ok {
+		l.Repo = v
+	}
+	if v, ok := a.values["issue"]; ok {
+		l.Issue = v
+	}
+	return nil
+}
+
+func parseRisk(v string) (lessons.RiskLevel, error) {
+	switch strings.ToLower(v) {
+	case "high", string(lessons.RiskHigh):
+		return lessons.RiskHigh, nil
+	case "medium", string(lessons.RiskMedium):
+		return lessons.RiskMedium, nil
+	case "low", string(lessons.RiskLow):
+		return lessons.RiskLow, nil
+	}
+	return "", fmt.Errorf("--risk must be high, medium or low, got %q", v)
+}
+
+// lessonNumbers parses lesson numbers; want is the number expected, or 0
+// for one or more.
+func lessonNumbers(args []string, want int) ([]int, error) {
+	if len(args) == 0 || (want > 0 && len(args) != want) {
+		return nil, fmt.Errorf("missing lesson number\n\n%s", lessonsUsage)
+	}
+	var nums []int
+	for _, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid lesson number %q", arg)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+func checkLessonNumbers(nums []int, count int) error {
+	for _, n := range nums {
+		if n > count {
+			return fmt.Errorf("no lesson %d (there are %d)", n, count)
+		}
+	}
+	return nil
+}
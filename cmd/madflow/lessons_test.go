@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/lessons"
+)
+
+func TestRunLessons(t *testing.T) {
+	m := &lessons.Manager{DataDir: t.TempDir()}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	run := func(args ...string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := runLessons(&buf, m, args, now); err != nil {
+			t.Fatalf("lessons %v: %v", args, err)
+		}
+		return buf.String()
+	}
+
+	run("add", "--risk", "high", "Issueに完了条件を書くこと")
+	run("add", "--role", "engineer", "--repo", "app", "--pin", "テストを先に書くこと")
+	run("add", "--risk", "低", "不要な教訓")
+
+	want := "  1. [高][date:2026-03-01] Issueに完了条件を書くこと\n" +
+		"  2. [中][engineer][repo:app][pinned][date:2026-03-01] テストを先に書くこと\n" +
+		"  3. [低][date:2026-03-01] 不要な教訓\n"
+	if got := run("list"); got != want {
+		t.Errorf("list =\n%s\nwant\n%s", got, want)
+	}
+	if got := run("list", "--role", "engineer"); !strings.HasPrefix(got, "  2. ") || strings.Count(got, "\n") != 1 {
+		t.Errorf("list --role engineer =\n%s", got)
+	}
+
+	run("remove", "3")
+	run("edit", "1", "--issue", "local-001", "Issueに完了条件と対象リポジトリを書くこと")
+	run("unpin", "2")
+	want = "  1. [高][issue:local-001][date:2026-03-01] Issueに完了条件と対象リポジトリを書くこと\n" +
+		"  2. [中][engineer][repo:app][date:2026-03-01] テストを先に書くこと\n"
+	if got := run("list"); got != want {
+		t.Errorf("list after remove, edit and unpin =\n%s\nwant\n%s", got, want)
+	}
+
+	// Export and import into another lessons file; lessons already there
+	// are not duplicated.
+	exported := filepath.Join(t.TempDir(), "shared.txt")
+	run("export", "--role", "engineer", exported)
+	other := &lessons.Manager{DataDir: t.TempDir()}
+	for _, wantAdded := range []string{"Imported 1 new", "Imported 0 new"} {
+		var buf bytes.Buffer
+		if err := runLessons(&buf, other, []string{"import", exported}, now); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), wantAdded) {
+			t.Errorf("import = %q, want %q", buf.String(), wantAdded)
+		}
+	}
+}
+
+func TestRunLessonsReport(t *testing.T) {
+	m := &lessons.Manager{DataDir: t.TempDir(), RetireAfter: 10}
+	if err := lessons.SaveLessons(m.LessonsPath(), []lessons.Lesson{
+		{Risk: lessons.RiskHigh, Text: "Issueに完了条件を書くこと"},
+		{Risk: lessons.RiskMedium, Role: "engineer", Text: "テストを先に書くこと"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stats := `{
+  "local-001": {"assigned": "2026-03-01", "scores": {"superintendent": 50}},
+  "local-002": {"assigned": "2026-03-02", "lessons": ["superintendent||Issueに完了条件を書くこと"], "scores": {"superintendent": 90}}
+}`
+	if err := os.WriteFile(filepath.Join(m.DataDir, "lessons-stats.json"), []byte(stats), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := runLessons(&buf, m, []string{"report"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	want := "  1. [高] Issueに完了条件を書くこと\n" +
+		"     before: 1 issues, avg 50.0 | after: 1 issues, avg 90.0 | change: +40.0\n" +
+		"  2. [中][engineer] テストを先に書くこと\n" +
+		"     before: no issues | after: no issues | change: -\n" +
+		"\nUnpinned lessons are retired after 10 issues without improvement.\n"
+	if got := buf.String(); got != want {
+		t.Errorf("report =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunLessonsErrors(t *testing.T) {
+	m := &lessons.Manager{DataDir: t.TempDir()}
+	var buf bytes.Buffer
+	if err := runLessons(&buf, m, []string{"add", "教訓"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{},
+		{"bogus"},
+		{"add"},
+		{"add", "--risk", "extreme", "x"},
+		{"add", "--role", "orchestrator", "x"},
+		{"remove", "2"},
+		{"remove", "zero"},
+		{"edit"},
+		{"pin", "--bogus", "1"},
+		{"import", filepath.Join(t.TempDir(), "missing.txt")},
+	} {
+		if err := runLessons(&buf, m, args, time.Now()); err == nil {
+			t.Errorf("lessons %v: expected error", args)
+		}
+	}
+}
@@ -25,10 +25,24 @@ const usage = `Usage: madflow <command> [options]
 
 Commands:
   init                      Initialize a new project
-  start                     Start all agents
+  start [--profile NAME]    Start all agents (profile: [profiles.NAME] in madflow.toml)
   use <preset>              Switch the active model preset in madflow.toml
-                            Presets: claude, gemini, claude-cheap, gemini-cheap, hybrid, hybrid-cheap,
-                                     claude-api-standard, claude-api-cheap (require ANTHROPIC_API_KEY)
+                            Built-in: claude, gemini, claude-cheap, gemini-cheap, hybrid, hybrid-cheap,
+                                      claude-api-standard, claude-api-cheap (require ANTHROPIC_API_KEY)
+                            Custom presets: [presets.NAME] in ~/.madflow/presets.toml or madflow.toml
+  use --show                Show which preset the current config matches
+  config validate           Check madflow.toml strictly (unknown keys, ranges, models, repos)
+  config show [--effective] Show madflow.toml, or the config with includes, profile,
+                            MADFLOW_* overrides and defaults applied (both accept --profile)
+  pause <team|issue-id>     Pause a team; it keeps its context and resumes where it stopped
+  pause --all | --new-teams Pause every team and team creation, or team creation only
+  resume <team|issue-id>    Resume a paused team (also --all, --new-teams)
+  audit [filters]           Show commands executed by agents
+                            Filters: --agent ID, --issue ID, --since T, --until T,
+                                     --status success|failure, --json
+  lessons <subcommand>      Manage the lessons injected into agent prompts:
+                            list, add, remove, edit, pin, unpin, export, import,
+                            report
   version                   Show current version
   upgrade                   Upgrade madflow to the latest version
 `
@@ -53,18 +67,24 @@ func main() {
 	case "init":
 		err = cmdInit()
 	case "start":
-		err = cmdStart()
+		err = cmdStart(os.Args[2:])
 	case "version", "--version", "-v":
 		fmt.Printf("madflow %s\n", version)
 		return
 	case "use":
-		preset := ""
-		if len(os.Args) >= 3 {
-			preset = os.Args[2]
-		}
-		err = cmdUse(preset)
+		err = cmdUse(os.Args[2:])
 	case "upgrade":
 		err = cmdUpgrade(version)
+	case "config":
+		err = cmdConfig(os.Args[2:])
+	case "audit":
+		err = cmdAudit(os.Args[2:])
+	case "pause":
+		err = cmdPause("PAUSE", os.Args[2:])
+	case "resume":
+		err = cmdPause("RESUME", os.Args[2:])
+	case "lessons":
+		err = cmdLessons(os.Args[2:])
 	case "help", "--help", "-h":
 		fmt.Print(usage)
 		return
@@ -188,8 +208,18 @@ func cleanupLegacyResources(repos []config.RepoConfig) {
 	}
 }
 
-func cmdStart() error {
-	configPath, cfg, proj, err := loadProjectConfig()
+func cmdStart(args []string) error {
+	profile := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--profile" && i+1 < len(args) {
+			i++
+			profile = args[i]
+			continue
+		}
+		return fmt.Errorf("unknown option %s\n\nUsage: madflow start [--profile NAME]", args[i])
+	}
+
+	configPath, cfg, proj, err := loadProjectConfig(profile)
 	if err != nil {
 		return err
 	}
@@ -229,10 +259,10 @@ func cmdStart() error {
 	return err
 }
 
-// loadProjectConfig detects the project and loads its config.
-// It returns the resolved config file path along with the parsed config and
-// project metadata so that the caller can enable hot-reload.
-func loadProjectConfig() (string, *config.Config, *project.Project, error) {
+// loadProjectConfig detects the project and loads its config with the given
+// profile. It returns the resolved config file path along with the parsed
+// config and project metadata so that the caller can enable hot-reload.
+func loadProjectConfig(profile string) (string, *config.Config, *project.Project, error) {
 	configPath, err := findConfigPath()
 	if err != nil {
 		return "", nil, nil, err
@@ -243,10 +273,13 @@ func loadProjectConfig() (string, *config.Config, *project.Project, error) {
 		return "", nil, nil, err
 	}
 
-	cfg, err := config.Load(configPath)
+	cfg, err := config.LoadProfile(configPath, profile)
 	if err != nil {
 		return "", nil, nil, err
 	}
+	if cfg.Profile != "" {
+		fmt.Printf("Using config profile '%s'\n", cfg.Profile)
+	}
 
 	return configPath, cfg, proj, nil
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/chatlog"
+	"github.com/ytnobody/madflow/internal/orchestrator"
+	"github.com/ytnobody/madflow/internal/project"
+)
+
+const pauseUsage = `Usage: madflow pause <team|issue-id> | --all | --new-teams
+       madflow resume <team|issue-id> | --all | --new-teams`
+
+// operatorSender is the chatlog sender of commands issued from the CLI.
+const operatorSender = "operator"
+
+// commandReplyTimeout bounds how long the CLI waits for the orchestrator.
+const commandReplyTimeout = 30 * time.Second
+
+// cmdPause implements `madflow pause` and `madflow resume` (name is "PAUSE"
+// or "RESUME"). The command is sent to the running orchestrator through the
+// chatlog, and its reply is printed.
+func cmdPause(name string, args []string) error {
+	line, err := pauseCommandLine(name, args)
+	if err != nil {
+		return err
+	}
+	proj, err := project.Detect()
+	if err != nil {
+		return err
+	}
+	return sendOrchestratorCommand(os.Stdout, filepath.Join(proj.DataDir, orchestrator.ChatLogFile), line, commandReplyTimeout)
+}
+
+// pauseCommandLine builds the orchestrator command for `madflow pause|resume`.
+func pauseCommandLine(name string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected one team, issue ID, --all or --new-teams\n\n%s", pauseUsage)
+	}
+	arg := args[0]
+	if strings.HasPrefix(arg, "-") && arg != "--all" && arg != "--new-teams" {
+		return "", fmt.Errorf("unknown option %s\n\n%s", arg, pauseUsage)
+	}
+	return name + " " + arg, nil
+}
+
+// sendOrchestratorCommand writes line to the orchestrator through the chatlog
+// at chatLogPath and waits for its ACK or NACK. A NACK is returned as an error.
+func sendOrchestratorCommand(w io.Writer, chatLogPath, line string, timeout time.Duration) error {
+	id := fmt.Sprintf("cli-%d", time.Now().UnixNano())
+	cl := chatlog.New(chatLogPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// Watch before writing so that the reply cannot be missed.
+	replies := cl.Watch(ctx, operatorSender)
+	if err := cl.Append("orchestrator", operatorSender, line+" --id="+id); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no reply from the orchestrator within %s; is `madflow start` running?", timeout)
+		case msg, ok := <-replies:
+			if !ok {
+				return fmt.Errorf("no reply from the orchestrator within %s; is `madflow start` running?", timeout)
+			}
+			status, rest, _ := strings.Cut(msg.Body, " ")
+			text, found := strings.CutPrefix(rest, "id="+id+" ")
+			if !found {
+				continue
+			}
+			if status == "NACK" {
+				return fmt.Errorf("%s", text)
+			}
+			fmt.Fprintln(w, text)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/chatlog"
+)
+
+func TestPauseCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"PAUSE", []string{"2"}, "PAUSE 2", false},
+		{"RESUME", []string{"--all"}, "RESUME --all", false},
+		{"PAUSE", []string{"--new-teams"}, "PAUSE --new-teams", false},
+		{"PAUSE", nil, "", true},
+		{"PAUSE", []string{"1", "2"}, "", true},
+		{"PAUSE", []string{"--force"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := pauseCommandLine(tt.name, tt.args)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("pauseCommandLine(%s, %q) = %q, %v", tt.name, tt.args, got, err)
+		}
+	}
+}
+
+// fakeOrchestrator answers every command in the chatlog at path with status.
+func fakeOrchestrator(ctx context.Context, path, status string) {
+	cl := chatlog.New(path)
+	cmds := cl.Watch(ctx, "orchestrator")
+	go func() {
+		for msg := range cmds {
+			_, id, _ := strings.Cut(msg.Body, "--id=")
+			cl.Append(msg.Sender, "orchestrator", status+" id="+id+" "+strings.Fields(msg.Body)[0]+": done")
+		}
+	}()
+}
+
+func TestSendOrchestratorCommand(t *testing.T) {
+	for _, status := range []string{"ACK", "NACK"} {
+		t.Run(status, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chatlog.txt")
+			fakeOrchestrator(t.Context(), path, status)
+
+			var out bytes.Buffer
+			err := sendOrchestratorCommand(&out, path, "PAUSE --all", 5*time.Second)
+			if status == "ACK" {
+				if err != nil || out.String() != "PAUSE: done\n" {
+					t.Errorf("ACK: err = %v, output = %q", err, out.String())
+				}
+				return
+			}
+			if err == nil || err.Error() != "PAUSE: done" {
+				t.Errorf("NACK: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestSendOrchestratorCommandTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chatlog.txt")
+	err := sendOrchestratorCommand(&bytes.Buffer{}, path, "RESUME 1", 600*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "madflow start") {
+		t.Errorf("err = %v, want a timeout error", err)
+	}
+}
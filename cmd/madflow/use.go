@@ -2,78 +2,174 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/BurntSushi/toml"
+	"github.com/ytnobody/madflow/internal/config"
 	"github.com/ytnobody/madflow/internal/project"
 )
 
-// presetModels defines the model configuration for each preset name.
-type presetModels struct {
-	Superintendent string
-	Engineer       string
-}
-
-// presets maps preset name → model configuration.
-var presets = map[string]presetModels{
+// presets maps built-in preset name → configuration.
+var presets = map[string]config.Preset{
 	"claude": {
+		Description:    "Claude CLI, Sonnet for both roles",
 		Superintendent: "claude-sonnet-4-6",
 		Engineer:       "claude-sonnet-4-6",
 	},
 	"gemini": {
+		Description:    "Gemini API, Pro for both roles",
 		Superintendent: "gemini-2.5-pro",
 		Engineer:       "gemini-2.5-pro",
 	},
 	"claude-cheap": {
+		Description:    "Claude CLI, Haiku engineers",
 		Superintendent: "claude-sonnet-4-6",
 		Engineer:       "claude-haiku-4-5",
 	},
 	"gemini-cheap": {
+		Description:    "Gemini API, Flash for both roles",
 		Superintendent: "gemini-2.5-flash",
 		Engineer:       "gemini-2.5-flash",
 	},
 	"hybrid": {
+		Description:    "Claude superintendent, Gemini Pro engineers",
 		Superintendent: "claude-sonnet-4-6",
 		Engineer:       "gemini-2.5-pro",
 	},
 	"hybrid-cheap": {
+		Description:    "Claude superintendent, Gemini Flash engineers",
 		Superintendent: "claude-sonnet-4-6",
 		Engineer:       "gemini-2.5-flash",
 	},
 	"claude-api-standard": {
+		Description:    "Anthropic API (ANTHROPIC_API_KEY), Sonnet + Haiku",
 		Superintendent: "anthropic/claude-sonnet-4-6",
 		Engineer:       "anthropic/claude-haiku-4-5",
 	},
 	"claude-api-cheap": {
+		Description:    "Anthropic API (ANTHROPIC_API_KEY), Haiku for both roles",
 		Superintendent: "anthropic/claude-haiku-4-5",
 		Engineer:       "anthropic/claude-haiku-4-5",
 	},
 }
 
-// cmdUse switches the active model preset in madflow.toml.
-func cmdUse(preset string) error {
-	if preset == "" {
-		return fmt.Errorf("usage: madflow use <preset>\n\nAvailable presets:\n%s", formatPresets())
+// builtinPresetNames lists the built-in presets in display order.
+var builtinPresetNames = []string{
+	"claude", "gemini", "claude-cheap", "gemini-cheap", "hybrid", "hybrid-cheap",
+	"claude-api-standard", "claude-api-cheap",
+}
+
+// userPresetsFile is the file under ~/.madflow with the user's own presets.
+const userPresetsFile = "presets.toml"
+
+// Preset sources, in increasing priority.
+const (
+	presetBuiltin = "built-in"
+	presetUser    = "user"
+	presetProject = "project"
+)
+
+// namedPreset is a preset together with its name and where it was defined.
+type namedPreset struct {
+	name   string
+	source string
+	config.Preset
+}
+
+// loadPresets returns the built-in presets followed by the presets defined in
+// userFile and in the project config. A preset replaces an earlier one with
+// the same name. Missing files are ignored.
+func loadPresets(userFile, configPath string) ([]namedPreset, error) {
+	var list []namedPreset
+	add := func(name, source string, p config.Preset) {
+		for i := range list {
+			if list[i].name == name {
+				list[i] = namedPreset{name: name, source: source, Preset: p}
+				return
+			}
+		}
+		list = append(list, namedPreset{name: name, source: source, Preset: p})
+	}
+	for _, name := range builtinPresetNames {
+		add(name, presetBuiltin, presets[name])
 	}
+	for _, f := range []struct{ path, source string }{{userFile, presetUser}, {configPath, presetProject}} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			continue
+		}
+		custom, err := config.ReadPresets(f.path)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(custom))
+		for name := range custom {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(name, f.source, custom[name])
+		}
+	}
+	return list, nil
+}
 
-	models, ok := presets[preset]
-	if !ok {
-		return fmt.Errorf("unknown preset %q\n\nAvailable presets:\n%s", preset, formatPresets())
+// userPresetsPath returns ~/.madflow/presets.toml, or "" if the home
+// directory is unknown.
+func userPresetsPath() string {
+	base, err := project.BaseDir()
+	if err != nil {
+		return ""
 	}
+	return filepath.Join(base, userPresetsFile)
+}
 
-	configPath, err := findConfigPath()
+// cmdUse switches the active model preset in madflow.toml, or with --show
+// reports which preset the current config matches.
+func cmdUse(args []string) error {
+	// Presets can be listed outside a project; only the built-in and user
+	// presets are shown then.
+	configPath, pathErr := findConfigPath()
+	if pathErr != nil {
+		configPath = ""
+	}
+	list, err := loadPresets(userPresetsPath(), configPath)
 	if err != nil {
 		return err
 	}
 
+	if len(args) == 0 || args[0] == "" {
+		return fmt.Errorf("usage: madflow use <preset> | --show\n\nAvailable presets:\n%s", formatPresets(list))
+	}
+	if pathErr != nil {
+		return pathErr
+	}
+	if args[0] == "--show" {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		return showPreset(os.Stdout, cfg, list)
+	}
+
+	p, ok := findPreset(list, args[0])
+	if !ok {
+		return fmt.Errorf("unknown preset %q\n\nAvailable presets:\n%s", args[0], formatPresets(list))
+	}
+
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return fmt.Errorf("read config: %w", err)
 	}
 
-	updated, err := updateModelsSection(string(data), models.Superintendent, models.Engineer)
+	updated, err := applyPreset(string(data), p.Preset)
 	if err != nil {
 		return fmt.Errorf("update config: %w", err)
 	}
@@ -82,42 +178,185 @@ func cmdUse(preset string) error {
 		return fmt.Errorf("write config: %w", err)
 	}
 
-	fmt.Printf("Switched to preset %q:\n  superintendent = %q\n  engineer       = %q\n", preset, models.Superintendent, models.Engineer)
+	fmt.Printf("Switched to preset %q (%s):\n  superintendent = %q\n  engineer       = %q\n", p.name, p.source, p.Superintendent, p.Engineer)
+	for _, k := range sortedKeys(p.Agent) {
+		fmt.Printf("  agent.%s = %v\n", k, p.Agent[k])
+	}
 	fmt.Printf("Config updated: %s\n", configPath)
 	return nil
 }
 
+func findPreset(list []namedPreset, name string) (namedPreset, bool) {
+	for _, p := range list {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return namedPreset{}, false
+}
+
+// applyPreset writes the preset's models and [agent] settings into content.
+func applyPreset(content string, p config.Preset) (string, error) {
+	updated, err := updateModelsSection(content, p.Superintendent, p.Engineer)
+	if err != nil {
+		return "", err
+	}
+	for _, k := range sortedKeys(p.Agent) {
+		updated, err = setAgentSetting(updated, k, p.Agent[k])
+		if err != nil {
+			return "", err
+		}
+	}
+	return updated, nil
+}
+
+// showPreset prints the presets that cfg matches. A preset matches when both
+// models and every [agent] setting it defines equal the current config.
+func showPreset(w io.Writer, cfg *config.Config, list []namedPreset) error {
+	current, err := agentSettings(cfg.Agent)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Current models: superintendent=%s, engineer=%s\n", cfg.Agent.Models.Superintendent, cfg.Agent.Models.Engineer)
+	var matches []string
+	for _, p := range list {
+		if p.Superintendent != cfg.Agent.Models.Superintendent || p.Engineer != cfg.Agent.Models.Engineer {
+			continue
+		}
+		match := true
+		for k, v := range p.Agent {
+			if fmt.Sprint(current[k]) != fmt.Sprint(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			matches = append(matches, fmt.Sprintf("%s (%s)", p.name, p.source))
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(w, "No preset matches the current config.")
+		return nil
+	}
+	fmt.Fprintf(w, "Matching preset: %s\n", strings.Join(matches, ", "))
+	return nil
+}
+
+// agentSettings returns the [agent] settings of a as TOML values.
+func agentSettings(a config.AgentConfig) (map[string]any, error) {
+	data, err := toml.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]any)
+	if _, err := toml.Decode(string(data), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	tableHeaderRe    = regexp.MustCompile(`^\s*\[`)
+	superintendentRe = regexp.MustCompile(`^(\s*superintendent\s*=\s*)".+"`)
+	engineerRe       = regexp.MustCompile(`^(\s*engineer\s*=\s*)".+"`)
+)
+
+// sectionRange returns the lines [start, end) that belong to the TOML table
+// section (excluding its header), or ok=false if the table is not declared.
+func sectionRange(lines []string, section string) (start, end int, ok bool) {
+	header := regexp.MustCompile(`^\s*\[\s*` + regexp.QuoteMeta(section) + `\s*\]\s*(#.*)?$`)
+	for i, l := range lines {
+		if !header.MatchString(l) {
+			continue
+		}
+		end = len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if tableHeaderRe.MatchString(lines[j]) {
+				end = j
+				break
+			}
+		}
+		return i + 1, end, true
+	}
+	return 0, 0, false
+}
+
 // updateModelsSection replaces the superintendent and engineer model values
 // in the [agent.models] section of a TOML config string.
-// It preserves all other content (comments, ordering, unrelated keys).
+// It preserves all other content (comments, ordering, unrelated keys),
+// including model names in other tables such as [presets.<name>].
 func updateModelsSection(content, superintendent, engineer string) (string, error) {
-	reSuper := regexp.MustCompile(`(?m)^(\s*superintendent\s*=\s*)".+"`)
-	reEng := regexp.MustCompile(`(?m)^(\s*engineer\s*=\s*)".+"`)
-
-	result := reSuper.ReplaceAllString(content, `${1}"`+superintendent+`"`)
-	result = reEng.ReplaceAllString(result, `${1}"`+engineer+`"`)
-
-	// Verify both keys exist after replacement (they should be present in a valid config).
-	if !reSuper.MatchString(content) {
+	lines := strings.Split(content, "\n")
+	start, end, ok := sectionRange(lines, "agent.models")
+	if !ok {
 		return "", fmt.Errorf("superintendent key not found in [agent.models]")
 	}
-	if !reEng.MatchString(content) {
-		return "", fmt.Errorf("engineer key not found in [agent.models]")
+	for _, kv := range []struct {
+		name  string
+		re    *regexp.Regexp
+		value string
+	}{{"superintendent", superintendentRe, superintendent}, {"engineer", engineerRe, engineer}} {
+		found := false
+		for i := start; i < end; i++ {
+			if kv.re.MatchString(lines[i]) {
+				lines[i] = kv.re.ReplaceAllString(lines[i], `${1}"`+kv.value+`"`)
+				found = true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("%s key not found in [agent.models]", kv.name)
+		}
 	}
+	return strings.Join(lines, "\n"), nil
+}
 
-	return result, nil
+// setAgentSetting sets key in the [agent] table of a TOML config string,
+// replacing an existing assignment or adding one after the table header.
+// The table is appended if the config has none.
+func setAgentSetting(content, key string, value any) (string, error) {
+	data, err := toml.Marshal(map[string]any{key: value})
+	if err != nil {
+		return "", fmt.Errorf("encode agent.%s: %w", key, err)
+	}
+	line := strings.TrimSpace(string(data))
+
+	lines := strings.Split(content, "\n")
+	start, end, ok := sectionRange(lines, "agent")
+	if !ok {
+		return strings.TrimRight(content, "\n") + "\n\n[agent]\n" + line + "\n", nil
+	}
+	keyRe := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=`)
+	for i := start; i < end; i++ {
+		if keyRe.MatchString(lines[i]) {
+			lines[i] = line
+			return strings.Join(lines, "\n"), nil
+		}
+	}
+	lines = append(lines[:start], append([]string{line}, lines[start:]...)...)
+	return strings.Join(lines, "\n"), nil
 }
 
 // formatPresets returns a human-readable list of available presets.
-func formatPresets() string {
-	names := []string{
-		"claude", "gemini", "claude-cheap", "gemini-cheap", "hybrid", "hybrid-cheap",
-		"claude-api-standard", "claude-api-cheap",
-	}
+func formatPresets(list []namedPreset) string {
 	var sb strings.Builder
-	for _, name := range names {
-		m := presets[name]
-		fmt.Fprintf(&sb, "  %-22s  superintendent=%s, engineer=%s\n", name, m.Superintendent, m.Engineer)
+	for _, p := range list {
+		fmt.Fprintf(&sb, "  %-22s  %-9s  superintendent=%s, engineer=%s", p.name, p.source, p.Superintendent, p.Engineer)
+		for _, k := range sortedKeys(p.Agent) {
+			fmt.Fprintf(&sb, ", %s=%v", k, p.Agent[k])
+		}
+		sb.WriteString("\n")
+		if p.Description != "" {
+			fmt.Fprintf(&sb, "  %-22s  %-9s  %s\n", "", "", p.Description)
+		}
 	}
 	return sb.String()
 }
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ytnobody/madflow/internal/config"
 )
 
 func TestPresets_AllDefined(t *testing.T) {
@@ -129,7 +134,11 @@ superintendent = "claude-sonnet-4-6"
 }
 
 func TestFormatPresets(t *testing.T) {
-	out := formatPresets()
+	list, err := loadPresets("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := formatPresets(list)
 	expectedPresets := []string{"claude", "gemini", "claude-cheap", "gemini-cheap", "hybrid", "hybrid-cheap", "claude-api-standard", "claude-api-cheap"}
 	for _, name := range expectedPresets {
 		if !strings.Contains(out, name) {
@@ -137,3 +146,148 @@ func TestFormatPresets(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadPresets_Custom(t *testing.T) {
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "presets.toml")
+	if err := os.WriteFile(userFile, []byte(`
+[presets.fast]
+description = "new models"
+superintendent = "claude-sonnet-4-7"
+engineer = "claude-haiku-4-7"
+
+[presets.fast.agent]
+max_teams = 6
+
+[presets.claude]
+superintendent = "claude-opus-4-6"
+engineer = "claude-opus-4-6"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(configPath, []byte(`
+[presets.fast]
+superintendent = "gemini-2.5-pro"
+engineer = "gemini-2.5-flash"
+
+[presets.fast.agent]
+gemini_rpm = 30
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := loadPresets(userFile, configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claude, ok := findPreset(list, "claude")
+	if !ok || claude.source != presetUser || claude.Engineer != "claude-opus-4-6" {
+		t.Errorf("user preset should replace the built-in: %+v", claude)
+	}
+	fast, ok := findPreset(list, "fast")
+	if !ok || fast.source != presetProject || fast.Engineer != "gemini-2.5-flash" || fast.Agent["gemini_rpm"] != int64(30) {
+		t.Errorf("project preset should replace the user preset: %+v", fast)
+	}
+
+	out := formatPresets(list)
+	if !strings.Contains(out, "gemini_rpm=30") || !strings.Contains(out, "Claude CLI, Haiku engineers") {
+		t.Errorf("unexpected preset list:\n%s", out)
+	}
+}
+
+func TestLoadPresets_InvalidSetting(t *testing.T) {
+	userFile := filepath.Join(t.TempDir(), "presets.toml")
+	if err := os.WriteFile(userFile, []byte(`
+[presets.bad]
+superintendent = "claude-sonnet-4-6"
+engineer = "claude-haiku-4-5"
+
+[presets.bad.agent]
+max_team = 6
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPresets(userFile, ""); err == nil || !strings.Contains(err.Error(), `unknown setting "max_team"`) {
+		t.Errorf("expected unknown setting error, got %v", err)
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	input := `[project]
+name = "test"
+
+[agent]
+max_teams = 2
+
+[agent.models]
+superintendent = "claude-opus-4-6"
+engineer = "claude-sonnet-4-6"
+
+[presets.mine]
+superintendent = "claude-opus-4-6"
+engineer = "claude-opus-4-6"
+`
+	result, err := applyPreset(input, config.Preset{
+		Superintendent: "gemini-2.5-pro",
+		Engineer:       "gemini-2.5-flash",
+		Agent:          map[string]any{"max_teams": int64(6), "gemini_rpm": int64(30)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"[agent]\ngemini_rpm = 30\nmax_teams = 6\n",
+		"[agent.models]\nsuperintendent = \"gemini-2.5-pro\"\nengineer = \"gemini-2.5-flash\"\n",
+		// Presets defined in the config are left alone.
+		"[presets.mine]\nsuperintendent = \"claude-opus-4-6\"\nengineer = \"claude-opus-4-6\"\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestSetAgentSetting_NoAgentTable(t *testing.T) {
+	result, err := setAgentSetting("[project]\nname = \"x\"\n", "gemini_rpm", int64(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "[project]\nname = \"x\"\n\n[agent]\ngemini_rpm = 20\n" {
+		t.Errorf("unexpected result:\n%s", result)
+	}
+}
+
+func TestShowPreset(t *testing.T) {
+	list, err := loadPresets("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list = append(list, namedPreset{name: "cheap-4", source: presetUser, Preset: config.Preset{
+		Superintendent: "claude-sonnet-4-6",
+		Engineer:       "claude-haiku-4-5",
+		Agent:          map[string]any{"max_teams": int64(4)},
+	}})
+
+	cfg := &config.Config{Agent: config.AgentConfig{
+		MaxTeams: 4,
+		Models:   config.ModelConfig{Superintendent: "claude-sonnet-4-6", Engineer: "claude-haiku-4-5"},
+	}}
+	var buf bytes.Buffer
+	if err := showPreset(&buf, cfg, list); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Matching preset: claude-cheap (built-in), cheap-4 (user)") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	cfg.Agent.MaxTeams = 8
+	cfg.Agent.Models.Engineer = "gemini-2.5-flash-lite"
+	buf.Reset()
+	if err := showPreset(&buf, cfg, list); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "No preset matches") {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
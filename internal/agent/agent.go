@@ -12,7 +12,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ytnobody/madflow/internal/audit"
 	"github.com/ytnobody/madflow/internal/chatlog"
+	"github.com/ytnobody/madflow/internal/filelock"
+	"github.com/ytnobody/madflow/internal/redact"
 	"github.com/ytnobody/madflow/internal/reset"
 )
 
@@ -27,8 +30,27 @@ type Agent struct {
 	Language      string
 	Dormancy      *Dormancy
 	Throttle      *Throttle
-	ready         chan struct{}
-	readyOnce     sync.Once
+	// Redactor masks secrets in chatlog lines rescued from text responses.
+	Redactor  *redact.Redactor
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// pending holds a configuration set by Reconfigure that has not been
+	// applied yet. It is applied at the next context reset.
+	pendingMu sync.Mutex
+	pending   *AgentConfig
+
+	// resume is non-nil while the agent is paused and is closed by Resume.
+	pauseMu sync.Mutex
+	resume  chan struct{}
+
+	// turnMu is held while the agent's process handles a turn, so that
+	// Handoff does not interrupt one.
+	turnMu sync.Mutex
+
+	// handoffMemo is the memo of the previous engineer of the task. It
+	// replaces the agent's own latest memo on its first start.
+	handoffMemo string
 }
 
 type AgentConfig struct {
@@ -46,13 +68,57 @@ type AgentConfig struct {
 	Process       Process
 	Dormancy      *Dormancy
 	Throttle      *Throttle
+	// Sandbox optionally isolates the commands this agent executes.
+	Sandbox *Sandbox
+	// AuditLog receives a record of every command this agent executes.
+	// nil disables auditing.
+	AuditLog *audit.Log
+	// Redactor masks secrets in chatlog writes and in gh commands that publish
+	// text to GitHub. nil disables redaction.
+	Redactor *redact.Redactor
+	// IssueID is the issue a team agent works on. It is recorded in the
+	// audit log; empty for resident agents.
+	IssueID string
+	// HandoffMemo is a memo written by the previous agent of this task (see
+	// Handoff). It is given to the agent in its initial prompt.
+	HandoffMemo string
 }
 
 func NewAgent(cfg AgentConfig) *Agent {
+	proc := newProcess(cfg)
+
+	lang := cfg.Language
+	if lang == "" {
+		lang = "en"
+	}
+
+	return &Agent{
+		ID:            cfg.ID,
+		Process:       proc,
+		ChatLog:       chatlog.New(cfg.ChatLogPath).WithRedactor(cfg.Redactor),
+		MemosDir:      cfg.MemosDir,
+		ResetInterval: cfg.ResetInterval,
+		SystemPrompt:  cfg.SystemPrompt,
+		OriginalTask:  cfg.OriginalTask,
+		Language:      lang,
+		Dormancy:      cfg.Dormancy,
+		Throttle:      cfg.Throttle,
+		Redactor:      cfg.Redactor,
+		handoffMemo:   cfg.HandoffMemo,
+		ready:         make(chan struct{}),
+	}
+}
+
+// newProcess returns cfg.Process, or the backend selected by cfg.Model.
+func newProcess(cfg AgentConfig) Process {
 	var proc Process
 	if cfg.Process != nil {
 		proc = cfg.Process
 	} else {
+		var auditor *CommandAuditor
+		if cfg.AuditLog != nil {
+			auditor = &CommandAuditor{Log: cfg.AuditLog, Agent: cfg.ID.String(), Issue: cfg.IssueID}
+		}
 		switch {
 		case cfg.Model == "test":
 			proc = &noopProcess{}
@@ -62,6 +128,9 @@ func NewAgent(cfg AgentConfig) *Agent {
 				Model:        cfg.Model,
 				WorkDir:      cfg.WorkDir,
 				BashTimeout:  cfg.BashTimeout,
+				Sandbox:      cfg.Sandbox,
+				Auditor:      auditor,
+				Redactor:     cfg.Redactor,
 			})
 		case strings.HasPrefix(cfg.Model, "anthropic/"):
 			proc = NewAnthropicAPIProcess(AnthropicAPIOptions{
@@ -69,6 +138,9 @@ func NewAgent(cfg AgentConfig) *Agent {
 				Model:        cfg.Model,
 				WorkDir:      cfg.WorkDir,
 				BashTimeout:  cfg.BashTimeout,
+				Sandbox:      cfg.Sandbox,
+				Auditor:      auditor,
+				Redactor:     cfg.Redactor,
 			})
 		case strings.HasPrefix(cfg.Model, "copilot/"):
 			proc = NewCopilotCLIProcess(CopilotCLIOptions{
@@ -76,34 +148,19 @@ func NewAgent(cfg AgentConfig) *Agent {
 				Model:        cfg.Model,
 				WorkDir:      cfg.WorkDir,
 				BashTimeout:  cfg.BashTimeout,
+				Sandbox:      cfg.Sandbox,
 			})
 		default:
 			proc = NewClaudeStreamProcess(ClaudeOptions{
 				SystemPrompt: cfg.SystemPrompt,
 				Model:        cfg.Model,
 				WorkDir:      cfg.WorkDir,
+				Sandbox:      cfg.Sandbox.WithWritable(claudeStatePaths()...),
+				Auditor:      auditor,
 			})
 		}
 	}
-
-	lang := cfg.Language
-	if lang == "" {
-		lang = "en"
-	}
-
-	return &Agent{
-		ID:            cfg.ID,
-		Process:       proc,
-		ChatLog:       chatlog.New(cfg.ChatLogPath),
-		MemosDir:      cfg.MemosDir,
-		ResetInterval: cfg.ResetInterval,
-		SystemPrompt:  cfg.SystemPrompt,
-		OriginalTask:  cfg.OriginalTask,
-		Language:      lang,
-		Dormancy:      cfg.Dormancy,
-		Throttle:      cfg.Throttle,
-		ready:         make(chan struct{}),
-	}
+	return proc
 }
 
 func (a *Agent) Ready() <-chan struct{} { return a.ready }
@@ -111,14 +168,21 @@ func (a *Agent) Ready() <-chan struct{} { return a.ready }
 func (a *Agent) markReady() { a.readyOnce.Do(func() { close(a.ready) }) }
 
 func (a *Agent) Run(ctx context.Context, msgCh <-chan chatlog.Message) error {
-	defer a.Process.Close()
+	// a.Process may be replaced by a reconfiguration; close the current one.
+	defer func() { a.Process.Close() }()
 
 	timer := reset.NewTimer(a.ResetInterval)
 	recipient := a.ID.String()
 	log.Printf("[%s] agent started", recipient)
 
-	memo, _ := reset.LoadLatestMemo(a.MemosDir, recipient)
+	memo := a.handoffMemo
+	a.handoffMemo = ""
+	if memo == "" {
+		memo, _ = reset.LoadLatestMemo(a.MemosDir, recipient)
+	}
+	a.turnMu.Lock()
 	_, initErr := a.sendWithRetry(ctx, a.buildInitialPrompt(memo))
+	a.turnMu.Unlock()
 	a.markReady()
 	if initErr != nil {
 		if ctx.Err() != nil {
@@ -127,6 +191,10 @@ func (a *Agent) Run(ctx context.Context, msgCh <-chan chatlog.Message) error {
 		log.Printf("[%s] initial send failed: %v", recipient, initErr)
 	}
 	for {
+		if !a.waitResumed(ctx) {
+			log.Printf("[%s] agent stopped", recipient)
+			return ctx.Err()
+		}
 		select {
 		case <-ctx.Done():
 			log.Printf("[%s] agent stopped", recipient)
@@ -135,6 +203,12 @@ func (a *Agent) Run(ctx context.Context, msgCh <-chan chatlog.Message) error {
 			if !ok {
 				return nil
 			}
+			// A message received just as the agent was paused is held until
+			// it is resumed.
+			if !a.waitResumed(ctx) {
+				log.Printf("[%s] agent stopped", recipient)
+				return ctx.Err()
+			}
 			// Drain: collect all pending messages from the channel
 			messages := []chatlog.Message{msg}
 		drain:
@@ -149,32 +223,46 @@ func (a *Agent) Run(ctx context.Context, msgCh <-chan chatlog.Message) error {
 					break drain
 				}
 			}
-			if timer.Expired() {
-				if err := a.performReset(ctx, timer); err != nil {
-					log.Printf("[%s] reset failed: %v", recipient, err)
-				}
-			}
-			prompt := buildMessagePrompt(messages, a.Language)
-			response, err := a.send(ctx, prompt)
+			a.turnMu.Lock()
+			err := a.handleMessages(ctx, timer, messages)
+			a.turnMu.Unlock()
 			if err != nil {
-				if ctx.Err() != nil {
-					return ctx.Err()
-				}
-				if IsMaxIterationsError(err) {
-					log.Printf("[%s] max iterations reached, restarting agent", recipient)
-					return err
-				}
-				log.Printf("[%s] send failed: %v", recipient, err)
-				continue
-			}
-			if response != "" {
-				log.Printf("[%s] response: %s", recipient, truncate(response, 200))
-				a.rescueChatLogMessages(response)
+				return err
 			}
 		}
 	}
 }
 
+// handleMessages sends a batch of chatlog messages to the process, resetting
+// the context first when the reset timer has expired. It returns an error
+// only when Run must return.
+func (a *Agent) handleMessages(ctx context.Context, timer *reset.Timer, messages []chatlog.Message) error {
+	recipient := a.ID.String()
+	if timer.Expired() {
+		if err := a.performReset(ctx, timer); err != nil {
+			log.Printf("[%s] reset failed: %v", recipient, err)
+		}
+	}
+	prompt := buildMessagePrompt(messages, a.Language)
+	response, err := a.send(ctx, prompt)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if IsMaxIterationsError(err) {
+			log.Printf("[%s] max iterations reached, restarting agent", recipient)
+			return err
+		}
+		log.Printf("[%s] send failed: %v", recipient, err)
+		return nil
+	}
+	if response != "" {
+		log.Printf("[%s] response: %s", recipient, truncate(response, 200))
+		a.rescueChatLogMessages(response)
+	}
+	return nil
+}
+
 // buildMessagePrompt creates a single prompt from one or more messages.
 func buildMessagePrompt(msgs []chatlog.Message, lang string) string {
 	m := getMessages(lang)
@@ -194,23 +282,26 @@ func buildMessagePrompt(msgs []chatlog.Message, lang string) string {
 // rescueChatLogMessages detects chatlog-formatted lines in a text response
 // and writes them to the chatlog file. This handles the case where the AI
 // model returns chatlog messages as text output instead of using bash echo.
+// Secrets in rescued lines are masked before they are written.
 func (a *Agent) rescueChatLogMessages(response string) {
-	f, err := os.OpenFile(a.ChatLog.Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return
-	}
-	defer f.Close()
-
+	var rescued []string
 	for _, line := range strings.Split(response, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 		if _, err := chatlog.ParseMessage(line); err == nil {
-			fmt.Fprintln(f, line)
-			log.Printf("[%s] rescued chatlog message from text response", a.ID.String())
+			rescued = append(rescued, a.Redactor.Redact(chatlog.DestChatlog, line))
 		}
 	}
+	if len(rescued) == 0 {
+		return
+	}
+	if err := filelock.AppendLines(a.ChatLog.Path(), rescued...); err != nil {
+		log.Printf("[%s] rescue chatlog messages: %v", a.ID.String(), err)
+		return
+	}
+	log.Printf("[%s] rescued %d chatlog message(s) from text response", a.ID.String(), len(rescued))
 }
 
 func (a *Agent) performReset(ctx context.Context, timer *reset.Timer) error {
@@ -229,9 +320,12 @@ func (a *Agent) performReset(ctx context.Context, timer *reset.Timer) error {
 	}
 	log.Printf("[%s] memo saved: %s", recipient, filepath.Base(memoPath))
 
-	// Kill the current process so next Send() starts a fresh one with clean context.
-	if err := a.Process.Reset(ctx); err != nil {
-		log.Printf("[%s] process reset failed: %v", recipient, err)
+	// Kill the current process so next Send() starts a fresh one with clean
+	// context, or replace it when a new configuration is pending.
+	if !a.applyPending(timer) {
+		if err := a.Process.Reset(ctx); err != nil {
+			log.Printf("[%s] process reset failed: %v", recipient, err)
+		}
 	}
 
 	memoContent, _ := os.ReadFile(memoPath)
@@ -244,6 +338,133 @@ func (a *Agent) performReset(ctx context.Context, timer *reset.Timer) error {
 	return nil
 }
 
+// Reconfigure schedules cfg to replace the agent's model, system prompt,
+// bash timeout, reset interval and language at the next context reset, so
+// that the current conversation is distilled into a memo first. ID,
+// OriginalTask and the chatlog are kept. A later call replaces an earlier
+// pending configuration.
+func (a *Agent) Reconfigure(cfg AgentConfig) {
+	a.pendingMu.Lock()
+	a.pending = &cfg
+	a.pendingMu.Unlock()
+}
+
+// HasPendingConfig reports whether a Reconfigure call is waiting for the next
+// context reset.
+func (a *Agent) HasPendingConfig() bool {
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+	return a.pending != nil
+}
+
+// Pause stops the agent from consuming chatlog messages. The process and its
+// conversation context are kept, and a turn already in progress completes.
+// Messages that arrive while paused are delivered, in order, after Resume.
+func (a *Agent) Pause() {
+	a.pauseMu.Lock()
+	defer a.pauseMu.Unlock()
+	if a.resume == nil {
+		a.resume = make(chan struct{})
+	}
+}
+
+// Resume lets a paused agent consume chatlog messages again.
+func (a *Agent) Resume() {
+	a.pauseMu.Lock()
+	defer a.pauseMu.Unlock()
+	if a.resume != nil {
+		close(a.resume)
+		a.resume = nil
+	}
+}
+
+// Paused reports whether the agent is paused.
+func (a *Agent) Paused() bool {
+	a.pauseMu.Lock()
+	defer a.pauseMu.Unlock()
+	return a.resume != nil
+}
+
+// WhileIdle runs fn while the agent is between turns, holding back its next
+// turn until fn returns. It returns false without running fn when the agent
+// is handling a turn or is paused.
+func (a *Agent) WhileIdle(fn func()) bool {
+	if a.Paused() || !a.turnMu.TryLock() {
+		return false
+	}
+	defer a.turnMu.Unlock()
+	fn()
+	return true
+}
+
+// waitResumed blocks while the agent is paused. It returns false if ctx is
+// done first.
+func (a *Agent) waitResumed(ctx context.Context) bool {
+	for {
+		a.pauseMu.Lock()
+		ch := a.resume
+		a.pauseMu.Unlock()
+		if ch == nil {
+			return true
+		}
+		log.Printf("[%s] paused", a.ID.String())
+		select {
+		case <-ch:
+			log.Printf("[%s] resumed", a.ID.String())
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// Handoff pauses the agent and asks it to distill its work into a memo for
+// the agent that takes over its task. A turn in progress is completed first.
+// The memo is saved in MemosDir under memoID and its path is returned. The
+// agent stays paused; the caller is expected to stop it.
+func (a *Agent) Handoff(ctx context.Context, memoID string) (string, error) {
+	a.Pause()
+	for !a.turnMu.TryLock() {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("wait for the current turn: %w", ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	defer a.turnMu.Unlock()
+
+	distilled, err := a.send(ctx, reset.GetDistillPrompt(a.Language))
+	if err != nil {
+		return "", fmt.Errorf("distill failed: %w", err)
+	}
+	return reset.SaveMemoWithLang(a.MemosDir, parseDistilledMemo(memoID, distilled), a.Language)
+}
+
+// applyPending swaps in the pending configuration, if any. The old process is
+// closed and a new one is built. It reports whether a configuration was applied.
+func (a *Agent) applyPending(timer *reset.Timer) bool {
+	a.pendingMu.Lock()
+	cfg := a.pending
+	a.pending = nil
+	a.pendingMu.Unlock()
+	if cfg == nil {
+		return false
+	}
+
+	if err := a.Process.Close(); err != nil {
+		log.Printf("[%s] close process before reconfiguration: %v", a.ID.String(), err)
+	}
+	a.Process = newProcess(*cfg)
+	a.SystemPrompt = cfg.SystemPrompt
+	a.ResetInterval = cfg.ResetInterval
+	a.Throttle = cfg.Throttle
+	if cfg.Language != "" {
+		a.Language = cfg.Language
+	}
+	timer.SetInterval(cfg.ResetInterval)
+	log.Printf("[%s] applied new configuration (model: %s)", a.ID.String(), cfg.Model)
+	return true
+}
+
 func (a *Agent) buildInitialPrompt(memo string) string {
 	m := getMessages(a.Language)
 	var sb strings.Builder
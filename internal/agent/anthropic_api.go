@@ -8,9 +8,10 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/redact"
 )
 
 const (
@@ -26,6 +27,12 @@ type AnthropicAPIOptions struct {
 	Model        string
 	WorkDir      string
 	BashTimeout  time.Duration
+	// Sandbox optionally isolates bash tool commands. nil runs them directly.
+	Sandbox *Sandbox
+	// Auditor records every bash tool command. nil disables auditing.
+	Auditor *CommandAuditor
+	// Redactor masks secrets in bash commands that publish text to GitHub.
+	Redactor *redact.Redactor
 }
 
 // AnthropicAPIProcess sends prompts to the Anthropic Messages API using ANTHROPIC_API_KEY.
@@ -306,32 +313,12 @@ func (a *AnthropicAPIProcess) executeTool(ctx context.Context, toolName string,
 
 // runBash executes a bash command and returns (output, isError).
 func (a *AnthropicAPIProcess) runBash(ctx context.Context, command string) (string, bool) {
-	if a.opts.BashTimeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, a.opts.BashTimeout)
-		defer cancel()
-	}
-
-	cmd := exec.CommandContext(ctx, "bash", "-c", command)
-	if a.opts.WorkDir != "" {
-		cmd.Dir = a.opts.WorkDir
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	result := strings.TrimSpace(stdout.String())
-	if stderr.Len() > 0 {
-		if result != "" {
-			result += "\n"
-		}
-		result += "STDERR:\n" + strings.TrimSpace(stderr.String())
-	}
-	if result == "" && err != nil {
-		result = fmt.Sprintf("command failed: %v", err)
-	}
-
-	return result, err != nil
+	return execBash(ctx, bashRequest{
+		Command:  command,
+		WorkDir:  a.opts.WorkDir,
+		Timeout:  a.opts.BashTimeout,
+		Sandbox:  a.opts.Sandbox,
+		Auditor:  a.opts.Auditor,
+		Redactor: a.opts.Redactor,
+	})
 }
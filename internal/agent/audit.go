@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"log"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
+)
+
+// CommandAuditor records the commands executed by one agent to the audit log.
+// A nil *CommandAuditor (or one with a nil Log) is a safe no-op.
+type CommandAuditor struct {
+	Log   *audit.Log
+	Agent string // agent ID, e.g. "engineer-1"
+	Issue string // issue the agent is working on; empty for resident agents
+}
+
+// Record appends one command execution to the audit log. The command and
+// error text are passed through SanitizeLog so that credentials typed on the
+// command line do not end up in the audit file. Write failures are logged,
+// never returned: auditing must not break the agent loop.
+func (a *CommandAuditor) Record(source, workDir, command string, exitCode int, duration time.Duration, errMsg string) {
+	if a == nil || a.Log == nil {
+		return
+	}
+	err := a.Log.Record(audit.Entry{
+		Agent:      a.Agent,
+		Issue:      a.Issue,
+		Source:     source,
+		WorkDir:    workDir,
+		Command:    SanitizeLog(command),
+		ExitCode:   exitCode,
+		DurationMS: duration.Milliseconds(),
+		Error:      SanitizeLog(errMsg),
+	})
+	if err != nil {
+		log.Printf("[audit] failed to record command for %s: %v", a.Agent, err)
+	}
+}
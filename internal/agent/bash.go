@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
+	"github.com/ytnobody/madflow/internal/redact"
+)
+
+// bashRequest describes a single bash tool invocation made by an API backend.
+type bashRequest struct {
+	Command string
+	WorkDir string
+	Timeout time.Duration
+	Sandbox *Sandbox
+	Auditor *CommandAuditor
+	// Redactor masks secrets in the text that commands publish to GitHub
+	// (gh issue comment, gh pr create, ...) before they are executed.
+	Redactor *redact.Redactor
+}
+
+// execBash runs req.Command with `bash -c` and returns (output, isError).
+// The command is checked against the sandbox policy before execution and,
+// when a sandbox is configured, executed inside it. Stderr is sanitized
+// with SanitizeLog because it is fed back to the model verbatim.
+// Every invocation, including rejected ones, is recorded via req.Auditor.
+func execBash(ctx context.Context, req bashRequest) (string, bool) {
+	var cleanup func()
+	req.Command, cleanup = req.Redactor.RedactGitHubCommand(req.Command, req.WorkDir)
+	defer cleanup()
+	if err := req.Sandbox.Check(req.Command); err != nil {
+		req.Auditor.Record(audit.SourceBash, req.WorkDir, req.Command, -1, 0, err.Error())
+		return err.Error(), true
+	}
+
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	cmd := req.Sandbox.Command(ctx, req.WorkDir, "bash", "-c", req.Command)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	exitCode, errMsg := exitStatus(ctx, err)
+	req.Auditor.Record(audit.SourceBash, req.WorkDir, req.Command, exitCode, time.Since(start), errMsg)
+
+	result := strings.TrimSpace(stdout.String())
+	if stderr.Len() > 0 {
+		if result != "" {
+			result += "\n"
+		}
+		result += "STDERR:\n" + SanitizeLog(strings.TrimSpace(stderr.String()))
+	}
+	if result == "" && err != nil {
+		result = fmt.Sprintf("command failed: %v", err)
+	}
+
+	return result, err != nil
+}
+
+// exitStatus converts the error returned by exec.Cmd.Run into an exit code and,
+// when the command did not exit normally, a short description for the audit log.
+func exitStatus(ctx context.Context, err error) (int, string) {
+	if err == nil {
+		return 0, ""
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return -1, "timed out"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return exitErr.ExitCode(), ""
+	}
+	return -1, err.Error()
+}
@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -24,6 +24,13 @@ type ClaudeOptions struct {
 	WorkDir      string
 	AllowedTools []string
 	MaxBudgetUSD float64
+	// Sandbox optionally runs the claude process itself inside a sandbox.
+	// Claude CLI executes tools internally, so command patterns cannot be
+	// checked per command; only filesystem/network isolation applies.
+	Sandbox *Sandbox
+	// Auditor records Bash tool invocations parsed from the stream-json
+	// output (ClaudeStreamProcess only). nil disables auditing.
+	Auditor *CommandAuditor
 }
 
 // ClaudeProcess manages Claude Code subprocess invocations.
@@ -42,10 +49,7 @@ func NewClaudeProcess(opts ClaudeOptions) *ClaudeProcess {
 func (c *ClaudeProcess) Send(ctx context.Context, prompt string) (string, error) {
 	args := c.buildArgs(prompt)
 
-	cmd := exec.CommandContext(ctx, "claude", args...)
-	if c.opts.WorkDir != "" {
-		cmd.Dir = c.opts.WorkDir
-	}
+	cmd := c.opts.Sandbox.Command(ctx, c.opts.WorkDir, "claude", args...)
 
 	// Remove CLAUDECODE/CLAUDE_CODE_ENTRYPOINT env vars to allow nested invocations.
 	// MADFLOW intentionally spawns claude as subprocesses.
@@ -156,6 +160,20 @@ func IsRateLimitError(err error) bool {
 		strings.Contains(msg, "resourceexhausted")
 }
 
+// claudeStatePaths returns the per-user paths the Claude CLI writes its own
+// state to (settings, credentials, session data). They must remain writable
+// when the claude process runs inside a sandbox.
+func claudeStatePaths() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{
+		filepath.Join(home, ".claude"),
+		filepath.Join(home, ".claude.json"),
+	}
+}
+
 // filterEnv returns a copy of env with the given key removed.
 func filterEnv(env []string, key string) []string {
 	prefix := key + "="
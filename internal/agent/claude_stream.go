@@ -10,9 +10,13 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
 )
 
 const (
@@ -143,10 +147,7 @@ func (c *ClaudeStreamProcess) ensureStarted(ctx context.Context) error {
 	}
 
 	args := c.buildStreamArgs()
-	cmd := exec.CommandContext(ctx, "claude", args...)
-	if c.opts.WorkDir != "" {
-		cmd.Dir = c.opts.WorkDir
-	}
+	cmd := c.opts.Sandbox.Command(ctx, c.opts.WorkDir, "claude", args...)
 
 	// Remove CLAUDECODE/CLAUDE_CODE_ENTRYPOINT env vars to allow nested invocations.
 	env := filterEnv(os.Environ(), "CLAUDECODE")
@@ -225,6 +226,7 @@ func (c *ClaudeStreamProcess) scanForResult() (string, error) {
 		return "", fmt.Errorf("claude stream scanner is nil")
 	}
 
+	tools := newStreamToolAuditor(c.opts.Auditor, c.opts.WorkDir)
 	eventCount := 0
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -247,6 +249,8 @@ func (c *ClaudeStreamProcess) scanForResult() (string, error) {
 				c.sessionID = event.SessionID
 				log.Printf("[claude-stream] init received (session=%s)", c.sessionID)
 			}
+		case "assistant", "user":
+			tools.observe(event)
 		case "result":
 			log.Printf("[claude-stream] result received after %d events (session=%s)", eventCount, c.sessionID)
 			return extractResultText(event), nil
@@ -263,6 +267,81 @@ func (c *ClaudeStreamProcess) scanForResult() (string, error) {
 	return "", fmt.Errorf("claude stream process exited without result event")
 }
 
+// streamContentBlock is one element of message.content in assistant/user events.
+type streamContentBlock struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// exitCodePattern extracts the exit code Claude Code reports for failed Bash calls.
+var exitCodePattern = regexp.MustCompile(`Exit code (\d+)`)
+
+// streamToolAuditor pairs Bash tool_use events with their tool_result events
+// and records each completed command via the CommandAuditor. The CLI does not
+// report exit codes directly: a successful result is recorded as 0, and an
+// error result uses the "Exit code N" text when present, otherwise 1.
+type streamToolAuditor struct {
+	auditor *CommandAuditor
+	workDir string
+	pending map[string]pendingToolUse
+}
+
+type pendingToolUse struct {
+	command string
+	started time.Time
+}
+
+func newStreamToolAuditor(auditor *CommandAuditor, workDir string) *streamToolAuditor {
+	return &streamToolAuditor{auditor: auditor, workDir: workDir, pending: make(map[string]pendingToolUse)}
+}
+
+// observe inspects an assistant or user event for Bash tool activity.
+func (t *streamToolAuditor) observe(event streamEvent) {
+	if t.auditor == nil || event.Message == nil {
+		return
+	}
+	var msg struct {
+		Content []streamContentBlock `json:"content"`
+	}
+	if err := json.Unmarshal(event.Message, &msg); err != nil {
+		return // content is a plain string: no tool activity
+	}
+	for _, block := range msg.Content {
+		switch block.Type {
+		case "tool_use":
+			if block.Name != "Bash" {
+				continue
+			}
+			var input struct {
+				Command string `json:"command"`
+			}
+			if err := json.Unmarshal(block.Input, &input); err != nil || input.Command == "" {
+				continue
+			}
+			t.pending[block.ID] = pendingToolUse{command: input.Command, started: time.Now()}
+		case "tool_result":
+			p, ok := t.pending[block.ToolUseID]
+			if !ok {
+				continue
+			}
+			delete(t.pending, block.ToolUseID)
+			exitCode := 0
+			if block.IsError {
+				exitCode = 1
+				if m := exitCodePattern.FindSubmatch(block.Content); m != nil {
+					exitCode, _ = strconv.Atoi(string(m[1]))
+				}
+			}
+			t.auditor.Record(audit.SourceClaudeStream, t.workDir, p.command, exitCode, time.Since(p.started), "")
+		}
+	}
+}
+
 // extractResultText pulls the text content from a result event.
 func extractResultText(event streamEvent) string {
 	// The result field may contain the final text directly
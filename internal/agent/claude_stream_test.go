@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
 )
 
 func TestBuildStreamArgs(t *testing.T) {
@@ -457,3 +460,40 @@ func newTestScanner(r io.Reader) *bufio.Scanner {
 }
 
 var _ = fmt.Sprintf // ensure fmt is used
+
+func TestScanForResultAuditsBashToolUse(t *testing.T) {
+	lines := `{"type":"system","session_id":"sess-abc"}
+{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./..."}},{"type":"tool_use","id":"t2","name":"Read","input":{"file_path":"x"}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"Exit code 2\nFAIL","is_error":true},{"type":"tool_result","tool_use_id":"t2","content":"x"}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t3","name":"Bash","input":{"command":"curl -H 'Authorization: Bearer secret123' x"}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t3","content":"ok"}]}}
+{"type":"result","result":"done"}
+`
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	p := &ClaudeStreamProcess{
+		opts: ClaudeOptions{
+			WorkDir: "/repo",
+			Auditor: &CommandAuditor{Log: audit.New(logPath, 0, 0), Agent: "engineer-1", Issue: "gh-1"},
+		},
+		scanner: newTestScanner(strings.NewReader(lines)),
+		started: true,
+	}
+
+	if _, err := p.scanForResult(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := audit.Query(logPath, audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if e := entries[0]; e.Command != "go test ./..." || e.ExitCode != 2 || e.Source != audit.SourceClaudeStream || e.WorkDir != "/repo" || e.Issue != "gh-1" {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if e := entries[1]; e.ExitCode != 0 || strings.Contains(e.Command, "secret123") {
+		t.Errorf("unexpected second entry (want exit 0, sanitized command): %+v", e)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -16,6 +15,8 @@ type CopilotCLIOptions struct {
 	Model        string
 	WorkDir      string
 	BashTimeout  time.Duration
+	// Sandbox optionally runs the copilot process itself inside a sandbox.
+	Sandbox *Sandbox
 }
 
 // CopilotCLIProcess sends prompts to the GitHub Copilot CLI (`copilot -p`).
@@ -53,10 +54,7 @@ func (c *CopilotCLIProcess) Send(ctx context.Context, prompt string) (string, er
 
 	args := c.buildArgs(prompt)
 
-	cmd := exec.CommandContext(ctx, "copilot", args...)
-	if c.opts.WorkDir != "" {
-		cmd.Dir = c.opts.WorkDir
-	}
+	cmd := c.opts.Sandbox.Command(ctx, c.opts.WorkDir, "copilot", args...)
 
 	// Pass through environment but filter out variables that may conflict.
 	env := filterEnv(os.Environ(), "CLAUDECODE")
@@ -10,9 +10,10 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/redact"
 )
 
 const (
@@ -43,6 +44,12 @@ type GeminiAPIOptions struct {
 	Model        string
 	WorkDir      string
 	BashTimeout  time.Duration
+	// Sandbox optionally isolates bash tool commands. nil runs them directly.
+	Sandbox *Sandbox
+	// Auditor records every bash tool command. nil disables auditing.
+	Auditor *CommandAuditor
+	// Redactor masks secrets in bash commands that publish text to GitHub.
+	Redactor *redact.Redactor
 }
 
 // GeminiAPIProcess sends prompts to the Gemini REST API using GOOGLE_API_KEY / GEMINI_API_KEY.
@@ -390,32 +397,12 @@ func (g *GeminiAPIProcess) executeTool(ctx context.Context, toolName string, inp
 
 // runBash executes a bash command and returns (output, isError).
 func (g *GeminiAPIProcess) runBash(ctx context.Context, command string) (string, bool) {
-	if g.opts.BashTimeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, g.opts.BashTimeout)
-		defer cancel()
-	}
-
-	cmd := exec.CommandContext(ctx, "bash", "-c", command)
-	if g.opts.WorkDir != "" {
-		cmd.Dir = g.opts.WorkDir
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	result := strings.TrimSpace(stdout.String())
-	if stderr.Len() > 0 {
-		if result != "" {
-			result += "\n"
-		}
-		result += "STDERR:\n" + SanitizeLog(strings.TrimSpace(stderr.String()))
-	}
-	if result == "" && err != nil {
-		result = fmt.Sprintf("command failed: %v", err)
-	}
-
-	return result, err != nil
+	return execBash(ctx, bashRequest{
+		Command:  command,
+		WorkDir:  g.opts.WorkDir,
+		Timeout:  g.opts.BashTimeout,
+		Sandbox:  g.opts.Sandbox,
+		Auditor:  g.opts.Auditor,
+		Redactor: g.opts.Redactor,
+	})
 }
@@ -47,7 +47,8 @@ type WorkMemo struct {
 var AllowedTargets = map[Role][]Role{
 	RoleSuperintendent: {RoleEngineer, RoleOrchestrator},
 
-	RoleEngineer: {RoleSuperintendent}, // Engineer can send to Superintendent
+	// Engineer can send to Superintendent, and VERIFY to the orchestrator
+	RoleEngineer: {RoleSuperintendent, RoleOrchestrator},
 }
 
 func CanSendTo(from, to Role) bool {
@@ -26,6 +26,7 @@ func TestCanSendTo(t *testing.T) {
 		{RoleSuperintendent, RoleEngineer, true},
 		{RoleSuperintendent, RoleOrchestrator, true},
 		{RoleEngineer, RoleSuperintendent, true},
+		{RoleEngineer, RoleOrchestrator, true},
 	}
 	for _, tt := range tests {
 		got := CanSendTo(tt.from, tt.to)
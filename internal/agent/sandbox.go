@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"runtime"
+
+	"github.com/ytnobody/madflow/internal/shell"
+)
+
+// Sandbox modes accepted by SandboxOptions.Mode.
+const (
+	SandboxModeAuto    = "auto"    // bwrap when available, then unshare, else none
+	SandboxModeBwrap   = "bwrap"   // bubblewrap: read-only root, writable allowlist, optional network isolation
+	SandboxModeUnshare = "unshare" // user/network namespaces only; filesystem writes are not restricted
+	SandboxModeNone    = "none"    // commands run directly (pattern checks still apply)
+)
+
+// Sandbox network policies accepted by SandboxOptions.Network.
+const (
+	SandboxNetworkAllow = "allow"
+	SandboxNetworkDeny  = "deny"
+)
+
+// SandboxOptions configures a Sandbox.
+type SandboxOptions struct {
+	// Mode selects the isolation mechanism. Empty means SandboxModeAuto.
+	Mode string
+	// Network is the network policy. Empty means SandboxNetworkAllow.
+	Network string
+	// WritablePaths are the only host paths that sandboxed commands may write to
+	// (in addition to a private /tmp). Typically the team worktree and data dir.
+	WritablePaths []string
+	// DenyPatterns are regular expressions; a command matching any of them is
+	// rejected before execution.
+	DenyPatterns []string
+	// AllowPatterns are regular expressions; when non-empty, a command must
+	// match at least one of them to be executed.
+	AllowPatterns []string
+}
+
+// hookBypassPatterns match git commands that would skip the team's guard
+// hooks (see teamgit.Guard): commits and pushes with --no-verify (or commit
+// -n) and changes to core.hooksPath. Sandbox.Check always rejects them.
+var hookBypassPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bgit\b[^;&|\n]*\s(commit|push)\b[^;&|\n]*\s--no-verify\b`),
+	regexp.MustCompile(`\bgit\b[^;&|\n]*\scommit\b[^;&|\n]*\s-[a-zA-Z]*n[a-zA-Z]*(\s|$)`),
+	regexp.MustCompile(`(?i)\bgit\b[^;&|\n]*\bcore\.hookspath\b`),
+}
+
+// CommandDeniedError is returned by Sandbox.Check when a command is rejected
+// by the configured deny/allow patterns or bypasses the guard hooks.
+type CommandDeniedError struct {
+	Command string
+	Reason  string
+}
+
+func (e *CommandDeniedError) Error() string {
+	return fmt.Sprintf("command rejected by sandbox policy: %s", e.Reason)
+}
+
+// Sandbox wraps agent commands in an optional Linux sandbox and enforces
+// command deny/allow patterns. A nil *Sandbox is a safe no-op: commands run
+// unwrapped and every command is allowed.
+type Sandbox struct {
+	mode     string // resolved mode (never SandboxModeAuto)
+	network  string
+	writable []string
+	deny     []*regexp.Regexp
+	allow    []*regexp.Regexp
+}
+
+// sandboxLookPath is exec.LookPath, replaceable in tests.
+var sandboxLookPath = exec.LookPath
+
+// NewSandbox validates opts and resolves the sandbox mode for the current host.
+// In auto mode, it falls back to weaker isolation (with a log warning) when
+// bubblewrap or unshare are unavailable.
+func NewSandbox(opts SandboxOptions) (*Sandbox, error) {
+	s := &Sandbox{
+		network:  opts.Network,
+		writable: opts.WritablePaths,
+	}
+	if s.network == "" {
+		s.network = SandboxNetworkAllow
+	}
+	if s.network != SandboxNetworkAllow && s.network != SandboxNetworkDeny {
+		return nil, fmt.Errorf("invalid sandbox network policy %q (want %q or %q)", s.network, SandboxNetworkAllow, SandboxNetworkDeny)
+	}
+
+	var err error
+	if s.deny, err = compileCommandPatterns(opts.DenyPatterns); err != nil {
+		return nil, err
+	}
+	if s.allow, err = compileCommandPatterns(opts.AllowPatterns); err != nil {
+		return nil, err
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = SandboxModeAuto
+	}
+	switch mode {
+	case SandboxModeAuto:
+		s.mode = detectSandboxMode()
+	case SandboxModeBwrap, SandboxModeUnshare:
+		if runtime.GOOS != "linux" {
+			return nil, fmt.Errorf("sandbox mode %q is only supported on Linux", mode)
+		}
+		if _, err := sandboxLookPath(mode); err != nil {
+			return nil, fmt.Errorf("sandbox mode %q: %w", mode, err)
+		}
+		s.mode = mode
+	case SandboxModeNone:
+		s.mode = mode
+	default:
+		return nil, fmt.Errorf("invalid sandbox mode %q", mode)
+	}
+
+	if s.mode == SandboxModeUnshare {
+		log.Printf("[sandbox] WARNING: using unshare; network policy is enforced but filesystem writes are not restricted (install bubblewrap for full isolation)")
+	}
+	if s.mode == SandboxModeNone && mode == SandboxModeAuto {
+		log.Printf("[sandbox] WARNING: no sandbox mechanism available (bwrap/unshare not found or not Linux); only command patterns are enforced")
+	}
+	return s, nil
+}
+
+// detectSandboxMode picks the strongest mechanism available on this host.
+func detectSandboxMode() string {
+	if runtime.GOOS != "linux" {
+		return SandboxModeNone
+	}
+	if _, err := sandboxLookPath("bwrap"); err == nil {
+		return SandboxModeBwrap
+	}
+	if _, err := sandboxLookPath("unshare"); err == nil {
+		return SandboxModeUnshare
+	}
+	return SandboxModeNone
+}
+
+func compileCommandPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sandbox command pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Mode returns the resolved sandbox mode. A nil Sandbox reports SandboxModeNone.
+func (s *Sandbox) Mode() string {
+	if s == nil {
+		return SandboxModeNone
+	}
+	return s.mode
+}
+
+// Check reports whether command may be executed under the configured
+// deny/allow patterns. Commands that bypass the guard hooks are always
+// rejected. It returns a *CommandDeniedError when rejected.
+func (s *Sandbox) Check(command string) error {
+	if s == nil {
+		return nil
+	}
+	for _, re := range hookBypassPatterns {
+		if re.MatchString(command) {
+			return &CommandDeniedError{Command: command, Reason: "git hooks (guardrails) may not be bypassed"}
+		}
+	}
+	for _, re := range s.deny {
+		if re.MatchString(command) {
+			return &CommandDeniedError{Command: command, Reason: fmt.Sprintf("matches deny pattern %q", re.String())}
+		}
+	}
+	if len(s.allow) == 0 {
+		return nil
+	}
+	for _, re := range s.allow {
+		if re.MatchString(command) {
+			return nil
+		}
+	}
+	return &CommandDeniedError{Command: command, Reason: "does not match any allow pattern"}
+}
+
+// WithWritable returns a copy of s that additionally allows writes to paths.
+// It is used when the same policy is applied to processes with different
+// working directories (e.g. one sandbox per team worktree).
+func (s *Sandbox) WithWritable(paths ...string) *Sandbox {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.writable = append(append([]string{}, s.writable...), paths...)
+	return &c
+}
+
+// Command builds an *exec.Cmd that runs name with args inside the sandbox,
+// using workDir as the working directory.
+func (s *Sandbox) Command(ctx context.Context, workDir, name string, args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch s.Mode() {
+	case SandboxModeBwrap:
+		cmd = exec.CommandContext(ctx, "bwrap", s.bwrapArgs(workDir, name, args)...)
+	case SandboxModeUnshare:
+		cmd = exec.CommandContext(ctx, "unshare", s.unshareArgs(name, args)...)
+	default:
+		cmd = exec.CommandContext(ctx, name, args...)
+	}
+	if workDir != "" {
+		cmd.Dir = workDir
+	}
+	return cmd
+}
+
+// ShellCommand is shell.Command run inside the sandbox. It is a
+// shell.CommandFunc for commands MADFLOW runs on behalf of agents, such as
+// VERIFY.
+func (s *Sandbox) ShellCommand(ctx context.Context, dir, line string) *exec.Cmd {
+	cmd := shell.Command(ctx, dir, line)
+	if s.Mode() == SandboxModeNone {
+		return cmd
+	}
+	wrapped := s.Command(ctx, dir, cmd.Args[0], cmd.Args[1:]...)
+	wrapped.WaitDelay = cmd.WaitDelay
+	return wrapped
+}
+
+// bwrapArgs builds the bubblewrap argument list: the host root is mounted
+// read-only, /tmp is private, and only the writable paths are bind-mounted
+// read-write.
+func (s *Sandbox) bwrapArgs(workDir, name string, args []string) []string {
+	bw := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	for _, p := range s.writable {
+		if p == "" {
+			continue
+		}
+		bw = append(bw, "--bind-try", p, p)
+	}
+	if s.network == SandboxNetworkDeny {
+		bw = append(bw, "--unshare-net")
+	}
+	bw = append(bw, "--die-with-parent")
+	if workDir != "" {
+		bw = append(bw, "--chdir", workDir)
+	}
+	bw = append(bw, "--", name)
+	return append(bw, args...)
+}
+
+// unshareArgs builds the unshare argument list. Only user and (when the
+// network policy is deny) network namespaces are created.
+func (s *Sandbox) unshareArgs(name string, args []string) []string {
+	us := []string{"--user", "--map-root-user"}
+	if s.network == SandboxNetworkDeny {
+		us = append(us, "--net")
+	}
+	us = append(us, "--", name)
+	return append(us, args...)
+}
@@ -0,0 +1,279 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/audit"
+	"github.com/ytnobody/madflow/internal/redact"
+)
+
+func TestSandboxNilIsNoOp(t *testing.T) {
+	var s *Sandbox
+	if err := s.Check("rm -rf /"); err != nil {
+		t.Fatalf("nil Sandbox.Check returned error: %v", err)
+	}
+	if s.Mode() != SandboxModeNone {
+		t.Errorf("nil Sandbox mode = %q, want %q", s.Mode(), SandboxModeNone)
+	}
+	if s.WithWritable("/tmp/x") != nil {
+		t.Error("nil Sandbox.WithWritable should return nil")
+	}
+	cmd := s.Command(context.Background(), "/work", "echo", "hi")
+	if want := []string{"echo", "hi"}; !slices.Equal(cmd.Args, want) {
+		t.Errorf("args = %v, want %v", cmd.Args, want)
+	}
+	if cmd.Dir != "/work" {
+		t.Errorf("dir = %q, want /work", cmd.Dir)
+	}
+}
+
+func TestSandboxCheckDenyPatterns(t *testing.T) {
+	s, err := NewSandbox(SandboxOptions{
+		Mode:         SandboxModeNone,
+		DenyPatterns: []string{`rm\s+-rf\s+/`, `git\s+push\s+.*--force`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Check("go test ./..."); err != nil {
+		t.Errorf("allowed command rejected: %v", err)
+	}
+	err = s.Check("git push origin main --force")
+	var denied *CommandDeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("expected CommandDeniedError, got %v", err)
+	}
+	if !strings.Contains(denied.Reason, "deny pattern") {
+		t.Errorf("unexpected reason: %q", denied.Reason)
+	}
+}
+
+func TestSandboxCheckHookBypass(t *testing.T) {
+	s, err := NewSandbox(SandboxOptions{Mode: SandboxModeNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []string{
+		"git commit --no-verify -m wip",
+		"git commit -nm wip",
+		"cd repo && git push --no-verify origin feature",
+		"git -C repo config core.hooksPath /tmp/hooks",
+		"git -c core.hooksPath=/dev/null commit -m wip",
+		"git config --unset core.hookspath",
+	} {
+		var denied *CommandDeniedError
+		if err := s.Check(cmd); !errors.As(err, &denied) || !strings.Contains(denied.Reason, "hooks") {
+			t.Errorf("Check(%q) = %v, want the hooks to be protected", cmd, err)
+		}
+	}
+	for _, cmd := range []string{
+		"git commit --amend -m wip",
+		"git push -n origin feature",
+		"echo --no-verify",
+	} {
+		if err := s.Check(cmd); err != nil {
+			t.Errorf("Check(%q) = %v, want it allowed", cmd, err)
+		}
+	}
+}
+
+func TestSandboxCheckAllowPatterns(t *testing.T) {
+	s, err := NewSandbox(SandboxOptions{
+		Mode:          SandboxModeNone,
+		AllowPatterns: []string{`^go `, `^git `},
+		DenyPatterns:  []string{`^git push`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Check("go build ./..."); err != nil {
+		t.Errorf("allowlisted command rejected: %v", err)
+	}
+	if err := s.Check("curl https://example.com"); err == nil {
+		t.Error("expected command outside allowlist to be rejected")
+	}
+	// Deny patterns take precedence over allow patterns.
+	if err := s.Check("git push origin main"); err == nil {
+		t.Error("expected denied command to be rejected even if allowlisted")
+	}
+}
+
+func TestNewSandboxValidation(t *testing.T) {
+	tests := map[string]SandboxOptions{
+		"invalid network": {Mode: SandboxModeNone, Network: "partial"},
+		"invalid mode":    {Mode: "docker"},
+		"invalid deny":    {Mode: SandboxModeNone, DenyPatterns: []string{"("}},
+		"invalid allow":   {Mode: SandboxModeNone, AllowPatterns: []string{"["}},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewSandbox(opts); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestNewSandboxAutoFallsBack(t *testing.T) {
+	orig := sandboxLookPath
+	defer func() { sandboxLookPath = orig }()
+	sandboxLookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+
+	s, err := NewSandbox(SandboxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Mode() != SandboxModeNone {
+		t.Errorf("mode = %q, want %q", s.Mode(), SandboxModeNone)
+	}
+
+	if _, err := NewSandbox(SandboxOptions{Mode: SandboxModeBwrap}); err == nil {
+		t.Error("expected error when bwrap is explicitly requested but unavailable")
+	}
+}
+
+func TestSandboxBwrapArgs(t *testing.T) {
+	s := &Sandbox{
+		mode:     SandboxModeBwrap,
+		network:  SandboxNetworkDeny,
+		writable: []string{"/repo", "", "/data"},
+	}
+	args := s.bwrapArgs("/repo/.worktrees/team-1", "bash", []string{"-c", "go test"})
+	got := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"--ro-bind / /",
+		"--tmpfs /tmp",
+		"--bind-try /repo /repo",
+		"--bind-try /data /data",
+		"--unshare-net",
+		"--chdir /repo/.worktrees/team-1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("bwrap args %q missing %q", got, want)
+		}
+	}
+	if !strings.HasSuffix(got, "-- bash -c go test") {
+		t.Errorf("bwrap args should end with the wrapped command, got %q", got)
+	}
+	if strings.Contains(got, "--bind-try  ") {
+		t.Errorf("empty writable path should be skipped, got %q", got)
+	}
+}
+
+func TestSandboxBwrapArgsNetworkAllowed(t *testing.T) {
+	s := &Sandbox{mode: SandboxModeBwrap, network: SandboxNetworkAllow}
+	if slices.Contains(s.bwrapArgs("", "true", nil), "--unshare-net") {
+		t.Error("--unshare-net should not be set when network is allowed")
+	}
+}
+
+func TestSandboxUnshareArgs(t *testing.T) {
+	s := &Sandbox{mode: SandboxModeUnshare, network: SandboxNetworkDeny}
+	want := []string{"--user", "--map-root-user", "--net", "--", "bash", "-c", "ls"}
+	if got := s.unshareArgs("bash", []string{"-c", "ls"}); !slices.Equal(got, want) {
+		t.Errorf("unshare args = %v, want %v", got, want)
+	}
+}
+
+func TestSandboxShellCommand(t *testing.T) {
+	var none *Sandbox
+	if cmd := none.ShellCommand(t.Context(), "/repo", "go test ./..."); !slices.Equal(cmd.Args, []string{"sh", "-c", "go test ./..."}) || cmd.WaitDelay == 0 {
+		t.Errorf("without a sandbox, ShellCommand = %v (WaitDelay %v)", cmd.Args, cmd.WaitDelay)
+	}
+	s := &Sandbox{mode: SandboxModeBwrap, network: SandboxNetworkAllow, writable: []string{"/repo"}}
+	cmd := s.ShellCommand(t.Context(), "/repo", "go test ./...")
+	got := strings.Join(cmd.Args, " ")
+	if cmd.Args[0] != "bwrap" || !strings.Contains(got, "--bind-try /repo /repo") || !strings.HasSuffix(got, "-- sh -c go test ./...") {
+		t.Errorf("sandboxed ShellCommand = %q", got)
+	}
+	if cmd.Dir != "/repo" || cmd.WaitDelay == 0 {
+		t.Errorf("Dir = %q, WaitDelay = %v", cmd.Dir, cmd.WaitDelay)
+	}
+}
+
+func TestSandboxWithWritableCopies(t *testing.T) {
+	s := &Sandbox{mode: SandboxModeNone, writable: []string{"/repo"}}
+	c := s.WithWritable("/home/user/.claude")
+	if len(s.writable) != 1 {
+		t.Errorf("original sandbox modified: %v", s.writable)
+	}
+	if want := []string{"/repo", "/home/user/.claude"}; !slices.Equal(c.writable, want) {
+		t.Errorf("writable = %v, want %v", c.writable, want)
+	}
+}
+
+func TestExecBashRejectedBySandbox(t *testing.T) {
+	s, err := NewSandbox(SandboxOptions{Mode: SandboxModeNone, DenyPatterns: []string{`^echo`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, isErr := execBash(context.Background(), bashRequest{Command: "echo hi", Sandbox: s})
+	if !isErr {
+		t.Fatal("expected error result")
+	}
+	if !strings.Contains(out, "rejected by sandbox policy") {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	out, isErr = execBash(context.Background(), bashRequest{Command: "printf ok", Sandbox: s})
+	if isErr || out != "ok" {
+		t.Errorf("got (%q, %v), want (\"ok\", false)", out, isErr)
+	}
+}
+
+func TestExecBashRecordsAudit(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditor := &CommandAuditor{Log: audit.New(logPath, 0, 0), Agent: "engineer-2"}
+	s, err := NewSandbox(SandboxOptions{Mode: SandboxModeNone, DenyPatterns: []string{`^rm `}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	execBash(context.Background(), bashRequest{Command: "exit 3", WorkDir: dir, Auditor: auditor})
+	execBash(context.Background(), bashRequest{Command: "rm -rf x", WorkDir: dir, Sandbox: s, Auditor: auditor})
+	execBash(context.Background(), bashRequest{Command: "sleep 5", WorkDir: dir, Timeout: 50 * time.Millisecond, Auditor: auditor})
+
+	entries, err := audit.Query(logPath, audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if e := entries[0]; e.ExitCode != 3 || e.Error != "" || e.WorkDir != dir || e.Agent != "engineer-2" || e.Source != audit.SourceBash {
+		t.Errorf("unexpected exit entry: %+v", e)
+	}
+	if e := entries[1]; e.ExitCode != -1 || !strings.Contains(e.Error, "deny pattern") {
+		t.Errorf("unexpected denied entry: %+v", e)
+	}
+	if e := entries[2]; e.Error != "timed out" {
+		t.Errorf("unexpected timeout entry: %+v", e)
+	}
+}
+
+func TestExecBashRedactsGitHubCommands(t *testing.T) {
+	r, err := redact.New(redact.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "gh" is shadowed by a shell function so the test does not need the CLI.
+	cmd := `gh() { echo "$@"; }; gh issue comment 1 --body "key=supersecretvalue"`
+	out, isErr := execBash(context.Background(), bashRequest{Command: cmd, Redactor: r})
+	if isErr {
+		t.Fatalf("unexpected error: %s", out)
+	}
+	if strings.Contains(out, "supersecretvalue") || !strings.Contains(out, "key=***") {
+		t.Errorf("secret not redacted before execution: %q", out)
+	}
+}
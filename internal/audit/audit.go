@@ -0,0 +1,231 @@
+// Package audit implements the append-only log of commands executed by agents.
+//
+// Each entry is one JSON object per line (JSONL). When the active file grows
+// beyond the configured size it is rotated to "<path>.1", "<path>.2", ... and
+// the oldest file beyond the retention count is deleted. Entries are never
+// rewritten in place.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources identify how a command was observed.
+const (
+	SourceBash         = "bash"          // API backend bash tool (anthropic/*, gemini-*)
+	SourceClaudeStream = "claude-stream" // Bash tool_use event parsed from Claude stream-json output
+)
+
+// Default rotation settings used when zero values are passed to New.
+const (
+	DefaultMaxBytes = 10 * 1024 * 1024
+	DefaultMaxFiles = 5
+)
+
+// Entry is a single audited command execution.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Agent    string    `json:"agent"`
+	Issue    string    `json:"issue,omitempty"`
+	Source   string    `json:"source"`
+	WorkDir  string    `json:"work_dir"`
+	Command  string    `json:"command"`
+	ExitCode int       `json:"exit_code"`
+	// DurationMS is the wall-clock execution time in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+	// Error describes why the command did not run to completion
+	// (e.g. rejected by the sandbox policy, timed out). Empty on normal exit.
+	Error string `json:"error,omitempty"`
+}
+
+// Log appends entries to an audit file with size-based rotation.
+// A nil *Log is a safe no-op.
+type Log struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+	mu       sync.Mutex
+}
+
+// New returns a Log writing to path. maxBytes and maxFiles control rotation;
+// zero or negative values select DefaultMaxBytes and DefaultMaxFiles.
+func New(path string, maxBytes int64, maxFiles int) *Log {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxFiles
+	}
+	return &Log{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+}
+
+// Path returns the path of the active audit file.
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Record appends e to the log, rotating first if the active file is full.
+// A zero e.Time is set to the current time.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(data)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log for append: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts <path>.N-1 → <path>.N ... <path> → <path>.1, dropping the
+// oldest file beyond maxFiles. Must be called with l.mu held.
+func (l *Log) rotate() error {
+	os.Remove(rotatedPath(l.path, l.maxFiles)) //nolint:errcheck // may not exist
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		src := rotatedPath(l.path, i)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := os.Rename(src, rotatedPath(l.path, i+1)); err != nil {
+			return fmt.Errorf("rotate audit log %s: %w", src, err)
+		}
+	}
+	if err := os.Rename(l.path, rotatedPath(l.path, 1)); err != nil {
+		return fmt.Errorf("rotate audit log %s: %w", l.path, err)
+	}
+	return nil
+}
+
+func rotatedPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Exit status filters accepted by Filter.Status.
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Filter selects entries returned by Query. Zero-valued fields match everything.
+type Filter struct {
+	// Agent matches the agent ID exactly (e.g. "engineer-1").
+	Agent string
+	// Issue matches the issue ID exactly.
+	Issue string
+	// Since and Until bound the entry time (inclusive).
+	Since time.Time
+	Until time.Time
+	// Status is StatusSuccess (exit code 0), StatusFailure (anything else) or empty.
+	Status string
+}
+
+// Match reports whether e satisfies f.
+func (f Filter) Match(e Entry) bool {
+	if f.Agent != "" && e.Agent != f.Agent {
+		return false
+	}
+	if f.Issue != "" && e.Issue != f.Issue {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	switch f.Status {
+	case StatusSuccess:
+		return e.ExitCode == 0 && e.Error == ""
+	case StatusFailure:
+		return e.ExitCode != 0 || e.Error != ""
+	}
+	return true
+}
+
+// Query reads the active audit file at path and its rotated predecessors and
+// returns matching entries, oldest first. Malformed lines are skipped.
+// A missing log is not an error.
+func Query(path string, f Filter) ([]Entry, error) {
+	files := []string{path}
+	for i := 1; ; i++ {
+		p := rotatedPath(path, i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		files = append(files, p)
+	}
+
+	var entries []Entry
+	// Rotated files are older; read them from the highest number down.
+	for i := len(files) - 1; i >= 0; i-- {
+		got, err := readFile(files[i], f)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, got...)
+	}
+	return entries, nil
+}
+
+func readFile(path string, f Filter) ([]Entry, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer fh.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			continue // skip malformed lines
+		}
+		if f.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan audit log %s: %w", path, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNilLogIsNoOp(t *testing.T) {
+	var l *Log
+	if err := l.Record(Entry{Command: "ls"}); err != nil {
+		t.Fatalf("nil Log.Record returned error: %v", err)
+	}
+	if l.Path() != "" {
+		t.Errorf("nil Log.Path = %q, want empty", l.Path())
+	}
+}
+
+func TestRecordAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l := New(path, 0, 0)
+
+	base := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: base, Agent: "engineer-1", Issue: "gh-1", Command: "go build", ExitCode: 0},
+		{Time: base.Add(time.Hour), Agent: "engineer-1", Issue: "gh-1", Command: "go test", ExitCode: 1},
+		{Time: base.Add(2 * time.Hour), Agent: "engineer-2", Issue: "gh-2", Command: "rm -rf /", ExitCode: -1, Error: "rejected"},
+		{Time: base.Add(3 * time.Hour), Agent: "superintendent", Command: "gh issue list"},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{"go build", "go test", "rm -rf /", "gh issue list"}},
+		{"agent", Filter{Agent: "engineer-1"}, []string{"go build", "go test"}},
+		{"issue", Filter{Issue: "gh-2"}, []string{"rm -rf /"}},
+		{"since", Filter{Since: base.Add(2 * time.Hour)}, []string{"rm -rf /", "gh issue list"}},
+		{"until", Filter{Until: base.Add(time.Hour)}, []string{"go build", "go test"}},
+		{"success", Filter{Status: StatusSuccess}, []string{"go build", "gh issue list"}},
+		{"failure", Filter{Status: StatusFailure}, []string{"go test", "rm -rf /"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Query(path, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, e := range got {
+				if e.Command != tt.want[i] {
+					t.Errorf("entry %d: got %q, want %q", i, e.Command, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecordSetsTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := New(path, 0, 0).Record(Entry{Command: "ls"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Query(path, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Time.IsZero() {
+		t.Fatalf("expected one entry with time set, got %+v", got)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	// Each entry is well over 100 bytes, so every record rotates the previous file.
+	l := New(path, 100, 2)
+	for _, cmd := range []string{"one", "two", "three", "four"} {
+		if err := l.Record(Entry{Agent: "engineer-1", WorkDir: "/some/long/worktree/path", Command: cmd}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated files, found %s.3", path)
+	}
+
+	got, err := Query(path, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"two", "three", "four"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i, e := range got {
+		if e.Command != want[i] {
+			t.Errorf("entry %d: got %q, want %q", i, e.Command, want[i])
+		}
+	}
+}
+
+func TestQueryMissingAndMalformed(t *testing.T) {
+	dir := t.TempDir()
+	got, err := Query(filepath.Join(dir, "missing.jsonl"), Filter{})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("missing log: got (%v, %v), want (empty, nil)", got, err)
+	}
+
+	path := filepath.Join(dir, "audit.jsonl")
+	data := "not json\n{\"agent\":\"engineer-1\",\"command\":\"ls\"}\n\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Query(path, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Command != "ls" {
+		t.Errorf("expected malformed line to be skipped, got %+v", got)
+	}
+}
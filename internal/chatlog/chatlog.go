@@ -9,12 +9,18 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/filelock"
+	"github.com/ytnobody/madflow/internal/redact"
 )
 
 var messagePattern = regexp.MustCompile(
 	`^\[(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})\] \[@([^\]]+)\] ([^:]+): (.+)$`,
 )
 
+// DestChatlog is the redaction destination name for chatlog writes.
+const DestChatlog = "chatlog"
+
 type Message struct {
 	Timestamp time.Time
 	Recipient string
@@ -24,13 +30,21 @@ type Message struct {
 }
 
 type ChatLog struct {
-	path string
+	path     string
+	redactor *redact.Redactor
 }
 
 func New(path string) *ChatLog {
 	return &ChatLog{path: path}
 }
 
+// WithRedactor makes Append mask secrets in message bodies with r.
+// A nil r disables redaction.
+func (c *ChatLog) WithRedactor(r *redact.Redactor) *ChatLog {
+	c.redactor = r
+	return c
+}
+
 func (c *ChatLog) Path() string {
 	return c.path
 }
@@ -63,15 +77,12 @@ func FormatMessage(recipient, sender, body string) string {
 }
 
 // Append writes a new formatted message to the chatlog file.
+// Secrets in body are masked when a redactor is configured. The line is
+// written under the chatlog lock (see filelock.AppendLines), so concurrent
+// writers never interleave partial lines.
 func (c *ChatLog) Append(recipient, sender, body string) error {
-	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("open chatlog for append: %w", err)
-	}
-	defer f.Close()
-
-	line := FormatMessage(recipient, sender, body)
-	if _, err := fmt.Fprintln(f, line); err != nil {
+	body = c.redactor.Redact(DestChatlog, body)
+	if err := filelock.AppendLines(c.path, FormatMessage(recipient, sender, body)); err != nil {
 		return fmt.Errorf("write chatlog: %w", err)
 	}
 	return nil
@@ -229,9 +240,18 @@ func (c *ChatLog) WatchAll(ctx context.Context) <-chan Message {
 }
 
 // Truncate keeps only the latest maxLines lines of the chatlog file,
-// removing older entries. It uses atomic write (temp file + rename) for safety.
+// removing older entries. It holds the chatlog lock and uses atomic write
+// (temp file + rename) for safety. Lines appended by processes that bypass
+// the lock (e.g. `echo >>` from agent shells) while the new file is being
+// written are carried over before the rename.
 // If the file does not exist or has fewer lines than maxLines, it does nothing.
 func (c *ChatLog) Truncate(maxLines int) error {
+	lock, err := filelock.Acquire(c.path)
+	if err != nil {
+		return fmt.Errorf("lock chatlog: %w", err)
+	}
+	defer lock.Release()
+
 	data, err := os.ReadFile(c.path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -255,15 +275,12 @@ func (c *ChatLog) Truncate(maxLines int) error {
 	lines = lines[removed:]
 
 	newContent := strings.Join(lines, "\n") + "\n"
-
-	tmpPath := c.path + ".tmp"
-	if err := os.WriteFile(tmpPath, []byte(newContent), 0600); err != nil {
-		return fmt.Errorf("write temp chatlog: %w", err)
+	if latest, err := os.ReadFile(c.path); err == nil && len(latest) > len(data) {
+		newContent += string(latest[len(data):])
 	}
 
-	if err := os.Rename(tmpPath, c.path); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("rename chatlog: %w", err)
+	if err := filelock.WriteFileAtomic(c.path, []byte(newContent), 0600); err != nil {
+		return fmt.Errorf("replace chatlog: %w", err)
 	}
 
 	log.Printf("[chatlog] truncated %d lines, keeping latest %d lines", removed, maxLines)
@@ -2,11 +2,15 @@ package chatlog
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/redact"
 )
 
 func TestParseMessage(t *testing.T) {
@@ -467,3 +471,74 @@ func TestTruncatePreservesRestrictedPermissions(t *testing.T) {
 		t.Errorf("chatlog permission after truncate: got %04o, want %04o", got, want)
 	}
 }
+
+func TestAppendRedactsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chatlog.txt")
+
+	r, err := redact.New(redact.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := New(path).WithRedactor(r)
+	if err := cl.Append("superintendent", "engineer-1", "env: ANTHROPIC_API_KEY=sk-ant-abcdefghijklmnop"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "abcdefghijklmnop") {
+		t.Errorf("secret written to chatlog: %q", data)
+	}
+	if !strings.Contains(string(data), "sk-***") {
+		t.Errorf("expected masked key in chatlog, got %q", data)
+	}
+	if c := r.Counts()[DestChatlog]; c != 1 {
+		t.Errorf("chatlog redaction count = %d, want 1", c)
+	}
+}
+
+func TestAppendConcurrentWithTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chatlog.txt")
+	cl := New(path)
+	body := strings.Repeat("long message ", 500)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := cl.Append("superintendent", fmt.Sprintf("engineer-%d", w), body); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := cl.Truncate(50); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) < 50 || len(lines) > 200 {
+		t.Errorf("got %d lines, want 50..200", len(lines))
+	}
+	for _, line := range lines {
+		if _, err := ParseMessage(line); err != nil {
+			t.Fatalf("corrupted line: %.80q", line)
+		}
+	}
+}
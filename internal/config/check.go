@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Finding is a problem found in a config file by Check.
+type Finding struct {
+	// Key is the dotted TOML key the finding refers to; empty for findings
+	// about the file as a whole.
+	Key     string
+	Message string
+	// Warning marks findings that do not prevent MADFLOW from starting.
+	Warning bool
+}
+
+func (f Finding) String() string {
+	level := "error"
+	if f.Warning {
+		level = "warning"
+	}
+	return level + ": " + f.Message
+}
+
+// Check loads the config file at path like LoadProfile, but strictly: unknown
+// keys are errors, and repository paths must exist and be git repositories.
+// It returns the fully defaulted config (nil when the file cannot be used)
+// together with every finding. The returned error is non-nil only if the
+// file cannot be read.
+func Check(path, profile string) (*Config, []Finding, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, fmt.Errorf("read config: %w", err)
+	}
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	l, err := readLayers(path, profile)
+	if err != nil {
+		return nil, []Finding{{Message: err.Error()}}, nil
+	}
+	cfg, md, err := l.decode()
+	if err != nil {
+		return nil, []Finding{{Message: err.Error()}}, nil
+	}
+	cfg.Profile = profile
+	cfg.Files = l.files
+
+	findings := unknownKeys(md)
+	for i := range findings {
+		findings[i].Warning = false
+	}
+
+	setDefaults(cfg)
+	applyGhLogin(cfg)
+	autoPopulateAuthorizedUsers(cfg)
+
+	if err := validate(cfg); err != nil {
+		findings = append(findings, Finding{Message: err.Error()})
+	}
+	findings = append(findings, checkRepos(cfg, filepath.Dir(path))...)
+	findings = append(findings, warnings(cfg)...)
+
+	if HasErrors(findings) {
+		return nil, findings, nil
+	}
+	return cfg, findings, nil
+}
+
+// HasErrors reports whether findings contains anything other than warnings.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if !f.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRepos verifies that every project repository exists and is a git
+// repository. Relative paths are resolved against dir, the directory of the
+// config file.
+func checkRepos(cfg *Config, dir string) []Finding {
+	var findings []Finding
+	for i, r := range cfg.Project.Repos {
+		if r.Path == "" {
+			continue
+		}
+		key := fmt.Sprintf("project.repos[%d].path", i)
+		p := r.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			findings = append(findings, Finding{Key: key, Message: fmt.Sprintf("%s: %s does not exist", key, r.Path)})
+			continue
+		}
+		if !info.IsDir() {
+			findings = append(findings, Finding{Key: key, Message: fmt.Sprintf("%s: %s is not a directory", key, r.Path)})
+			continue
+		}
+		// .git is a directory in a normal clone and a file in a worktree.
+		if _, err := os.Stat(filepath.Join(p, ".git")); err != nil {
+			findings = append(findings, Finding{Key: key, Message: fmt.Sprintf("%s: %s is not a git repository", key, r.Path)})
+		}
+	}
+	return findings
+}
+
+// unknownKeys reports the keys in the file that do not correspond to any
+// config field, with a suggestion when a known key is spelled similarly.
+// The findings are warnings; Check turns them into errors.
+func unknownKeys(md toml.MetaData) []Finding {
+	undecoded := md.Undecoded()
+	if len(undecoded) == 0 {
+		return nil
+	}
+	known := knownKeys()
+	var findings []Finding
+	for _, k := range undecoded {
+		key := k.String()
+		msg := fmt.Sprintf("unknown key %q", key)
+		if s := suggestKey(key, known); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		findings = append(findings, Finding{Key: key, Message: msg, Warning: true})
+	}
+	return findings
+}
+
+// knownKeys returns every dotted key that can appear in a config file.
+func knownKeys() []string {
+	var keys []string
+	collectKeys("", reflect.TypeOf(Config{}), &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func collectKeys(prefix string, t reflect.Type, keys *[]string) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		*keys = append(*keys, name)
+		collectKeys(name, f.Type, keys)
+	}
+}
+
+// suggestKey returns the known key closest to key, or "" when none is close.
+// Only keys at the same depth are considered, so that "agent.max_team"
+// suggests "agent.max_teams" rather than a top-level key.
+func suggestKey(key string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if strings.Count(k, ".") != strings.Count(key, ".") {
+			continue
+		}
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCheckConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const checkBase = `
+[project]
+name = "app"
+
+[[project.repos]]
+name = "main"
+path = "."
+`
+
+func TestCheckValid(t *testing.T) {
+	path := writeCheckConfig(t, checkBase)
+	cfg, findings, err := Check(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HasErrors(findings) {
+		t.Fatalf("unexpected errors: %v", findings)
+	}
+	if cfg == nil || cfg.Agent.MaxTeams != 4 {
+		t.Fatalf("expected defaulted config, got %+v", cfg)
+	}
+}
+
+func TestCheckUnknownKeys(t *testing.T) {
+	path := writeCheckConfig(t, checkBase+`
+[agent]
+max_team = 8
+
+[agent.model]
+engineer = "claude-haiku-4-5"
+`)
+	cfg, findings, err := Check(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg != nil {
+		t.Error("expected nil config for a file with unknown keys")
+	}
+	var msgs []string
+	for _, f := range findings {
+		if f.Warning {
+			continue
+		}
+		msgs = append(msgs, f.Message)
+	}
+	got := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		`unknown key "agent.max_team" (did you mean "agent.max_teams"?)`,
+		`unknown key "agent.model.engineer" (did you mean "agent.models.engineer"?)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("findings missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestLoadWarnsOnUnknownKeys(t *testing.T) {
+	path := writeCheckConfig(t, checkBase+`
+[agent]
+max_team = 8
+`)
+	if _, err := Load(path); err != nil {
+		t.Fatalf("Load should accept unknown keys with a warning: %v", err)
+	}
+}
+
+func TestCheckRanges(t *testing.T) {
+	tests := []struct {
+		extra string
+		want  string
+	}{
+		{"[agent]\nbash_timeout_minutes = -5\n", "agent.bash_timeout_minutes must be at least 1"},
+		{"[agent]\nissue_patrol_interval_minutes = -2\n", "agent.issue_patrol_interval_minutes must be at least -1"},
+		{"[branches]\ncleanup_interval_minutes = -1\n", "branches.cleanup_interval_minutes must be at least 0"},
+		{"pool_size = -1\n", "project.repos[0].pool_size must be at least 0"},
+		{"[github]\nowner = \"o\"\nrepos = [\"r\"]\nevent_poll_seconds = -1\n", "github.event_poll_seconds must be at least 1"},
+		{"[agent.models]\nengineer = \"claud-sonnet-4-6\"\n", `agent.models.engineer: unknown model "claud-sonnet-4-6"`},
+	}
+	for _, tt := range tests {
+		path := writeCheckConfig(t, checkBase+tt.extra)
+		_, findings, err := Check(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !HasErrors(findings) || !strings.Contains(findings[0].Message, tt.want) {
+			t.Errorf("%q: findings = %v, want %q", tt.extra, findings, tt.want)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%q: Load should fail", tt.extra)
+		}
+	}
+}
+
+func TestCheckWarnings(t *testing.T) {
+	path := writeCheckConfig(t, checkBase+`
+[github]
+owner = "o"
+repos = ["r"]
+event_poll_seconds = 120
+idle_poll_minutes = 1
+`)
+	cfg, findings, err := Check(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil || HasErrors(findings) {
+		t.Fatalf("warnings must not make the config invalid: %v", findings)
+	}
+	found := false
+	for _, f := range findings {
+		if f.Key == "github.idle_poll_minutes" && f.Warning {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected idle_poll_minutes warning, got %v", findings)
+	}
+}
+
+func TestCheckRepos(t *testing.T) {
+	path := writeCheckConfig(t, checkBase+`
+[[project.repos]]
+name = "missing"
+path = "does-not-exist"
+
+[[project.repos]]
+name = "plain"
+path = "plain"
+`)
+	if err := os.Mkdir(filepath.Join(filepath.Dir(path), "plain"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, findings, err := Check(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) < 2 ||
+		!strings.Contains(findings[0].Message, "does-not-exist does not exist") ||
+		!strings.Contains(findings[1].Message, "plain is not a git repository") {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
+func TestValidateModel(t *testing.T) {
+	for _, m := range []string{"test", "sonnet", "claude-opus-4-6", "anthropic/claude-haiku-4-5", "gemini-2.5-pro", "copilot/gpt-5"} {
+		if err := validateModel("k", m); err != nil {
+			t.Errorf("validateModel(%q): %v", m, err)
+		}
+	}
+	for _, m := range []string{"gpt-4o", "anthropic/gpt-4o", "gemini-", "copilot/"} {
+		if err := validateModel("k", m); err == nil {
+			t.Errorf("validateModel(%q): expected error", m)
+		}
+	}
+}
@@ -3,19 +3,51 @@ package config
 import (
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
-
-	"github.com/BurntSushi/toml"
 )
 
 type Config struct {
-	Project    ProjectConfig `toml:"project"`
-	Agent      AgentConfig   `toml:"agent"`
-	Branches   BranchConfig  `toml:"branches"`
-	GitHub     *GitHubConfig `toml:"github,omitempty"`
-	PromptsDir string        `toml:"prompts_dir,omitempty"`
+	Project  ProjectConfig `toml:"project"`
+	Agent    AgentConfig   `toml:"agent"`
+	Branches BranchConfig  `toml:"branches"`
+	GitHub   *GitHubConfig `toml:"github,omitempty"`
+	// Sandbox enables optional isolation of the commands agents execute.
+	// nil (no [sandbox] section) runs commands directly as the MADFLOW user.
+	Sandbox *SandboxConfig `toml:"sandbox,omitempty"`
+	// Redaction configures masking of secrets in chatlog writes and in text
+	// published to GitHub.
+	Redaction RedactionConfig `toml:"redaction"`
+	// Screening configures prompt-injection screening of GitHub issues and
+	// comments before they reach agents.
+	Screening ScreeningConfig `toml:"screening"`
+	// Audit configures the command audit log (<data dir>/audit.jsonl).
+	Audit AuditConfig `toml:"audit"`
+	// Release configures the RELEASE pipeline.
+	Release ReleaseConfig `toml:"release"`
+	// IntegrationCheck configures the periodic merge-and-test of all team
+	// branches together.
+	IntegrationCheck IntegrationCheckConfig `toml:"integration_check"`
+	// Verify configures the checks an engineer's branch must pass (VERIFY)
+	// before it is ready for review.
+	Verify VerifyConfig `toml:"verify"`
+	// GitIdentity configures the git identity and commit trailers of the
+	// engineers' commits.
+	GitIdentity GitIdentityConfig `toml:"git_identity"`
+	// Guardrails configures the git hooks that restrict what engineers may
+	// commit and push.
+	Guardrails GuardrailsConfig `toml:"guardrails"`
+	// Lessons configures the lessons learned from scored issues.
+	Lessons LessonsConfig `toml:"lessons"`
+	// Presets are project-defined presets for `madflow use`, declared as
+	// [presets.<name>]. They are not applied by Load.
+	Presets    map[string]Preset `toml:"presets,omitempty"`
+	PromptsDir string            `toml:"prompts_dir,omitempty"`
 	// AuthorizedUsers is a list of GitHub user logins that are allowed to create
 	// issues, PRs, and comments that MADFLOW will process.
 	//
@@ -34,6 +66,11 @@ type Config struct {
 	// paths per user (e.g. "madflow/{gh_login}/issue-{id}").
 	// Empty if the GitHub CLI is unavailable or not authenticated.
 	GhLogin string `toml:"-"`
+	// Profile is the profile applied by LoadProfile ("" for none).
+	Profile string `toml:"-"`
+	// Files are the config files that were read: the main file followed by
+	// the files it includes. Runtime-only; used to watch for changes.
+	Files []string `toml:"-"`
 }
 
 type ProjectConfig struct {
@@ -44,6 +81,12 @@ type ProjectConfig struct {
 type RepoConfig struct {
 	Name string `toml:"name"`
 	Path string `toml:"path"`
+	// PoolSize is the number of spare worktrees kept ready for new teams.
+	// 0 (the default) disables the pool.
+	PoolSize int `toml:"pool_size"`
+	// Bootstrap is a shell command run in each pooled worktree before it is
+	// handed to a team, e.g. "go mod download" or "npm ci".
+	Bootstrap string `toml:"bootstrap"`
 }
 
 type AgentConfig struct {
@@ -113,6 +156,13 @@ type BranchConfig struct {
 	// CleanupIntervalMinutes specifies how often to delete merged feature branches
 	// from all configured repos. 0 (default) disables branch cleanup.
 	CleanupIntervalMinutes int `toml:"cleanup_interval_minutes"`
+	// UpdateIntervalMinutes specifies how often feature branches of active
+	// teams that are behind develop are brought up to date in their team's
+	// worktree. 0 (default) disables the updates.
+	UpdateIntervalMinutes int `toml:"update_interval_minutes"`
+	// UpdateStrategy is "merge" (default) to merge develop into the feature
+	// branch, or "rebase" to rebase the branch onto develop.
+	UpdateStrategy string `toml:"update_strategy"`
 }
 
 type GitHubConfig struct {
@@ -146,27 +196,285 @@ type GitHubConfig struct {
 	BotCommentPatterns []string `toml:"bot_comment_patterns,omitempty"`
 }
 
+// Preset is a named set of models and [agent] settings that `madflow use`
+// writes into madflow.toml.
+type Preset struct {
+	Description    string `toml:"description,omitempty"`
+	Superintendent string `toml:"superintendent"`
+	Engineer       string `toml:"engineer"`
+	// Agent holds additional [agent] settings, e.g. gemini_rpm or max_teams.
+	Agent map[string]any `toml:"agent,omitempty"`
+}
+
+// AuditConfig configures the append-only log of commands executed by agents.
+type AuditConfig struct {
+	// Disabled turns the audit log off. Auditing is enabled by default.
+	Disabled bool `toml:"disabled"`
+	// MaxSizeMB is the size at which the active audit file is rotated.
+	// Defaults to 10.
+	MaxSizeMB int `toml:"max_size_mb"`
+	// MaxFiles is the number of rotated files kept (audit.jsonl.1 … .N).
+	// Defaults to 5.
+	MaxFiles int `toml:"max_files"`
+}
+
+// ReleaseConfig configures the RELEASE command, which merges develop into
+// main, tags a new version and publishes it.
+type ReleaseConfig struct {
+	// TagPrefix is prepended to the version in tag names. Defaults to "v".
+	TagPrefix string `toml:"tag_prefix"`
+	// Changelog is the file, relative to each repository, that release notes
+	// are prepended to. Defaults to "CHANGELOG.md".
+	Changelog string `toml:"changelog"`
+	// SkipCI skips the check that CI is green on the develop branch. The
+	// check is always skipped when [github] is not configured.
+	SkipCI bool `toml:"skip_ci"`
+	// NoPush keeps the release local: main, develop and the tag are not
+	// pushed to origin.
+	NoPush bool `toml:"no_push"`
+	// GitHubRelease creates a GitHub release for the tag with the release
+	// notes. Requires [github] and push.
+	GitHubRelease bool `toml:"github_release"`
+}
+
+// IntegrationCheckConfig configures the integration check, which merges
+// develop and the feature branches of all active teams in a scratch worktree
+// and runs TestCommand there.
+type IntegrationCheckConfig struct {
+	// IntervalMinutes specifies how often the check runs. 0 (default)
+	// disables it.
+	IntervalMinutes int `toml:"interval_minutes"`
+	// TestCommand is run with the shell in the merged tree of each
+	// repository, e.g. "go test ./...". Empty checks for conflicts only.
+	TestCommand string `toml:"test_command"`
+	// TimeoutMinutes bounds TestCommand. Defaults to 20.
+	TimeoutMinutes int `toml:"timeout_minutes"`
+}
+
+// VerifyConfig configures VERIFY, which runs the project's build, lint and
+// test commands in a team's worktree before the engineer may request a
+// review. Commands are run with the shell; empty commands are skipped.
+type VerifyConfig struct {
+	Build string `toml:"build"`
+	Lint  string `toml:"lint"`
+	Test  string `toml:"test"`
+	// TimeoutMinutes bounds all commands of one repository together.
+	// Defaults to 20.
+	TimeoutMinutes int `toml:"timeout_minutes"`
+	// MinCoverage is the required test coverage in percent, read from the
+	// output of Test. 0 (default) does not check coverage.
+	MinCoverage float64 `toml:"min_coverage"`
+	// Repos holds per-repository settings, as [verify.repos.<name>]. They
+	// replace the commands and coverage above for that repository; a zero
+	// timeout_minutes keeps the top-level one.
+	Repos map[string]VerifyConfig `toml:"repos,omitempty"`
+}
+
+// For returns the settings that apply to the named repository.
+func (v VerifyConfig) For(repo string) VerifyConfig {
+	r, ok := v.Repos[repo]
+	if !ok {
+		r = v
+	}
+	r.Repos = nil
+	if r.TimeoutMinutes == 0 {
+		r.TimeoutMinutes = v.TimeoutMinutes
+	}
+	return r
+}
+
+// Enabled reports whether any command is configured.
+func (v VerifyConfig) Enabled() bool {
+	return v.Build != "" || v.Lint != "" || v.Test != ""
+}
+
+// GitIdentityConfig configures how the commits of each team are identified.
+// Each team's worktrees get their own user.name and user.email, expanded from
+// the templates below, and a commit-msg hook that adds Madflow-Issue,
+// Madflow-Team and Madflow-Model trailers.
+//
+// The templates may contain {agent} (e.g. engineer-3), {team}, {issue},
+// {model} and {login} (the GitHub login).
+type GitIdentityConfig struct {
+	// Disabled keeps the host's git identity and adds no trailers.
+	Disabled bool `toml:"disabled"`
+	// Name defaults to "madflow-{agent}".
+	Name string `toml:"name"`
+	// Email defaults to "madflow-{agent}@madflow.invalid".
+	Email string `toml:"email"`
+}
+
+// GuardrailsConfig configures the pre-commit and pre-push hooks installed in
+// each team's worktrees. Engineers may only push their own feature branch,
+// may not force push or delete it, may not change protected paths without
+// the superintendent's approval (GUARD_APPROVE) and may not push a branch
+// whose diff against develop exceeds MaxDiffLines. Violations are refused
+// by git and reported to the engineer in the chatlog.
+type GuardrailsConfig struct {
+	// Disabled installs no guard hooks.
+	Disabled bool `toml:"disabled"`
+	// AllowForcePush permits pushes that rewrite the feature branch.
+	AllowForcePush bool `toml:"allow_force_push"`
+	// ProtectedPaths are glob patterns of repository paths that need
+	// approval, matched like shell case patterns (* also matches /).
+	// Defaults to [".github/workflows/*"]; set it to [] to protect nothing.
+	ProtectedPaths []string `toml:"protected_paths"`
+	// MaxDiffLines limits the lines added plus deleted by a feature branch.
+	// 0 (the default) means no limit.
+	MaxDiffLines int `toml:"max_diff_lines"`
+}
+
+// LessonsConfig configures the lessons file (see `madflow lessons`) and the
+// utility model that generates and merges lessons.
+type LessonsConfig struct {
+	// File is the lessons file, an absolute path or one starting with "~/".
+	// Pointing several projects at the same file shares their lessons.
+	// Defaults to lessons.txt in the data directory.
+	File string `toml:"file"`
+	// Model is the utility model: any agent model, or openai/<model> for an
+	// OpenAI-compatible API. Defaults to a small model of the
+	// superintendent's backend.
+	Model string `toml:"model"`
+	// BaseURL is the base URL of the OpenAI-compatible API for openai/
+	// models. Defaults to $OPENAI_BASE_URL, then https://api.openai.com/v1.
+	BaseURL string `toml:"base_url"`
+	// RetireAfter is the number of scored issues a lesson must be active for
+	// before it is retired when their average score did not improve (see
+	// `madflow lessons report`). 0 triggers the default of 10. Set to -1 to
+	// disable.
+	RetireAfter int `toml:"retire_after"`
+}
+
+// Path returns File with "~/" expanded to the home directory, or "" when
+// File is not set.
+func (c LessonsConfig) Path() string {
+	return expandHome(c.File)
+}
+
+// expandHome returns path with a leading "~/" expanded to the home
+// directory. path is returned unchanged when the home directory is unknown.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// guardPathRe matches the protected path patterns the hooks can use
+// unquoted: no whitespace, quotes or other shell syntax.
+var guardPathRe = regexp.MustCompile(`^[A-Za-z0-9_.*?/@+,=!\[\]-]+$`)
+
+// RedactionConfig configures secret redaction. Built-in credential patterns
+// (API keys, GitHub/AWS/Slack tokens, private keys) and the values of well-known
+// API key environment variables are always included unless Disabled is set.
+type RedactionConfig struct {
+	// Disabled turns redaction off. Redaction is enabled by default.
+	Disabled bool `toml:"disabled"`
+	// Patterns are additional regular expressions to mask. If a pattern has a
+	// capture group, group 1 is kept as context and the rest is masked.
+	Patterns []string `toml:"patterns,omitempty"`
+	// EnvVars names environment variables whose values are masked literally
+	// (values shorter than 8 characters are ignored).
+	EnvVars []string `toml:"env_vars,omitempty"`
+}
+
+// ScreeningConfig configures prompt-injection screening. Issues and comments
+// from users outside authorized_users (and text quoted by authorized users)
+// are checked; flagged issues are quarantined as pending approval.
+type ScreeningConfig struct {
+	// Disabled turns screening off. Screening is enabled by default.
+	Disabled bool `toml:"disabled"`
+	// DisableBuiltinRules drops the built-in rules; Rules still apply.
+	DisableBuiltinRules bool `toml:"disable_builtin_rules"`
+	// Rules are additional rules, declared as [[screening.rules]].
+	Rules []ScreeningRule `toml:"rules,omitempty"`
+	// ClassifierModel enables a second, LLM-based check of text that no rule
+	// flagged (e.g. "claude-haiku-4-5"). Requires ANTHROPIC_API_KEY.
+	// Empty disables the classifier.
+	ClassifierModel string `toml:"classifier_model,omitempty"`
+}
+
+// ScreeningRule is a user-defined screening rule.
+type ScreeningRule struct {
+	Name    string `toml:"name"`
+	Pattern string `toml:"pattern"`
+	// Reason is shown to the superintendent when the rule matches.
+	Reason string `toml:"reason,omitempty"`
+}
+
+// SandboxConfig configures the optional Linux sandbox for agent commands.
+// Writes are restricted to the agent's working directory, the MADFLOW data
+// directory and WritablePaths; command patterns are checked before execution
+// by the API backends (anthropic/*, gemini-*).
+type SandboxConfig struct {
+	// Enabled turns the sandbox on. Defaults to false.
+	Enabled bool `toml:"enabled"`
+	// Mode selects the isolation mechanism: "auto" (default; bubblewrap if
+	// installed, otherwise unshare), "bwrap", "unshare" or "none" (patterns only).
+	Mode string `toml:"mode"`
+	// Network is the network policy for sandboxed commands: "allow" (default)
+	// or "deny". Note that agents usually need network access for git push and gh.
+	Network string `toml:"network"`
+	// WritablePaths lists additional host paths sandboxed commands may write to
+	// (e.g. a shared Go module cache): absolute paths or paths starting with
+	// "~/".
+	WritablePaths []string `toml:"writable_paths,omitempty"`
+	// DenyCommands is a list of regular expressions; a bash command matching
+	// any of them is rejected before execution.
+	DenyCommands []string `toml:"deny_commands,omitempty"`
+	// AllowCommands is a list of regular expressions; when non-empty, a bash
+	// command must match at least one of them to be executed.
+	AllowCommands []string `toml:"allow_commands,omitempty"`
+}
+
+// Writable returns WritablePaths with "~/" expanded to the home directory.
+func (c *SandboxConfig) Writable() []string {
+	paths := make([]string, len(c.WritablePaths))
+	for i, p := range c.WritablePaths {
+		paths[i] = expandHome(p)
+	}
+	return paths
+}
+
+// Load reads the config file at path with the profile named by the
+// MADFLOW_PROFILE environment variable, if set. See LoadProfile.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return LoadProfile(path, "")
+}
+
+// LoadProfile reads the config file at path, merges the files it includes,
+// overlays [profiles.<profile>] and applies MADFLOW_* environment overrides.
+// An empty profile selects the one named by MADFLOW_PROFILE, if any.
+func LoadProfile(path, profile string) (*Config, error) {
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	l, err := readLayers(path, profile)
 	if err != nil {
-		return nil, fmt.Errorf("read config: %w", err)
+		return nil, err
 	}
-
-	var cfg Config
-	if err := toml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
+	cfg, md, err := l.decode()
+	if err != nil {
+		return nil, err
 	}
+	for _, f := range unknownKeys(md) {
+		log.Printf("[config] WARNING: %s", f.Message)
+	}
+	cfg.Profile = profile
+	cfg.Files = l.files
 
-	setDefaults(&cfg)
-	applyGhLogin(&cfg)
-	warnDefaults(&cfg)
-	autoPopulateAuthorizedUsers(&cfg)
+	setDefaults(cfg)
+	applyGhLogin(cfg)
+	warnDefaults(cfg)
+	autoPopulateAuthorizedUsers(cfg)
 
-	if err := validate(&cfg); err != nil {
+	if err := validate(cfg); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 func setDefaults(cfg *Config) {
@@ -206,12 +514,45 @@ func setDefaults(cfg *Config) {
 	if cfg.Agent.Language == "" {
 		cfg.Agent.Language = "en"
 	}
+	if cfg.Audit.MaxSizeMB == 0 {
+		cfg.Audit.MaxSizeMB = 10
+	}
+	if cfg.Audit.MaxFiles == 0 {
+		cfg.Audit.MaxFiles = 5
+	}
+	if cfg.Lessons.RetireAfter == 0 {
+		cfg.Lessons.RetireAfter = 10
+	}
+	if cfg.Release.TagPrefix == "" {
+		cfg.Release.TagPrefix = "v"
+	}
+	if cfg.Release.Changelog == "" {
+		cfg.Release.Changelog = "CHANGELOG.md"
+	}
 	if cfg.Branches.Main == "" {
 		cfg.Branches.Main = "main"
 	}
 	if cfg.Branches.Develop == "" {
 		cfg.Branches.Develop = "develop"
 	}
+	if cfg.IntegrationCheck.TimeoutMinutes == 0 {
+		cfg.IntegrationCheck.TimeoutMinutes = 20
+	}
+	if cfg.Verify.TimeoutMinutes == 0 {
+		cfg.Verify.TimeoutMinutes = 20
+	}
+	if cfg.GitIdentity.Name == "" {
+		cfg.GitIdentity.Name = "madflow-{agent}"
+	}
+	if cfg.GitIdentity.Email == "" {
+		cfg.GitIdentity.Email = "madflow-{agent}@madflow.invalid"
+	}
+	if cfg.Guardrails.ProtectedPaths == nil {
+		cfg.Guardrails.ProtectedPaths = []string{".github/workflows/*"}
+	}
+	if cfg.Branches.UpdateStrategy == "" {
+		cfg.Branches.UpdateStrategy = "merge"
+	}
 	// FeaturePrefix default is applied after GhLogin is resolved in applyGhLogin.
 	// Leave it empty here so applyGhLogin can detect whether the user set it explicitly.
 	if cfg.GitHub != nil && cfg.GitHub.SyncIntervalMinutes == 0 {
@@ -228,12 +569,55 @@ func setDefaults(cfg *Config) {
 	}
 	// DormancyThresholdMinutes intentionally has no default (0 = disabled).
 	// Users must opt-in by setting a positive value in their config.
+	if cfg.Sandbox != nil && cfg.Sandbox.Mode == "" {
+		cfg.Sandbox.Mode = "auto"
+	}
+	if cfg.Sandbox != nil && cfg.Sandbox.Network == "" {
+		cfg.Sandbox.Network = "allow"
+	}
 }
 
 func warnDefaults(cfg *Config) {
+	for _, w := range warnings(cfg) {
+		log.Printf("[config] WARNING: %s", w.Message)
+	}
+}
+
+// warnings returns settings that are valid but probably not what the user
+// intended.
+func warnings(cfg *Config) []Finding {
+	var ws []Finding
 	if cfg.Agent.ContextResetMinutes < 10 {
-		log.Printf("[config] WARNING: context_reset_minutes=%d is below 10; short intervals cause redundant completions — consider 15+", cfg.Agent.ContextResetMinutes)
+		ws = append(ws, Finding{
+			Key:     "agent.context_reset_minutes",
+			Message: fmt.Sprintf("context_reset_minutes=%d is below 10; short intervals cause redundant completions — consider 15+", cfg.Agent.ContextResetMinutes),
+			Warning: true,
+		})
 	}
+	if gh := cfg.GitHub; gh != nil {
+		if gh.IdlePollMinutes*60 <= gh.EventPollSeconds {
+			ws = append(ws, Finding{
+				Key:     "github.idle_poll_minutes",
+				Message: fmt.Sprintf("github.idle_poll_minutes=%d is not longer than github.event_poll_seconds=%d; idle mode will not reduce polling", gh.IdlePollMinutes, gh.EventPollSeconds),
+				Warning: true,
+			})
+		}
+		if gh.DormancyThresholdMinutes > 0 && gh.DormancyThresholdMinutes <= gh.IdleThresholdMinutes {
+			ws = append(ws, Finding{
+				Key:     "github.dormancy_threshold_minutes",
+				Message: fmt.Sprintf("github.dormancy_threshold_minutes=%d is not longer than github.idle_threshold_minutes=%d; polling stops without an idle phase", gh.DormancyThresholdMinutes, gh.IdleThresholdMinutes),
+				Warning: true,
+			})
+		}
+	}
+	if cfg.Release.GitHubRelease && (cfg.Release.NoPush || cfg.GitHub == nil) {
+		ws = append(ws, Finding{
+			Key:     "release.github_release",
+			Message: "release.github_release has no effect without [github] or with release.no_push",
+			Warning: true,
+		})
+	}
+	return ws
 }
 
 func validate(cfg *Config) error {
@@ -251,9 +635,223 @@ func validate(cfg *Config) error {
 			return fmt.Errorf("project.repos[%d].path is required", i)
 		}
 	}
+	if err := validateRanges(cfg); err != nil {
+		return err
+	}
+	if err := validateModel("agent.models.superintendent", cfg.Agent.Models.Superintendent); err != nil {
+		return err
+	}
+	if err := validateModel("agent.models.engineer", cfg.Agent.Models.Engineer); err != nil {
+		return err
+	}
+	for name, p := range cfg.Presets {
+		if err := ValidatePreset(name, p); err != nil {
+			return err
+		}
+	}
+	if s := cfg.Branches.UpdateStrategy; s != "merge" && s != "rebase" {
+		return fmt.Errorf(`branches.update_strategy must be "merge" or "rebase", got %q`, s)
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Verify.Repos)) {
+		if !slices.ContainsFunc(cfg.Project.Repos, func(r RepoConfig) bool { return r.Name == name }) {
+			return fmt.Errorf("verify.repos.%s: no repository named %q in project.repos", name, name)
+		}
+	}
+	if err := validateVerify("verify", cfg.Verify); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Verify.Repos)) {
+		if err := validateVerify("verify.repos."+name, cfg.Verify.Repos[name]); err != nil {
+			return err
+		}
+	}
+	for _, p := range cfg.Guardrails.ProtectedPaths {
+		if !guardPathRe.MatchString(p) {
+			return fmt.Errorf("guardrails.protected_paths: invalid pattern %q (letters, digits and _ . * ? / @ + , = ! [ ] - only)", p)
+		}
+	}
+	if f := cfg.Lessons.File; f != "" && !filepath.IsAbs(f) && !strings.HasPrefix(f, "~/") {
+		return fmt.Errorf("lessons.file must be an absolute path or start with ~/, got %q", f)
+	}
+	if m := cfg.Lessons.Model; m != "" && !(strings.HasPrefix(m, "openai/") && len(m) > len("openai/")) {
+		if err := validateModel("lessons.model", m); err != nil {
+			return fmt.Errorf("%w, or openai/*", err)
+		}
+	}
+	if u := cfg.Lessons.BaseURL; u != "" && !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return fmt.Errorf("lessons.base_url must be an http(s) URL, got %q", u)
+	}
+	if c := cfg.Release.Changelog; filepath.IsAbs(c) || !filepath.IsLocal(c) {
+		return fmt.Errorf("release.changelog must be a path inside the repository, got %q", c)
+	}
+	for _, p := range cfg.Redaction.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("redaction: invalid pattern %q: %w", p, err)
+		}
+	}
+	for i, r := range cfg.Screening.Rules {
+		if r.Pattern == "" {
+			return fmt.Errorf("screening.rules[%d].pattern is required", i)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("screening.rules[%d]: invalid pattern %q: %w", i, r.Pattern, err)
+		}
+	}
+	if sb := cfg.Sandbox; sb != nil {
+		switch sb.Mode {
+		case "auto", "bwrap", "unshare", "none":
+		default:
+			return fmt.Errorf("sandbox.mode must be one of auto, bwrap, unshare, none (got %q)", sb.Mode)
+		}
+		if sb.Network != "allow" && sb.Network != "deny" {
+			return fmt.Errorf("sandbox.network must be \"allow\" or \"deny\" (got %q)", sb.Network)
+		}
+		for _, p := range sb.WritablePaths {
+			if !filepath.IsAbs(expandHome(p)) {
+				return fmt.Errorf("sandbox.writable_paths must be absolute paths or start with ~/, got %q", p)
+			}
+		}
+		for _, p := range append(append([]string{}, sb.DenyCommands...), sb.AllowCommands...) {
+			if _, err := regexp.Compile(p); err != nil {
+				return fmt.Errorf("sandbox: invalid command pattern %q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateVerify checks the coverage setting of a [verify] table.
+func validateVerify(key string, v VerifyConfig) error {
+	if v.MinCoverage < 0 || v.MinCoverage > 100 {
+		return fmt.Errorf("%s.min_coverage must be between 0 and 100 (got %g)", key, v.MinCoverage)
+	}
+	if v.MinCoverage > 0 && v.Test == "" {
+		return fmt.Errorf("%s.min_coverage requires %s.test", key, key)
+	}
+	return nil
+}
+
+// validateRanges rejects numeric settings outside their valid range. Zero
+// means "use the default" for most settings and has already been replaced by
+// setDefaults, so only negative values are rejected here.
+func validateRanges(cfg *Config) error {
+	type field struct {
+		key string
+		v   int
+		min int
+	}
+	fields := []field{
+		{"agent.context_reset_minutes", cfg.Agent.ContextResetMinutes, 1},
+		{"agent.max_teams", cfg.Agent.MaxTeams, 1},
+		{"agent.chatlog_max_lines", cfg.Agent.ChatlogMaxLines, 1},
+		{"agent.main_check_interval_hours", cfg.Agent.MainCheckIntervalHours, 0},
+		{"agent.doc_check_interval_hours", cfg.Agent.DocCheckIntervalHours, 0},
+		{"agent.gemini_rpm", cfg.Agent.GeminiRPM, 1},
+		{"agent.dormancy_probe_minutes", cfg.Agent.DormancyProbeMinutes, 1},
+		{"agent.bash_timeout_minutes", cfg.Agent.BashTimeoutMinutes, 1},
+		// -1 disables the issue patrol.
+		{"agent.issue_patrol_interval_minutes", cfg.Agent.IssuePatrolIntervalMinutes, -1},
+		{"agent.worktree_cleanup_interval_minutes", cfg.Agent.WorktreeCleanupIntervalMinutes, 0},
+		{"agent.merged_worktree_cleanup_interval_minutes", cfg.Agent.MergedWorktreeCleanupIntervalMinutes, 0},
+		{"branches.cleanup_interval_minutes", cfg.Branches.CleanupIntervalMinutes, 0},
+		{"branches.update_interval_minutes", cfg.Branches.UpdateIntervalMinutes, 0},
+		{"integration_check.interval_minutes", cfg.IntegrationCheck.IntervalMinutes, 0},
+		{"integration_check.timeout_minutes", cfg.IntegrationCheck.TimeoutMinutes, 1},
+		// -1 disables retiring lessons.
+		{"lessons.retire_after", cfg.Lessons.RetireAfter, -1},
+		{"verify.timeout_minutes", cfg.Verify.TimeoutMinutes, 1},
+		{"guardrails.max_diff_lines", cfg.Guardrails.MaxDiffLines, 0},
+		{"audit.max_size_mb", cfg.Audit.MaxSizeMB, 1},
+		{"audit.max_files", cfg.Audit.MaxFiles, 1},
+	}
+	for i, r := range cfg.Project.Repos {
+		fields = append(fields, field{fmt.Sprintf("project.repos[%d].pool_size", i), r.PoolSize, 0})
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Verify.Repos)) {
+		fields = append(fields, field{"verify.repos." + name + ".timeout_minutes", cfg.Verify.Repos[name].TimeoutMinutes, 0})
+	}
+	if gh := cfg.GitHub; gh != nil {
+		fields = append(fields,
+			field{"github.sync_interval_minutes", gh.SyncIntervalMinutes, 1},
+			field{"github.event_poll_seconds", gh.EventPollSeconds, 1},
+			field{"github.idle_poll_minutes", gh.IdlePollMinutes, 1},
+			field{"github.idle_threshold_minutes", gh.IdleThresholdMinutes, 1},
+			field{"github.dormancy_threshold_minutes", gh.DormancyThresholdMinutes, 0},
+		)
+	}
+	for _, f := range fields {
+		if f.v < f.min {
+			return fmt.Errorf("%s must be at least %d (got %d)", f.key, f.min, f.v)
+		}
+	}
+	return nil
+}
+
+// ValidatePreset checks that p names two valid models and only known [agent]
+// settings.
+func ValidatePreset(name string, p Preset) error {
+	if err := validateModel(fmt.Sprintf("presets.%s.superintendent", name), p.Superintendent); err != nil {
+		return err
+	}
+	if err := validateModel(fmt.Sprintf("presets.%s.engineer", name), p.Engineer); err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, k := range knownKeys() {
+		known[k] = true
+	}
+	for k := range p.Agent {
+		if k == "models" || !known["agent."+k] {
+			return fmt.Errorf("presets.%s.agent: unknown setting %q", name, k)
+		}
+	}
 	return nil
 }
 
+// ReadPresets reads the [presets.<name>] tables of a TOML file (and the files
+// it includes). It is used for the project config and ~/.madflow/presets.toml.
+func ReadPresets(path string) (map[string]Preset, error) {
+	l, err := readLayers(path, "")
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := l.decode()
+	if err != nil {
+		return nil, err
+	}
+	for name, p := range cfg.Presets {
+		if err := ValidatePreset(name, p); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return cfg.Presets, nil
+}
+
+// ValidateModel checks that model names a supported agent backend (see
+// validateModel). key is used in the error message.
+func ValidateModel(key, model string) error {
+	return validateModel(key, model)
+}
+
+// claudeModelAliases are the model aliases accepted by the Claude CLI.
+var claudeModelAliases = map[string]bool{"opus": true, "sonnet": true, "haiku": true}
+
+// validateModel checks that model selects one of the agent backends:
+// the Claude CLI (claude-* or an alias), the Anthropic API (anthropic/claude-*),
+// the Gemini API (gemini-*), the Copilot CLI (copilot/*) or "test".
+func validateModel(key, model string) error {
+	switch {
+	case model == "test", claudeModelAliases[model]:
+		return nil
+	case strings.HasPrefix(model, "anthropic/claude-"),
+		strings.HasPrefix(model, "gemini-") && len(model) > len("gemini-"),
+		strings.HasPrefix(model, "copilot/") && len(model) > len("copilot/"),
+		strings.HasPrefix(model, "claude-") && len(model) > len("claude-"):
+		return nil
+	}
+	return fmt.Errorf("%s: unknown model %q (expected claude-*, anthropic/claude-*, gemini-*, copilot/* or an alias: opus, sonnet, haiku)", key, model)
+}
+
 // resolveGitHubLogin calls the GitHub CLI to get the currently authenticated
 // user's login name. Returns an empty string if the CLI is unavailable or the
 // user is not authenticated.
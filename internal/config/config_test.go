@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -911,3 +913,453 @@ repos = ["testrepo"]
 		t.Errorf("expected empty bot_comment_patterns, got %v", cfg.GitHub.BotCommentPatterns)
 	}
 }
+
+func TestSandboxDefault(t *testing.T) {
+	content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+[sandbox]
+enabled = true
+deny_commands = ["rm\\s+-rf\\s+/"]
+writable_paths = ["~/go/pkg/mod", "/var/cache/build"]
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Sandbox == nil {
+		t.Fatal("expected Sandbox config, got nil")
+	}
+	if !cfg.Sandbox.Enabled {
+		t.Error("expected sandbox enabled")
+	}
+	if cfg.Sandbox.Mode != "auto" {
+		t.Errorf("expected default mode 'auto', got %q", cfg.Sandbox.Mode)
+	}
+	if cfg.Sandbox.Network != "allow" {
+		t.Errorf("expected default network 'allow', got %q", cfg.Sandbox.Network)
+	}
+	if len(cfg.Sandbox.DenyCommands) != 1 {
+		t.Errorf("expected 1 deny pattern, got %v", cfg.Sandbox.DenyCommands)
+	}
+	home, _ := os.UserHomeDir()
+	if got, want := cfg.Sandbox.Writable(), []string{filepath.Join(home, "go/pkg/mod"), "/var/cache/build"}; !slices.Equal(got, want) {
+		t.Errorf("Writable() = %v, want %v", got, want)
+	}
+}
+
+func TestSandboxAbsent(t *testing.T) {
+	content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Sandbox != nil {
+		t.Errorf("expected nil Sandbox config, got %+v", cfg.Sandbox)
+	}
+}
+
+func TestSandboxValidationError(t *testing.T) {
+	tests := map[string]string{
+		"invalid mode":    `mode = "docker"`,
+		"invalid network": `network = "partial"`,
+		"invalid pattern": `deny_commands = ["("]`,
+		"relative path":   `writable_paths = ["cache"]`,
+	}
+	for name, sandbox := range tests {
+		t.Run(name, func(t *testing.T) {
+			content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+[sandbox]
+enabled = true
+` + sandbox + "\n"
+			dir := t.TempDir()
+			path := filepath.Join(dir, "madflow.toml")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+}
+
+func TestRedactionConfig(t *testing.T) {
+	content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+[redaction]
+patterns = ["CORP-[0-9]{6}"]
+env_vars = ["DB_PASSWORD"]
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Redaction.Disabled {
+		t.Error("expected redaction enabled by default")
+	}
+	if len(cfg.Redaction.Patterns) != 1 || len(cfg.Redaction.EnvVars) != 1 {
+		t.Errorf("unexpected redaction config: %+v", cfg.Redaction)
+	}
+}
+
+func TestRedactionInvalidPattern(t *testing.T) {
+	content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+[redaction]
+patterns = ["("]
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected validation error")
+	}
+}
+
+func TestScreeningConfig(t *testing.T) {
+	content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+[screening]
+classifier_model = "claude-haiku-4-5"
+
+[[screening.rules]]
+name = "deploy"
+pattern = "(?i)deploy to production"
+reason = "asks for a production deploy"
+`
+	dir := t.TempDir()
+	path := filepath.Join(dir, "madflow.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Screening.Disabled {
+		t.Error("expected screening enabled by default")
+	}
+	if cfg.Screening.ClassifierModel != "claude-haiku-4-5" {
+		t.Errorf("classifier_model = %q", cfg.Screening.ClassifierModel)
+	}
+	if len(cfg.Screening.Rules) != 1 || cfg.Screening.Rules[0].Name != "deploy" {
+		t.Errorf("unexpected screening rules: %+v", cfg.Screening.Rules)
+	}
+}
+
+func TestScreeningInvalidRule(t *testing.T) {
+	tests := map[string]string{
+		"missing pattern": "[[screening.rules]]\nname = \"x\"\n",
+		"invalid pattern": "[[screening.rules]]\nname = \"x\"\npattern = \"(\"\n",
+	}
+	for name, rules := range tests {
+		t.Run(name, func(t *testing.T) {
+			content := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+` + rules
+			path := filepath.Join(t.TempDir(), "madflow.toml")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Fatal("expected validation error")
+			}
+		})
+	}
+}
+
+func TestReleaseConfig(t *testing.T) {
+	base := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+`
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	if err := os.WriteFile(path, []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Release.TagPrefix != "v" || cfg.Release.Changelog != "CHANGELOG.md" {
+		t.Errorf("unexpected release defaults: %+v", cfg.Release)
+	}
+	if ws := warnings(cfg); len(ws) != 0 {
+		t.Errorf("unexpected warnings: %+v", ws)
+	}
+
+	cfg.Release.GitHubRelease = true
+	if ws := warnings(cfg); len(ws) != 1 || ws[0].Key != "release.github_release" {
+		t.Errorf("github_release without [github] should warn, got %+v", ws)
+	}
+
+	for _, changelog := range []string{"/tmp/CHANGELOG.md", "../CHANGELOG.md"} {
+		content := base + "\n[release]\nchangelog = \"" + changelog + "\"\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("changelog %q: expected validation error", changelog)
+		}
+	}
+}
+
+func TestBranchUpdateConfig(t *testing.T) {
+	base := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "main"
+path = "."
+
+[branches]
+`
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	if err := os.WriteFile(path, []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Branches.UpdateIntervalMinutes != 0 || cfg.Branches.UpdateStrategy != "merge" {
+		t.Errorf("unexpected defaults: %+v", cfg.Branches)
+	}
+
+	if err := os.WriteFile(path, []byte(base+"update_strategy = \"squash\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "branches.update_strategy") {
+		t.Errorf("Load = %v, want an update_strategy error", err)
+	}
+}
+
+func TestVerifyConfig(t *testing.T) {
+	base := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "app"
+path = "."
+
+[[project.repos]]
+name = "docs"
+path = "docs"
+
+[verify]
+build = "go build ./..."
+test = "go test -cover ./..."
+min_coverage = 55
+`
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(base + "\n[verify.repos.docs]\nlint = \"markdownlint .\"\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	app := cfg.Verify.For("app")
+	if app.Build != "go build ./..." || app.MinCoverage != 55 || app.TimeoutMinutes != 20 || !app.Enabled() {
+		t.Errorf("For(app) = %+v", app)
+	}
+	docs := cfg.Verify.For("docs")
+	if docs.Build != "" || docs.Test != "" || docs.Lint != "markdownlint ." || docs.MinCoverage != 0 || docs.TimeoutMinutes != 20 {
+		t.Errorf("For(docs) = %+v, want only its own lint and the top-level timeout", docs)
+	}
+	if (VerifyConfig{}).Enabled() {
+		t.Error("empty VerifyConfig should not be enabled")
+	}
+
+	for _, tt := range []struct{ extra, want string }{
+		{"\n[verify.repos.web]\ntest = \"npm test\"\n", "verify.repos.web"},
+		{"\n[verify.repos.docs]\nmin_coverage = 80\n", "verify.repos.docs.min_coverage requires"},
+		{"\n[verify.repos.app]\ntest = \"go test\"\nmin_coverage = 101\n", "verify.repos.app.min_coverage"},
+	} {
+		write(base + tt.extra)
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load with %q = %v, want an error containing %q", tt.extra, err, tt.want)
+		}
+	}
+}
+
+func TestGuardrailsConfig(t *testing.T) {
+	base := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "app"
+path = "."
+`
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	load := func(extra string) (*Config, error) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(base+extra), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return Load(path)
+	}
+
+	cfg, err := load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if g := cfg.Guardrails; g.Disabled || g.AllowForcePush || g.MaxDiffLines != 0 || !slices.Equal(g.ProtectedPaths, []string{".github/workflows/*"}) {
+		t.Errorf("defaults = %+v", g)
+	}
+	cfg, err = load("\n[guardrails]\nprotected_paths = []\nmax_diff_lines = 800\n")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if g := cfg.Guardrails; len(g.ProtectedPaths) != 0 || g.MaxDiffLines != 800 {
+		t.Errorf("guardrails = %+v, want no protected paths", g)
+	}
+
+	for _, tt := range []struct{ extra, want string }{
+		{"\n[guardrails]\nprotected_paths = [\"a b\"]\n", "guardrails.protected_paths"},
+		{"\n[guardrails]\nprotected_paths = [\"$(rm -rf /)\"]\n", "guardrails.protected_paths"},
+		{"\n[guardrails]\nmax_diff_lines = -1\n", "guardrails.max_diff_lines"},
+	} {
+		if _, err := load(tt.extra); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load with %q = %v, want an error containing %q", tt.extra, err, tt.want)
+		}
+	}
+}
+
+func TestLessonsConfig(t *testing.T) {
+	base := `
+[project]
+name = "test-app"
+
+[[project.repos]]
+name = "app"
+path = "."
+`
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	load := func(extra string) (*Config, error) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(base+extra), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return Load(path)
+	}
+
+	cfg, err := load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p := cfg.Lessons.Path(); p != "" {
+		t.Errorf("default lessons path = %q, want empty", p)
+	}
+	if n := cfg.Lessons.RetireAfter; n != 10 {
+		t.Errorf("default lessons.retire_after = %d, want 10", n)
+	}
+	if cfg, err := load("\n[lessons]\nretire_after = -1\n"); err != nil || cfg.Lessons.RetireAfter != -1 {
+		t.Errorf("Load with lessons.retire_after = -1: %v", err)
+	}
+
+	t.Setenv("HOME", "/home/test")
+	cfg, err = load("\n[lessons]\nfile = \"~/shared/lessons.txt\"\n")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p, want := cfg.Lessons.Path(), filepath.Join("/home/test", "shared", "lessons.txt"); p != want {
+		t.Errorf("lessons path = %q, want %q", p, want)
+	}
+
+	for _, model := range []string{"haiku", "anthropic/claude-haiku-4-5", "gemini-2.5-flash", "openai/gpt-4o-mini"} {
+		if _, err := load("\n[lessons]\nmodel = \"" + model + "\"\nbase_url = \"http://localhost:11434/v1\"\n"); err != nil {
+			t.Errorf("Load with lessons.model %q: %v", model, err)
+		}
+	}
+
+	for _, tt := range []struct{ extra, want string }{
+		{"\n[lessons]\nfile = \"lessons.txt\"\n", "lessons.file"},
+		{"\n[lessons]\nmodel = \"gpt-4o\"\n", "lessons.model"},
+		{"\n[lessons]\nmodel = \"openai/\"\n", "lessons.model"},
+		{"\n[lessons]\nbase_url = \"localhost:11434\"\n", "lessons.base_url"},
+		{"\n[lessons]\nretire_after = -2\n", "lessons.retire_after"},
+	} {
+		if _, err := load(tt.extra); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load with %q = %v, want an error containing %q", tt.extra, err, tt.want)
+		}
+	}
+}
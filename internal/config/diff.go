@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is a single setting that differs between two configurations.
+type Change struct {
+	// Key is the dotted TOML key, e.g. "agent.models.engineer". A whole
+	// section that was added or removed is reported by its section key.
+	Key string
+	Old string
+	New string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s (%s -> %s)", c.Key, c.Old, c.New)
+}
+
+// Diff returns the settings that differ between old and new, in declaration
+// order. Fields that are not read from the config file (toml:"-") are ignored.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffValue("", reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), &changes)
+	return changes
+}
+
+func diffValue(key string, a, b reflect.Value, changes *[]Change) {
+	switch a.Kind() {
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			*changes = append(*changes, Change{Key: key, Old: "(none)", New: "(set)"})
+		case b.IsNil():
+			*changes = append(*changes, Change{Key: key, Old: "(set)", New: "(none)"})
+		default:
+			diffValue(key, a.Elem(), b.Elem(), changes)
+		}
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+			if name == "-" || !t.Field(i).IsExported() {
+				continue
+			}
+			if name == "" {
+				name = t.Field(i).Name
+			}
+			if key != "" {
+				name = key + "." + name
+			}
+			diffValue(name, a.Field(i), b.Field(i), changes)
+		}
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changes = append(*changes, Change{Key: key, Old: formatValue(a), New: formatValue(b)})
+		}
+	}
+}
+
+// maxDiffValueLen limits how much of a value (e.g. extra_prompt) is shown.
+const maxDiffValueLen = 60
+
+func formatValue(v reflect.Value) string {
+	var s string
+	if v.Kind() == reflect.String {
+		s = fmt.Sprintf("%q", v.String())
+	} else {
+		s = fmt.Sprintf("%v", v.Interface())
+	}
+	if len(s) > maxDiffValueLen {
+		s = s[:maxDiffValueLen] + "..."
+	}
+	return s
+}
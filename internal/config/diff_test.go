@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := &Config{
+		Project: ProjectConfig{Name: "app", Repos: []RepoConfig{{Name: "main", Path: "."}}},
+		Agent:   AgentConfig{MaxTeams: 2, Models: ModelConfig{Engineer: "a"}},
+		GhLogin: "alice",
+	}
+	updated := *old
+	updated.Agent.MaxTeams = 4
+	updated.Agent.Models.Engineer = "b"
+	updated.GitHub = &GitHubConfig{Owner: "org"}
+	updated.GhLogin = "bob" // not read from the file; ignored
+
+	var keys []string
+	for _, c := range Diff(old, &updated) {
+		keys = append(keys, c.String())
+	}
+	want := []string{
+		"agent.max_teams (2 -> 4)",
+		`agent.models.engineer ("a" -> "b")`,
+		"github ((none) -> (set))",
+	}
+	if strings.Join(keys, "|") != strings.Join(want, "|") {
+		t.Errorf("Diff = %q, want %q", keys, want)
+	}
+
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Errorf("Diff of identical configs = %v", changes)
+	}
+}
+
+func TestDiffNestedPointerAndTruncation(t *testing.T) {
+	old := &Config{GitHub: &GitHubConfig{Repos: []string{"a"}}}
+	updated := &Config{
+		GitHub: &GitHubConfig{Repos: []string{"a", "b"}},
+		Agent:  AgentConfig{ExtraPrompt: strings.Repeat("x", 200)},
+	}
+	changes := Diff(old, updated)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %v", len(changes), changes)
+	}
+	if changes[0].Key != "agent.extra_prompt" || len(changes[0].New) > maxDiffValueLen+3 {
+		t.Errorf("unexpected change: %+v", changes[0])
+	}
+	if changes[1].Key != "github.repos" || changes[1].New != "[a b]" {
+		t.Errorf("unexpected change: %+v", changes[1])
+	}
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvPrefix is the prefix of environment variables that override config keys:
+// agent.max_teams is overridden by MADFLOW_AGENT_MAX_TEAMS.
+const EnvPrefix = "MADFLOW_"
+
+// ProfileEnv selects a profile when none is given on the command line.
+const ProfileEnv = "MADFLOW_PROFILE"
+
+// layered is the result of merging a config file with its includes, the
+// selected profile and the environment overrides.
+type layered struct {
+	tree map[string]any
+	// files are the config files read, the main file first.
+	files []string
+}
+
+// readLayers builds the effective key/value tree for path:
+//
+//  1. files listed in `include` (relative to the including file), in order,
+//     each overlaid by the next, with the including file on top;
+//  2. the [profiles.<profile>] table, if profile is not empty;
+//  3. MADFLOW_* environment variables.
+//
+// Tables are merged key by key; any other value, including arrays of tables
+// such as [[project.repos]], replaces the value of the layer below.
+func readLayers(path, profile string) (*layered, error) {
+	l := &layered{}
+	tree, err := l.readFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, _ := tree["profiles"].(map[string]any)
+	delete(tree, "profiles")
+	if profile != "" {
+		overlay, ok := profiles[profile].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("profile %q is not defined (add a [profiles.%s] table)", profile, profile)
+		}
+		mergeTree(tree, overlay)
+	}
+
+	if err := applyEnv(tree, os.Environ()); err != nil {
+		return nil, err
+	}
+	l.tree = tree
+	return l, nil
+}
+
+// readFile reads path and the files it includes. stack holds the files being
+// read, to detect include cycles.
+func (l *layered) readFile(path string, stack []string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", path, err)
+	}
+	for _, p := range stack {
+		if p == abs {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if len(stack) > 0 {
+			return nil, fmt.Errorf("include %s: %w", path, err)
+		}
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	l.files = append(l.files, abs)
+
+	tree := make(map[string]any)
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	includes, err := includeList(tree["include"])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(tree, "include")
+	if len(includes) == 0 {
+		return tree, nil
+	}
+
+	base := make(map[string]any)
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(abs), inc)
+		}
+		t, err := l.readFile(inc, append(stack, abs))
+		if err != nil {
+			return nil, err
+		}
+		mergeTree(base, t)
+	}
+	mergeTree(base, tree)
+	return base, nil
+}
+
+// includeList accepts `include = "file"` and `include = ["a", "b"]`.
+func includeList(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("include must be a string or an array of strings")
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("include must be a string or an array of strings")
+}
+
+// mergeTree overlays src onto dst.
+func mergeTree(dst, src map[string]any) {
+	for k, v := range src {
+		if sm, ok := v.(map[string]any); ok {
+			if dm, ok := dst[k].(map[string]any); ok {
+				mergeTree(dm, sm)
+				continue
+			}
+			cp := make(map[string]any)
+			mergeTree(cp, sm)
+			dst[k] = cp
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// decode decodes the merged tree into a Config.
+func (l *layered) decode() (*Config, toml.MetaData, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(l.tree); err != nil {
+		return nil, toml.MetaData{}, fmt.Errorf("encode merged config: %w", err)
+	}
+	var cfg Config
+	md, err := toml.Decode(buf.String(), &cfg)
+	if err != nil {
+		return nil, toml.MetaData{}, fmt.Errorf("parse config: %w", err)
+	}
+	return &cfg, md, nil
+}
+
+// envKeys maps environment variable names to the config keys they override
+// and the type of the field. Keys inside arrays of tables ([[project.repos]],
+// [[screening.rules]]) and [presets] cannot be overridden.
+func envKeys() map[string]envKey {
+	keys := make(map[string]envKey)
+	var walk func(prefix string, t reflect.Type)
+	walk = func(prefix string, t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			switch {
+			case ft.Kind() == reflect.Struct:
+				walk(name, ft)
+			case ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.String,
+				ft.Kind() == reflect.Map:
+			default:
+				env := EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+				keys[env] = envKey{key: name, kind: ft.Kind()}
+			}
+		}
+	}
+	walk("", reflect.TypeOf(Config{}))
+	return keys
+}
+
+type envKey struct {
+	key  string
+	kind reflect.Kind
+}
+
+// applyEnv sets the keys overridden by MADFLOW_* variables in environ.
+func applyEnv(tree map[string]any, environ []string) error {
+	keys := envKeys()
+	var names []string
+	values := make(map[string]string)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if _, known := keys[name]; ok && known {
+			names = append(names, name)
+			values[name] = value
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		k := keys[name]
+		v, err := parseEnvValue(k.kind, values[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		setTreeValue(tree, k.key, v)
+	}
+	return nil
+}
+
+func parseEnvValue(kind reflect.Kind, s string) (any, error) {
+	switch kind {
+	case reflect.Int:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return n, nil
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", s)
+		}
+		return f, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", s)
+		}
+		return b, nil
+	case reflect.Slice:
+		// A TOML array (["a", "b"]) or a comma-separated list.
+		if strings.HasPrefix(strings.TrimSpace(s), "[") {
+			var v struct{ V []string }
+			if _, err := toml.Decode("V = "+s, &v); err != nil {
+				return nil, fmt.Errorf("invalid array %q: %w", s, err)
+			}
+			return v.V, nil
+		}
+		var list []string
+		for _, e := range strings.Split(s, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				list = append(list, e)
+			}
+		}
+		return list, nil
+	}
+	return s, nil
+}
+
+// setTreeValue sets the dotted key in tree, creating tables as needed.
+func setTreeValue(tree map[string]any, key string, v any) {
+	parts := strings.Split(key, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := tree[p].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			tree[p] = next
+		}
+		tree = next
+	}
+	tree[parts[len(parts)-1]] = v
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared", "org.toml"), `
+[agent]
+max_teams = 2
+extra_prompt = "org rules"
+
+[agent.models]
+engineer = "claude-sonnet-4-6"
+
+[[project.repos]]
+name = "org"
+path = "/tmp/org"
+`)
+	path := filepath.Join(dir, "madflow.toml")
+	writeFile(t, path, `include = "shared/org.toml"
+`+baseConfig)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Agent.MaxTeams != 2 || cfg.Agent.ExtraPrompt != "org rules" {
+		t.Errorf("included values not applied: %+v", cfg.Agent)
+	}
+	// Tables merge key by key: the main file sets superintendent, the include engineer.
+	if cfg.Agent.Models.Engineer != "claude-sonnet-4-6" || cfg.Agent.Models.Superintendent != "claude-opus-4-6" {
+		t.Errorf("models = %+v", cfg.Agent.Models)
+	}
+	// Arrays of tables are replaced, not appended.
+	if len(cfg.Project.Repos) != 1 || cfg.Project.Repos[0].Name != "main" {
+		t.Errorf("repos = %+v", cfg.Project.Repos)
+	}
+	if len(cfg.Files) != 2 || !strings.HasSuffix(cfg.Files[1], "org.toml") {
+		t.Errorf("files = %v", cfg.Files)
+	}
+}
+
+func TestLoadIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.toml"), `include = ["b.toml"]`)
+	writeFile(t, filepath.Join(dir, "b.toml"), `include = ["a.toml"]`)
+	_, err := Load(filepath.Join(dir, "a.toml"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+}
+
+const profileConfig = baseConfig + `
+[profiles.ci.agent]
+max_teams = 8
+
+[profiles.ci.agent.models]
+engineer = "anthropic/claude-haiku-4-5"
+
+[profiles.ci.github]
+owner = "org"
+repos = ["build"]
+`
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	writeFile(t, path, profileConfig)
+
+	base, err := LoadProfile(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Agent.MaxTeams != 4 || base.GitHub != nil || base.Profile != "" {
+		t.Errorf("base config should not include the profile: %+v", base)
+	}
+
+	ci, err := LoadProfile(path, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Agent.MaxTeams != 8 || ci.Agent.Models.Engineer != "anthropic/claude-haiku-4-5" {
+		t.Errorf("profile not applied: %+v", ci.Agent)
+	}
+	if ci.Agent.Models.Superintendent != "claude-opus-4-6" || ci.Agent.ContextResetMinutes != 5 {
+		t.Errorf("base values lost: %+v", ci.Agent)
+	}
+	if ci.GitHub == nil || ci.GitHub.Owner != "org" || ci.Profile != "ci" {
+		t.Errorf("github = %+v, profile = %q", ci.GitHub, ci.Profile)
+	}
+
+	if _, err := LoadProfile(path, "prod"); err == nil || !strings.Contains(err.Error(), `profile "prod" is not defined`) {
+		t.Errorf("expected undefined profile error, got %v", err)
+	}
+
+	t.Setenv(ProfileEnv, "ci")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "ci" {
+		t.Errorf("MADFLOW_PROFILE not applied: profile = %q", cfg.Profile)
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "madflow.toml")
+	writeFile(t, path, profileConfig)
+
+	t.Setenv("MADFLOW_AGENT_MAX_TEAMS", "6")
+	t.Setenv("MADFLOW_AGENT_MODELS_ENGINEER", "gemini-2.5-pro")
+	t.Setenv("MADFLOW_GITHUB_REPOS", "a, b")
+	t.Setenv("MADFLOW_AUDIT_DISABLED", "true")
+	t.Setenv("MADFLOW_AUTHORIZED_USERS", `["alice", "bob"]`)
+	t.Setenv("MADFLOW_VERIFY_TEST", "go test -cover ./...")
+	t.Setenv("MADFLOW_VERIFY_MIN_COVERAGE", "80")
+
+	// Environment overrides win over the profile.
+	cfg, err := LoadProfile(path, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Agent.MaxTeams != 6 || cfg.Agent.Models.Engineer != "gemini-2.5-pro" || !cfg.Audit.Disabled {
+		t.Errorf("overrides not applied: %+v %+v", cfg.Agent, cfg.Audit)
+	}
+	if cfg.GitHub == nil || strings.Join(cfg.GitHub.Repos, ",") != "a,b" || cfg.GitHub.Owner != "org" {
+		t.Errorf("github = %+v", cfg.GitHub)
+	}
+	if strings.Join(cfg.AuthorizedUsers, ",") != "alice,bob" {
+		t.Errorf("authorized_users = %v", cfg.AuthorizedUsers)
+	}
+	if cfg.Verify.MinCoverage != 80 {
+		t.Errorf("verify.min_coverage = %v, want 80", cfg.Verify.MinCoverage)
+	}
+
+	t.Setenv("MADFLOW_VERIFY_MIN_COVERAGE", "most")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "MADFLOW_VERIFY_MIN_COVERAGE") {
+		t.Errorf("expected error naming the variable, got %v", err)
+	}
+
+	t.Setenv("MADFLOW_VERIFY_MIN_COVERAGE", "80")
+	t.Setenv("MADFLOW_AGENT_MAX_TEAMS", "many")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "MADFLOW_AGENT_MAX_TEAMS") {
+		t.Errorf("expected error naming the variable, got %v", err)
+	}
+}
+
+func TestWatcher_DetectsIncludeChange(t *testing.T) {
+	dir := t.TempDir()
+	inc := filepath.Join(dir, "org.toml")
+	writeFile(t, inc, "[agent]\nmax_teams = 2\n")
+	path := filepath.Join(dir, "madflow.toml")
+	writeFile(t, path, `include = "org.toml"`+"\n"+baseConfig)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch := NewWatcher(path).WithFiles(cfg.Files).Watch(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+	future := time.Now().Add(2 * time.Second)
+	writeFile(t, inc, "[agent]\nmax_teams = 7\n")
+	if err := os.Chtimes(inc, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case newCfg := <-ch:
+		if newCfg.Agent.MaxTeams != 7 {
+			t.Errorf("max_teams = %d, want 7", newCfg.Agent.MaxTeams)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for reload after include change")
+	}
+}
@@ -9,14 +9,33 @@ import (
 
 const watchInterval = 500 * time.Millisecond
 
-// Watcher monitors a config file for changes and emits validated new configs.
+// Watcher monitors a config file, and the files it includes, for changes and
+// emits validated new configs.
 type Watcher struct {
-	path string
+	path    string
+	profile string
+	files   []string
 }
 
 // NewWatcher creates a new Watcher for the given config file path.
 func NewWatcher(path string) *Watcher {
-	return &Watcher{path: path}
+	return &Watcher{path: path, files: []string{path}}
+}
+
+// WithProfile reloads the config with the given profile (see LoadProfile).
+func (w *Watcher) WithProfile(profile string) *Watcher {
+	w.profile = profile
+	return w
+}
+
+// WithFiles sets the files watched until the next successful reload, which
+// replaces them with the files that config read. Pass Config.Files of the
+// active config so that changes to included files are detected.
+func (w *Watcher) WithFiles(files []string) *Watcher {
+	if len(files) > 0 {
+		w.files = files
+	}
+	return w
 }
 
 // Watch polls the config file for changes and sends validated new configs to
@@ -46,7 +65,7 @@ func (w *Watcher) Watch(ctx context.Context) <-chan *Config {
 				}
 
 				// File changed; try to load and validate.
-				newCfg, err := Load(w.path)
+				newCfg, err := LoadProfile(w.path, w.profile)
 				if err != nil {
 					log.Printf("[config watcher] reload failed (keeping current config): %v", err)
 					// Update modTime so we don't spam the log on every tick.
@@ -55,6 +74,10 @@ func (w *Watcher) Watch(ctx context.Context) <-chan *Config {
 				}
 
 				lastModTime = modTime
+				if len(newCfg.Files) > 0 {
+					w.files = newCfg.Files
+					lastModTime = w.currentModTime()
+				}
 				log.Printf("[config watcher] config reloaded from %s", w.path)
 
 				// Non-blocking send: if the consumer is slow we drop the older
@@ -73,12 +96,21 @@ func (w *Watcher) Watch(ctx context.Context) <-chan *Config {
 	return ch
 }
 
-// currentModTime returns the modification time of the watched file.
-// Returns zero time if the file cannot be stat'd.
+// currentModTime returns the latest modification time of the watched files.
+// Returns zero time if the main file cannot be stat'd.
 func (w *Watcher) currentModTime() time.Time {
-	info, err := os.Stat(w.path)
-	if err != nil {
+	if _, err := os.Stat(w.path); err != nil {
 		return time.Time{}
 	}
-	return info.ModTime()
+	var latest time.Time
+	for _, f := range w.files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
 }
@@ -0,0 +1,125 @@
+// Package filelock provides advisory file locks and crash-safe writes for
+// files shared between MADFLOW processes, goroutines and agent shell commands.
+//
+// Locks are taken on a sidecar "<path>.lock" file rather than on the data file
+// itself, so that the data file can be replaced by rename while locked.
+// Locks are advisory: they serialize MADFLOW writers, but a plain
+// `echo >> file` from a shell does not honor them. Such appends are still
+// safe against interleaving because every write here is a single O_APPEND
+// write of complete lines.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LockSuffix is appended to a path to form its lock file.
+const LockSuffix = ".lock"
+
+// Lock is an exclusive advisory lock held on "<path>.lock".
+type Lock struct {
+	f *os.File
+}
+
+// Acquire blocks until the exclusive lock for path is held.
+// The lock file is created if needed and is never removed.
+func Acquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path+LockSuffix, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release unlocks and closes the lock file. It is safe to call on nil.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// AppendLines appends lines to path under its lock, creating the file with
+// mode 0600 if needed. All lines are written with a single write call so
+// that concurrent appenders cannot interleave partial lines.
+func AppendLines(path string, lines ...string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	var buf []byte
+	for _, line := range lines {
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	lock, err := Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open %s for append: %w", filepath.Base(path), err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return fmt.Errorf("append %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers see either the old or the new content and
+// never a partially written file. The caller is responsible for locking if
+// several writers may race.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	// The leading dot keeps temp files out of directory listings that match
+	// on the final extension (e.g. "*.toml").
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpPath)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		cleanup()
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendLinesConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chatlog.txt")
+	long := strings.Repeat("x", 8192) // larger than PIPE_BUF
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if err := AppendLines(path, fmt.Sprintf("w%d-%d %s", w, i, long), fmt.Sprintf("w%d-%d end", w, i)); err != nil {
+					t.Error(err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 8*20*2 {
+		t.Fatalf("got %d lines, want %d", len(lines), 8*20*2)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		id, _, _ := strings.Cut(lines[i], " ")
+		if lines[i] != id+" "+long || lines[i+1] != id+" end" {
+			t.Fatalf("lines %d-%d interleaved: %.40q / %.40q", i, i+1, lines[i], lines[i+1])
+		}
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestAcquireExcludes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	first, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		second, err := Acquire(path)
+		if err != nil {
+			t.Error(err)
+		}
+		close(acquired)
+		second.Release()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second Acquire succeeded while the lock was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := first.Release(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Acquire did not proceed after Release")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "issue.toml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("content = %q, want new", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestReleaseNil(t *testing.T) {
+	var l *Lock
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
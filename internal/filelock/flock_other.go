@@ -0,0 +1,36 @@
+//go:build !unix
+
+package filelock
+
+import (
+	"os"
+	"sync"
+)
+
+// Platforms without flock(2) fall back to a process-wide lock keyed by the
+// lock file path. This serializes writers within one MADFLOW process only.
+var (
+	localMu    sync.Mutex
+	localLocks = make(map[string]*sync.Mutex)
+)
+
+func localLock(f *os.File) *sync.Mutex {
+	localMu.Lock()
+	defer localMu.Unlock()
+	mu, ok := localLocks[f.Name()]
+	if !ok {
+		mu = &sync.Mutex{}
+		localLocks[f.Name()] = mu
+	}
+	return mu
+}
+
+func lockFile(f *os.File) error {
+	localLock(f).Lock()
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	localLock(f).Unlock()
+	return nil
+}
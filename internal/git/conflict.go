@@ -0,0 +1,201 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Limits of a ConflictReport, which is sent to an engineer.
+const (
+	maxConflictHunks = 10
+	maxHunkLines     = 30
+)
+
+// ConflictHunk is one conflicted region of a file.
+type ConflictHunk struct {
+	// Line is the 1-based line of the "<<<<<<<" marker in the conflicted file.
+	Line int
+	// Ours and Theirs are the two sides as git labels them. Long sides are
+	// truncated.
+	Ours   string
+	Theirs string
+}
+
+// ConflictFile is a file that could not be merged.
+type ConflictFile struct {
+	Path string
+	// Hunks is empty for conflicts without markers (e.g. a file deleted on
+	// one side and modified on the other).
+	Hunks []ConflictHunk
+	// Truncated is set when the file had more than the reported hunks.
+	Truncated bool
+}
+
+// ConflictReport describes why bringing a branch up to date failed.
+type ConflictReport struct {
+	// Op is "merge" or "rebase".
+	Op string
+	// Onto is the revision that was merged or rebased onto.
+	Onto string
+	// Commit is the commit being applied when a rebase stopped
+	// ("<hash> <subject>"); empty for a merge.
+	Commit string
+	Files  []ConflictFile
+}
+
+// UpdateFromBase brings the current branch up to date with base, by merging
+// base into it or, with rebase set, by rebasing it onto base. On conflict
+// the operation is aborted, leaving the branch as it was, and the conflicts
+// are returned. A merge or rebase already in progress is an error.
+func (r *Repo) UpdateFromBase(base string, rebase bool) (*ConflictReport, error) {
+	if r.operationInProgress() {
+		return nil, fmt.Errorf("a merge or rebase is already in progress in %s", r.path)
+	}
+	op, args, abort := "merge", []string{"merge", "--no-edit", base}, []string{"merge", "--abort"}
+	if rebase {
+		op, args, abort = "rebase", []string{"rebase", base}, []string{"rebase", "--abort"}
+	}
+
+	_, err := r.run(args...)
+	if err == nil {
+		return nil, nil
+	}
+	files, _ := r.run("diff", "--name-only", "--diff-filter=U")
+	if strings.TrimSpace(files) == "" {
+		if r.operationInProgress() {
+			r.run(abort...) //nolint:errcheck // best-effort cleanup
+		}
+		return nil, fmt.Errorf("%s %s: %w", op, base, err)
+	}
+
+	report := &ConflictReport{Op: op, Onto: base}
+	if rebase {
+		if out, err := r.run("log", "-1", "--format=%h %s", "REBASE_HEAD"); err == nil {
+			report.Commit = strings.TrimSpace(out)
+		}
+	}
+	for _, path := range strings.Split(strings.TrimSpace(files), "\n") {
+		f := ConflictFile{Path: path}
+		f.Hunks, f.Truncated = conflictHunks(filepath.Join(r.path, path))
+		report.Files = append(report.Files, f)
+	}
+	if _, err := r.run(abort...); err != nil {
+		return report, fmt.Errorf("abort %s: %w", op, err)
+	}
+	return report, nil
+}
+
+// operationInProgress reports whether a merge or rebase is stopped in the
+// repository.
+func (r *Repo) operationInProgress() bool {
+	for _, name := range []string{"MERGE_HEAD", "rebase-merge", "rebase-apply"} {
+		out, err := r.run("rev-parse", "--git-path", name)
+		if err != nil {
+			continue
+		}
+		path := strings.TrimSpace(out)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.path, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictHunks reads the conflict markers of the file at path. Note that
+// during a rebase git's "ours" is the base and "theirs" the replayed commit.
+func conflictHunks(path string) (hunks []ConflictHunk, truncated bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+	state := outside
+	var cur ConflictHunk
+	var ours, theirs []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case state == outside && strings.HasPrefix(line, "<<<<<<<"):
+			state, cur, ours, theirs = inOurs, ConflictHunk{Line: n}, nil, nil
+		case state == inOurs && strings.HasPrefix(line, "|||||||"):
+			state = inBase
+		case (state == inOurs || state == inBase) && line == "=======":
+			state = inTheirs
+		case state == inTheirs && strings.HasPrefix(line, ">>>>>>>"):
+			state = outside
+			if len(hunks) == maxConflictHunks {
+				truncated = true
+				continue
+			}
+			cur.Ours, cur.Theirs = joinLimited(ours), joinLimited(theirs)
+			hunks = append(hunks, cur)
+		case state == inOurs:
+			ours = append(ours, line)
+		case state == inTheirs:
+			theirs = append(theirs, line)
+		}
+	}
+	return hunks, truncated
+}
+
+func joinLimited(lines []string) string {
+	if len(lines) > maxHunkLines {
+		n := len(lines) - maxHunkLines
+		lines = append(lines[:maxHunkLines:maxHunkLines], fmt.Sprintf("... (%d more lines)", n))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Markdown renders the report for the engineer who resolves the conflicts.
+func (c *ConflictReport) Markdown(branch string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conflicts updating %s from %s (%s)\n\n", branch, c.Onto, c.Op)
+	if c.Commit != "" {
+		fmt.Fprintf(&b, "Stopped at commit: %s\n\n", c.Commit)
+	}
+	for _, f := range c.Files {
+		fmt.Fprintf(&b, "## %s\n\n", f.Path)
+		if len(f.Hunks) == 0 {
+			b.WriteString("No conflict markers (the file was deleted, renamed or is binary on one side).\n\n")
+		}
+		for _, h := range f.Hunks {
+			fmt.Fprintf(&b, "### line %d\n\nours:\n```\n%s\n```\n\ntheirs:\n```\n%s\n```\n\n", h.Line, h.Ours, h.Theirs)
+		}
+		if f.Truncated {
+			fmt.Fprintf(&b, "(more than %d conflicts; only the first are shown)\n\n", maxConflictHunks)
+		}
+	}
+	return b.String()
+}
+
+// Summary is a one-line description of the conflicted files, e.g.
+// "a.go (2: L10, L40), b.go".
+func (c *ConflictReport) Summary() string {
+	parts := make([]string, len(c.Files))
+	for i, f := range c.Files {
+		if len(f.Hunks) == 0 {
+			parts[i] = f.Path
+			continue
+		}
+		lines := make([]string, len(f.Hunks))
+		for j, h := range f.Hunks {
+			lines[j] = fmt.Sprintf("L%d", h.Line)
+		}
+		parts[i] = fmt.Sprintf("%s (%d: %s)", f.Path, len(f.Hunks), strings.Join(lines, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// divergedRepo returns a repository whose branch "feature" and "develop"
+// both changed README.md since they forked; with conflict unset, develop
+// changes another file instead.
+func divergedRepo(t *testing.T, conflict bool) *Repo {
+	t.Helper()
+	repo := initTestRepo(t)
+	dir := repo.Path()
+	run(t, dir, "git", "branch", "develop")
+	run(t, dir, "git", "checkout", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Feature\n"), 0644)
+	run(t, dir, "git", "commit", "-am", "feature change")
+
+	run(t, dir, "git", "checkout", "develop")
+	file := "other.txt"
+	if conflict {
+		file = "README.md"
+	}
+	os.WriteFile(filepath.Join(dir, file), []byte("# Develop\n"), 0644)
+	run(t, dir, "git", "add", file)
+	run(t, dir, "git", "commit", "-m", "develop change")
+	run(t, dir, "git", "checkout", "feature")
+	return repo
+}
+
+func TestUpdateFromBase(t *testing.T) {
+	for _, rebase := range []bool{false, true} {
+		repo := divergedRepo(t, false)
+		report, err := repo.UpdateFromBase("develop", rebase)
+		if err != nil || report != nil {
+			t.Fatalf("rebase=%v: UpdateFromBase = %v, %v", rebase, report, err)
+		}
+		if n, _ := repo.CountCommits("HEAD", "develop"); n != 0 {
+			t.Errorf("rebase=%v: branch is still %d commit(s) behind develop", rebase, n)
+		}
+	}
+}
+
+func TestUpdateFromBaseConflict(t *testing.T) {
+	for _, rebase := range []bool{false, true} {
+		repo := divergedRepo(t, true)
+		head, _ := repo.RevParse("HEAD")
+
+		report, err := repo.UpdateFromBase("develop", rebase)
+		if err != nil || report == nil {
+			t.Fatalf("rebase=%v: UpdateFromBase = %v, %v; want a conflict", rebase, report, err)
+		}
+		if len(report.Files) != 1 || report.Files[0].Path != "README.md" || len(report.Files[0].Hunks) != 1 {
+			t.Fatalf("rebase=%v: report = %+v", rebase, report)
+		}
+		h := report.Files[0].Hunks[0]
+		if h.Line != 1 || !strings.Contains(h.Ours+h.Theirs, "# Feature") || !strings.Contains(h.Ours+h.Theirs, "# Develop") {
+			t.Errorf("rebase=%v: hunk = %+v", rebase, h)
+		}
+		if rebase && !strings.Contains(report.Commit, "feature change") {
+			t.Errorf("report.Commit = %q", report.Commit)
+		}
+		if got := report.Summary(); got != "README.md (1: L1)" {
+			t.Errorf("Summary = %q", got)
+		}
+
+		// The operation is aborted and the branch left as it was.
+		if now, _ := repo.RevParse("HEAD"); now != head || repo.operationInProgress() {
+			t.Errorf("rebase=%v: branch not restored", rebase)
+		}
+		if clean, _ := repo.IsClean(); !clean {
+			t.Errorf("rebase=%v: worktree left dirty", rebase)
+		}
+	}
+}
+
+func TestConflictHunksLimits(t *testing.T) {
+	var b strings.Builder
+	for range maxConflictHunks + 2 {
+		b.WriteString("<<<<<<< HEAD\nours\n||||||| base\nold\n=======\n")
+		for range maxHunkLines + 5 {
+			b.WriteString("theirs\n")
+		}
+		b.WriteString(">>>>>>> develop\n")
+	}
+	path := filepath.Join(t.TempDir(), "f.txt")
+	os.WriteFile(path, []byte(b.String()), 0644)
+
+	hunks, truncated := conflictHunks(path)
+	if len(hunks) != maxConflictHunks || !truncated {
+		t.Fatalf("got %d hunks, truncated=%v", len(hunks), truncated)
+	}
+	if hunks[0].Ours != "ours" || !strings.HasSuffix(hunks[0].Theirs, "(5 more lines)") {
+		t.Errorf("hunk = %+v", hunks[0])
+	}
+}
@@ -125,6 +125,62 @@ func (r *Repo) AddWorktree(path, newBranch, baseBranch string) error {
 	return nil
 }
 
+// AddWorktreeForBranch creates a git worktree at path for an existing branch.
+func (r *Repo) AddWorktreeForBranch(path, branch string) error {
+	if _, err := r.run("worktree", "add", path, branch); err != nil {
+		return fmt.Errorf("add worktree at %s: %w", path, err)
+	}
+	return nil
+}
+
+// AddDetachedWorktree creates a git worktree at path with a detached HEAD
+// at rev.
+func (r *Repo) AddDetachedWorktree(path, rev string) error {
+	if _, err := r.run("worktree", "add", "--detach", path, rev); err != nil {
+		return fmt.Errorf("add worktree at %s: %w", path, err)
+	}
+	return nil
+}
+
+// MoveWorktree moves a git worktree to dst, creating dst's parent
+// directories.
+func (r *Repo) MoveWorktree(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("move worktree %s: %w", src, err)
+	}
+	if _, err := r.run("worktree", "move", src, dst); err != nil {
+		return fmt.Errorf("move worktree %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
+
+// BranchWorktree returns the path of the worktree that has branch checked
+// out, or "" when no worktree has.
+func (r *Repo) BranchWorktree(branch string) (string, error) {
+	out, err := r.run("worktree", "list", "--porcelain")
+	if err != nil {
+		return "", fmt.Errorf("list worktrees: %w", err)
+	}
+	var path string
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+		} else if line == "branch refs/heads/"+branch {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// CheckoutNewBranch creates branch name at start and checks it out. The new
+// branch does not track start, even when start is a remote branch.
+func (r *Repo) CheckoutNewBranch(name, start string) error {
+	if _, err := r.run("checkout", "--no-track", "-b", name, start); err != nil {
+		return fmt.Errorf("create branch %s from %s: %w", name, start, err)
+	}
+	return nil
+}
+
 // RemoveWorktree removes a git worktree.
 func (r *Repo) RemoveWorktree(path string) error {
 	if _, err := r.run("worktree", "remove", path, "--force"); err != nil {
@@ -133,6 +189,23 @@ func (r *Repo) RemoveWorktree(path string) error {
 	return nil
 }
 
+// ResetHard resets the current HEAD, index and working tree to rev.
+func (r *Repo) ResetHard(rev string) error {
+	if _, err := r.run("reset", "--hard", rev); err != nil {
+		return fmt.Errorf("reset to %s: %w", rev, err)
+	}
+	return nil
+}
+
+// PruneWorktrees removes git's records of worktrees whose directories are
+// gone.
+func (r *Repo) PruneWorktrees() error {
+	if _, err := r.run("worktree", "prune"); err != nil {
+		return fmt.Errorf("prune worktrees: %w", err)
+	}
+	return nil
+}
+
 // CleanWorktrees removes all worktrees under the .worktrees/ directory
 // that match the given prefix (e.g. "team-"). This is used at startup to
 // clean up stale worktrees from previous runs.
@@ -327,6 +327,40 @@ func TestAddAndRemoveWorktree(t *testing.T) {
 	}
 }
 
+func TestDetachedWorktreeMoveAndBranch(t *testing.T) {
+	repo := initTestRepo(t)
+	base, err := repo.CurrentBranch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(repo.Path(), ".worktrees", ".pool", "wt-1")
+	if err := repo.AddDetachedWorktree(src, base); err != nil {
+		t.Fatalf("AddDetachedWorktree failed: %v", err)
+	}
+	if err := NewRepo(src).CheckoutNewBranch("feature-pool", base); err != nil {
+		t.Fatalf("CheckoutNewBranch failed: %v", err)
+	}
+
+	if got, err := repo.BranchWorktree("feature-pool"); err != nil || !sameFile(got, src) {
+		t.Errorf("BranchWorktree = %q, %v; want %s", got, err, src)
+	}
+	if got, _ := repo.BranchWorktree("no-such-branch"); got != "" {
+		t.Errorf("BranchWorktree of a branch without worktree = %q", got)
+	}
+
+	dst := filepath.Join(repo.Path(), ".worktrees", "alice", "issue-1")
+	if err := repo.MoveWorktree(src, dst); err != nil {
+		t.Fatalf("MoveWorktree failed: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("expected the source directory to be gone")
+	}
+	if got, _ := NewRepo(dst).CurrentBranch(); got != "feature-pool" {
+		t.Errorf("moved worktree is on %q, want feature-pool", got)
+	}
+}
+
 func TestPrepareWorktreeCreatesDevelopFromMain(t *testing.T) {
 	repo := initTestRepo(t)
 
@@ -688,3 +722,10 @@ func TestPrepareWorktreeRejectsTraversalBranchName(t *testing.T) {
 		t.Error("worktree directory must not exist after rejected traversal attempt")
 	}
 }
+
+// sameFile reports whether paths a and b name the same existing file.
+func sameFile(a, b string) bool {
+	fa, errA := os.Stat(a)
+	fb, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(fa, fb)
+}
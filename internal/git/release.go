@@ -0,0 +1,147 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Commit is a commit as returned by Log.
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// IsClean reports whether the working tree has no staged, unstaged or
+// untracked changes. Files under .worktrees/ are ignored.
+func (r *Repo) IsClean() (bool, error) {
+	out, err := r.run("status", "--porcelain", "--", ".", ":(exclude).worktrees")
+	if err != nil {
+		return false, fmt.Errorf("git status: %w", err)
+	}
+	return strings.TrimSpace(out) == "", nil
+}
+
+// RevParse resolves rev to a full commit hash.
+func (r *Repo) RevParse(rev string) (string, error) {
+	out, err := r.run("rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", rev, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CountCommits returns the number of commits reachable from to but not from
+// from (git rev-list --count from..to).
+func (r *Repo) CountCommits(from, to string) (int, error) {
+	out, err := r.run("rev-list", "--count", from+".."+to)
+	if err != nil {
+		return 0, fmt.Errorf("count commits %s..%s: %w", from, to, err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return 0, fmt.Errorf("count commits %s..%s: %w", from, to, err)
+	}
+	return n, nil
+}
+
+// Log returns the commits in rng (e.g. "v1.0.0..develop"), newest first.
+// Merge commits are included.
+func (r *Repo) Log(rng string) ([]Commit, error) {
+	out, err := r.run("log", "--format=%H%x1f%s%x1f%b%x1e", rng)
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", rng, err)
+	}
+	var commits []Commit
+	for rec := range strings.SplitSeq(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(rec, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits, nil
+}
+
+// Tags returns the tags matching pattern (e.g. "v*") reachable from ref,
+// highest version first.
+func (r *Repo) Tags(pattern, ref string) ([]string, error) {
+	out, err := r.run("tag", "--list", pattern, "--merged", ref, "--sort=-v:refname")
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	return strings.Fields(out), nil
+}
+
+// CreateAnnotatedTag creates the annotated tag name on ref.
+func (r *Repo) CreateAnnotatedTag(name, message, ref string) error {
+	if _, err := r.run("tag", "-a", name, "-m", message, ref); err != nil {
+		return fmt.Errorf("create tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteTag deletes a local tag.
+func (r *Repo) DeleteTag(name string) error {
+	if _, err := r.run("tag", "-d", name); err != nil {
+		return fmt.Errorf("delete tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// ResetBranch points branch at rev. The working tree is reset as well when
+// branch is checked out.
+func (r *Repo) ResetBranch(branch, rev string) error {
+	current, err := r.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	if current == branch {
+		_, err = r.run("reset", "--hard", rev)
+	} else {
+		_, err = r.run("branch", "-f", branch, rev)
+	}
+	if err != nil {
+		return fmt.Errorf("reset %s to %s: %w", branch, rev, err)
+	}
+	return nil
+}
+
+// CommitFiles stages paths and commits them with message.
+func (r *Repo) CommitFiles(message string, paths ...string) error {
+	if _, err := r.run(append([]string{"add", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+	if _, err := r.run("commit", "-m", message); err != nil {
+		return fmt.Errorf("git commit: %w", err)
+	}
+	return nil
+}
+
+// HasRemote reports whether the named remote is configured.
+func (r *Repo) HasRemote(name string) bool {
+	_, err := r.run("remote", "get-url", name)
+	return err == nil
+}
+
+// Fetch fetches branches and tags from remote.
+func (r *Repo) Fetch(remote string) error {
+	if _, err := r.run("fetch", "--tags", remote); err != nil {
+		return fmt.Errorf("fetch %s: %w", remote, err)
+	}
+	return nil
+}
+
+// Push runs git push to remote with the given refspecs and options, e.g.
+// Push("origin", "--atomic", "main", "refs/tags/v1.0.0").
+func (r *Repo) Push(remote string, args ...string) error {
+	if _, err := r.run(append([]string{"push", remote}, args...)...); err != nil {
+		return fmt.Errorf("push to %s: %w", remote, err)
+	}
+	return nil
+}
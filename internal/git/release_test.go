@@ -0,0 +1,102 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsClean(t *testing.T) {
+	repo := initTestRepo(t)
+
+	if clean, err := repo.IsClean(); err != nil || !clean {
+		t.Fatalf("IsClean() = %v, %v; want true", clean, err)
+	}
+
+	os.MkdirAll(filepath.Join(repo.Path(), ".worktrees", "team-1"), 0755)
+	os.WriteFile(filepath.Join(repo.Path(), ".worktrees", "team-1", "x"), []byte("x"), 0644)
+	if clean, _ := repo.IsClean(); !clean {
+		t.Error(".worktrees should be ignored")
+	}
+
+	os.WriteFile(filepath.Join(repo.Path(), "new.txt"), []byte("x"), 0644)
+	if clean, _ := repo.IsClean(); clean {
+		t.Error("untracked file should make the tree dirty")
+	}
+}
+
+func TestLogAndCountCommits(t *testing.T) {
+	repo := initTestRepo(t)
+	base, err := repo.RevParse("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(repo.Path(), "a.txt"), []byte("a"), 0644)
+	if err := repo.CommitFiles("feat: add a\n\nBREAKING CHANGE: a replaces b", "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(repo.Path(), "b.txt"), []byte("b"), 0644)
+	if err := repo.CommitFiles("fix: add b", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := repo.CountCommits(base, "HEAD"); err != nil || n != 2 {
+		t.Errorf("CountCommits() = %d, %v; want 2", n, err)
+	}
+	commits, err := repo.Log(base + "..HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2: %+v", len(commits), commits)
+	}
+	if commits[0].Subject != "fix: add b" || commits[0].Body != "" {
+		t.Errorf("commits[0] = %+v", commits[0])
+	}
+	if commits[1].Subject != "feat: add a" || commits[1].Body != "BREAKING CHANGE: a replaces b" {
+		t.Errorf("commits[1] = %+v", commits[1])
+	}
+}
+
+func TestTagsAndResetBranch(t *testing.T) {
+	repo := initTestRepo(t)
+	branch, _ := repo.CurrentBranch()
+	first, _ := repo.RevParse("HEAD")
+
+	for _, tag := range []string{"v1.9.0", "v1.10.0", "other"} {
+		if err := repo.CreateAnnotatedTag(tag, "release "+tag, "HEAD"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tags, err := repo.Tags("v*", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"v1.10.0", "v1.9.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags() = %v, want %v", tags, want)
+	}
+	if err := repo.DeleteTag("v1.10.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(repo.Path(), "c.txt"), []byte("c"), 0644)
+	if err := repo.CommitFiles("c", "c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.EnsureBranch("other-branch", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []string{branch, "other-branch"} {
+		if err := repo.ResetBranch(b, first); err != nil {
+			t.Fatalf("ResetBranch(%s): %v", b, err)
+		}
+		if got, _ := repo.RevParse(b); got != first {
+			t.Errorf("%s = %s, want %s", b, got, first)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repo.Path(), "c.txt")); !os.IsNotExist(err) {
+		t.Error("resetting the current branch should reset the working tree")
+	}
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// EnableWorktreeConfig turns on per-worktree configuration
+// (extensions.worktreeConfig) for the repository, so that
+// SetWorktreeConfig in one worktree does not affect the others.
+func (r *Repo) EnableWorktreeConfig() error {
+	if _, err := r.run("config", "extensions.worktreeConfig", "true"); err != nil {
+		return fmt.Errorf("enable worktree config: %w", err)
+	}
+	return nil
+}
+
+// SetWorktreeConfig sets key to value in the configuration of this worktree
+// only. EnableWorktreeConfig must have been called for the repository.
+func (r *Repo) SetWorktreeConfig(key, value string) error {
+	if _, err := r.run("config", "--worktree", key, value); err != nil {
+		return fmt.Errorf("set %s: %w", key, err)
+	}
+	return nil
+}
+
+// GitDir returns the absolute path of the worktree's own git directory:
+// .git for the main worktree, .git/worktrees/<name> for a linked one.
+func (r *Repo) GitDir() (string, error) {
+	out, err := r.run("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("git dir of %s: %w", r.path, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SharedHooksDir returns the hooks directory of the repository as a whole:
+// core.hooksPath from the repository config, which may be relative to the
+// worktree, or the hooks directory of the common git directory.
+// Per-worktree settings are ignored.
+func (r *Repo) SharedHooksDir() (string, error) {
+	if out, err := r.run("config", "--local", "--get", "core.hooksPath"); err == nil && strings.TrimSpace(out) != "" {
+		return strings.TrimSpace(out), nil
+	}
+	out, err := r.run("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("git common dir of %s: %w", r.path, err)
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.path, dir)
+	}
+	return filepath.Join(dir, "hooks"), nil
+}
@@ -0,0 +1,43 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorktreeConfig(t *testing.T) {
+	repo := initTestRepo(t)
+	wtPath := filepath.Join(repo.Path(), ".worktrees", "team-1")
+	if err := repo.AddWorktree(wtPath, "feature", "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	wt := NewRepo(wtPath)
+
+	if err := wt.EnableWorktreeConfig(); err != nil {
+		t.Fatalf("EnableWorktreeConfig: %v", err)
+	}
+	if err := wt.SetWorktreeConfig("user.name", "madflow-engineer-1"); err != nil {
+		t.Fatalf("SetWorktreeConfig: %v", err)
+	}
+	if got := strings.TrimSpace(run(t, wtPath, "git", "config", "user.name")); got != "madflow-engineer-1" {
+		t.Errorf("worktree user.name = %q", got)
+	}
+	if got := strings.TrimSpace(run(t, repo.Path(), "git", "config", "user.name")); got != "Test User" {
+		t.Errorf("main worktree user.name = %q, want it unchanged", got)
+	}
+
+	gitDir, err := wt.GitDir()
+	if err != nil || !strings.Contains(filepath.ToSlash(gitDir), ".git/worktrees/") {
+		t.Errorf("GitDir = %q, %v, want the linked worktree's git dir", gitDir, err)
+	}
+	hooks, err := wt.SharedHooksDir()
+	if err != nil || hooks != filepath.Join(repo.Path(), ".git", "hooks") {
+		t.Errorf("SharedHooksDir = %q, %v", hooks, err)
+	}
+	wt.SetWorktreeConfig("core.hooksPath", "/elsewhere")
+	run(t, repo.Path(), "git", "config", "core.hooksPath", ".husky")
+	if hooks, _ := wt.SharedHooksDir(); hooks != ".husky" {
+		t.Errorf("SharedHooksDir = %q, want the repository's core.hooksPath", hooks)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/screen"
 )
 
 const maxSeenEvents = 1000
@@ -97,6 +98,7 @@ type EventWatcher struct {
 	idleInterval    time.Duration    // effective only when idleDetector is set
 	authorizedUsers []string         // empty = all users trusted
 	botPatterns     []*regexp.Regexp // compiled bot comment patterns; nil = no pattern check
+	screening       screening        // prompt-injection screening; zero value = disabled
 
 	mu         sync.Mutex
 	seenEvents map[string]struct{}
@@ -131,6 +133,14 @@ func (w *EventWatcher) WithBotCommentPatterns(patterns []*regexp.Regexp) *EventW
 	return w
 }
 
+// WithScreener enables prompt-injection screening of received issues and
+// comments. Flagged issues are quarantined as pending approval and
+// onQuarantine (may be nil) is called after the issue is saved.
+func (w *EventWatcher) WithScreener(sc *screen.Screener, onQuarantine QuarantineFunc) *EventWatcher {
+	w.screening = screening{screener: sc, onQuarantine: onQuarantine}
+	return w
+}
+
 // WithIdleDetector attaches an IdleDetector to the EventWatcher, enabling adaptive polling.
 // When the detector reports no active issues, the watcher uses idleInterval instead
 // of the normal interval. Returns the EventWatcher for method chaining.
@@ -428,11 +438,18 @@ func (w *EventWatcher) handleIssuesEvent(repo string, ev ghEvent) {
 			Labels:          extractLabels(payload.Issue.Labels),
 			Body:            payload.Issue.Body,
 		}
+		var reason string
+		if f := w.screening.screenIssue(payload.Issue.Title, payload.Issue.Body, !pendingApproval); f != nil {
+			reason = quarantineBody(newIssue, "issue body", f)
+		}
 		if err := w.store.Update(newIssue); err != nil {
 			log.Printf("[event-watcher] create %s failed: %v", localID, err)
 			return
 		}
 		log.Printf("[event-watcher] imported %s: %s", localID, payload.Issue.Title)
+		if reason != "" {
+			w.screening.notify(localID, reason)
+		}
 	} else {
 		// Update existing issue if still open
 		if existing.Status != issue.StatusOpen {
@@ -448,11 +465,18 @@ func (w *EventWatcher) handleIssuesEvent(repo string, ev ghEvent) {
 			updated = true
 		}
 		if updated {
+			var reason string
+			if f := w.screening.screenIssue(existing.Title, existing.Body, !pendingApproval); f != nil {
+				reason = quarantineBody(existing, "edited issue body", f)
+			}
 			if err := w.store.Update(existing); err != nil {
 				log.Printf("[event-watcher] update %s failed: %v", localID, err)
 				return
 			}
 			log.Printf("[event-watcher] updated %s", localID)
+			if reason != "" {
+				w.screening.notify(localID, reason)
+			}
 		}
 	}
 
@@ -506,11 +530,18 @@ func (w *EventWatcher) handleIssueCommentEvent(repo string, ev ghEvent) {
 		IsBot:     isBot(payload.Comment.User.Login, payload.Comment.User.Type, payload.Comment.Body, w.botPatterns),
 	}
 
+	// Authorized users are trusted, but text they quote from others is not.
+	var reason string
+	if f := w.screening.screenComment(comment.Body, true); f != nil {
+		comment.Quarantined = true
+		reason = quarantine(existing, fmt.Sprintf("comment #%d by @%s", comment.ID, commentLogin), f)
+	}
+
 	changed := existing.AddComment(comment)
 
 	// If an authorized user posts /approve on a pending-approval issue, clear the flag.
-	if existing.PendingApproval && strings.Contains(strings.ToLower(payload.Comment.Body), "/approve") {
-		existing.PendingApproval = false
+	if existing.PendingApproval && reason == "" && isApproval(existing, comment, w.authorizedUsers) {
+		existing.Approve()
 		changed = true
 		log.Printf("[event-watcher] issue %s approved by %s via comment #%d", localID, commentLogin, payload.Comment.ID)
 	}
@@ -522,6 +553,11 @@ func (w *EventWatcher) handleIssueCommentEvent(repo string, ev ghEvent) {
 		}
 		log.Printf("[event-watcher] comment #%d processed for %s", comment.ID, localID)
 
+		if reason != "" {
+			// The flagged comment must not reach agents.
+			w.screening.notify(localID, reason)
+			return
+		}
 		if w.callback != nil {
 			w.callback(EventTypeIssueComment, localID, &comment)
 		}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/screen"
 )
 
 // ghComment represents a comment from `gh api` for issue comments.
@@ -129,6 +130,7 @@ type Syncer struct {
 	skipComments       bool             // if true, skip comment sync (for fast startup)
 	rateLimitThreshold int              // minimum remaining API calls before waiting/skipping; 0 disables
 	ghLogin            string           // authenticated GitHub login for assignee-based filtering; empty = disabled
+	screening          screening        // prompt-injection screening; zero value = disabled
 	// addAssigneeFn is used to inject a test double for gh CLI calls.
 	// When nil, the real gh CLI is invoked.
 	addAssigneeFn func(repo string, number int, login string) error
@@ -162,6 +164,14 @@ func (s *Syncer) WithBotCommentPatterns(patterns []*regexp.Regexp) *Syncer {
 	return s
 }
 
+// WithScreener enables prompt-injection screening of imported issues and
+// comments. Flagged issues are quarantined as pending approval and
+// onQuarantine (may be nil) is called after the issue is saved.
+func (s *Syncer) WithScreener(sc *screen.Screener, onQuarantine QuarantineFunc) *Syncer {
+	s.screening = screening{screener: sc, onQuarantine: onQuarantine}
+	return s
+}
+
 // WithSkipComments configures the Syncer to skip comment synchronization.
 // When true, syncComments is not called for any issue during SyncOnce.
 // This dramatically reduces startup time when there are many issues, since
@@ -384,10 +394,17 @@ func (s *Syncer) syncIssues(repo string, issues []ghIssue, _ map[string]struct{}
 				Labels:          extractLabels(gh.Labels),
 				Body:            gh.Body,
 			}
+			var reason string
+			if f := s.screening.screenIssue(gh.Title, gh.Body, !pendingApproval); f != nil {
+				reason = quarantineBody(newIssue, "issue body", f)
+			}
 			if err := s.store.Update(newIssue); err != nil {
 				log.Printf("[github-sync] create %s failed: %v", localID, err)
 			} else {
 				log.Printf("[github-sync] imported %s: %s", localID, gh.Title)
+				if reason != "" {
+					s.screening.notify(localID, reason)
+				}
 			}
 			// Sync comments for new issue (may contain /approve), unless skipped.
 			if !s.skipComments {
@@ -411,11 +428,18 @@ func (s *Syncer) syncIssues(repo string, issues []ghIssue, _ map[string]struct{}
 			updated = true
 		}
 
+		var reason string
 		if updated {
+			if f := s.screening.screenIssue(existing.Title, existing.Body, !pendingApproval); f != nil {
+				reason = quarantineBody(existing, "edited issue body", f)
+			}
 			if err := s.store.Update(existing); err != nil {
 				log.Printf("[github-sync] update %s failed: %v", localID, err)
 			} else {
 				log.Printf("[github-sync] updated %s", localID)
+				if reason != "" {
+					s.screening.notify(localID, reason)
+				}
 			}
 		}
 
@@ -518,7 +542,11 @@ func (s *Syncer) syncComments(repo string, issueNumber int, localID string) {
 	}
 
 	added := 0
+	var reasons []string
 	for _, c := range comments {
+		if iss.HasComment(c.ID) {
+			continue
+		}
 		createdAt, _ := time.Parse(time.RFC3339, c.CreatedAt)
 		updatedAt, _ := time.Parse(time.RFC3339, c.UpdatedAt)
 		comment := issue.Comment{
@@ -529,6 +557,10 @@ func (s *Syncer) syncComments(repo string, issueNumber int, localID string) {
 			UpdatedAt: updatedAt,
 			IsBot:     isBot(c.User.Login, c.User.Type, c.Body, s.botPatterns),
 		}
+		if f := s.screening.screenComment(c.Body, isAuthorized(c.User.Login, s.authorizedUsers)); f != nil {
+			comment.Quarantined = true
+			reasons = append(reasons, quarantine(iss, fmt.Sprintf("comment #%d by @%s", c.ID, c.User.Login), f))
+		}
 		if iss.AddComment(comment) {
 			added++
 		}
@@ -536,10 +568,10 @@ func (s *Syncer) syncComments(repo string, issueNumber int, localID string) {
 
 	// Check for /approve from an authorized user to clear PendingApproval.
 	approvalChanged := false
-	if iss.PendingApproval {
+	if iss.PendingApproval && len(reasons) == 0 {
 		for _, c := range iss.Comments {
-			if isAuthorized(c.Author, s.authorizedUsers) && strings.Contains(strings.ToLower(c.Body), "/approve") {
-				iss.PendingApproval = false
+			if isApproval(iss, c, s.authorizedUsers) {
+				iss.Approve()
 				approvalChanged = true
 				log.Printf("[github-sync] issue %s approved by %s", localID, c.Author)
 				break
@@ -550,9 +582,13 @@ func (s *Syncer) syncComments(repo string, issueNumber int, localID string) {
 	if added > 0 || approvalChanged {
 		if err := s.store.Update(iss); err != nil {
 			log.Printf("[github-sync] save comments for %s failed: %v", localID, err)
+			return
 		} else if added > 0 {
 			log.Printf("[github-sync] added %d comments to %s", added, localID)
 		}
+		for _, reason := range reasons {
+			s.screening.notify(localID, reason)
+		}
 	}
 }
 
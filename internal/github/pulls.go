@@ -0,0 +1,104 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PullRequest is an open pull request as seen by `gh pr view`.
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+	// Mergeable is MERGEABLE, CONFLICTING or UNKNOWN.
+	Mergeable string `json:"mergeable"`
+	IsDraft   bool   `json:"isDraft"`
+	// HeadRefOid is the commit at the head of the pull request's branch.
+	HeadRefOid        string        `json:"headRefOid"`
+	StatusCheckRollup []statusCheck `json:"statusCheckRollup"`
+}
+
+// statusCheck is an entry of statusCheckRollup: a check run (Status and
+// Conclusion) or a commit status (State).
+type statusCheck struct {
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	State      string `json:"state"`
+}
+
+// ChecksPassed reports whether every check of the pull request has
+// completed successfully. A pull request without checks passes.
+func (pr *PullRequest) ChecksPassed() bool {
+	for _, c := range pr.StatusCheckRollup {
+		if c.State != "" {
+			if c.State != "SUCCESS" {
+				return false
+			}
+			continue
+		}
+		if c.Status != "COMPLETED" {
+			return false
+		}
+		switch c.Conclusion {
+		case "SUCCESS", "NEUTRAL", "SKIPPED":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// PullRequests finds and merges pull requests. Each call is made for the
+// local repository at dir; the GitHub repository is the one its origin
+// remote points to.
+type PullRequests interface {
+	// FindOpen returns the open pull request whose head is branch, or nil.
+	FindOpen(ctx context.Context, dir, branch string) (*PullRequest, error)
+	// Merge squash-merges pull request number. The branch is left for the
+	// merged-worktree cleanup, which also removes its worktree.
+	Merge(ctx context.Context, dir string, number int) error
+}
+
+// GHPullRequests implements PullRequests with the gh command-line tool.
+type GHPullRequests struct{}
+
+// FindOpen implements PullRequests.
+func (GHPullRequests) FindOpen(ctx context.Context, dir, branch string) (*PullRequest, error) {
+	out, err := runGHIn(ctx, dir, "pr", "list", "--head", branch, "--state", "open", "--limit", "1",
+		"--json", "number,url,state,mergeable,isDraft,headRefOid,statusCheckRollup")
+	if err != nil {
+		return nil, err
+	}
+	var prs []PullRequest
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return nil, fmt.Errorf("parse gh pr list: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &prs[0], nil
+}
+
+// Merge implements PullRequests.
+func (GHPullRequests) Merge(ctx context.Context, dir string, number int) error {
+	_, err := runGHIn(ctx, dir, "pr", "merge", strconv.Itoa(number), "--squash")
+	return err
+}
+
+// runGHIn runs gh in dir and returns its standard output.
+func runGHIn(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("gh %s %s: %w (output: %s)", args[0], args[1], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,23 @@
+package github
+
+import "testing"
+
+func TestPullRequestChecksPassed(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []statusCheck
+		want   bool
+	}{
+		{"no checks", nil, true},
+		{"all green", []statusCheck{{Status: "COMPLETED", Conclusion: "SUCCESS"}, {Status: "COMPLETED", Conclusion: "SKIPPED"}, {State: "SUCCESS"}}, true},
+		{"running", []statusCheck{{Status: "IN_PROGRESS"}}, false},
+		{"failed run", []statusCheck{{Status: "COMPLETED", Conclusion: "FAILURE"}}, false},
+		{"pending status", []statusCheck{{Status: "COMPLETED", Conclusion: "SUCCESS"}, {State: "PENDING"}}, false},
+	}
+	for _, tt := range tests {
+		pr := &PullRequest{StatusCheckRollup: tt.checks}
+		if got := pr.ChecksPassed(); got != tt.want {
+			t.Errorf("%s: ChecksPassed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
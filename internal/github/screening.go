@@ -0,0 +1,81 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/screen"
+)
+
+// QuarantineFunc is called after an issue has been quarantined and saved.
+// reason is a human-readable description of what was flagged.
+type QuarantineFunc func(issueID, reason string)
+
+// screening bundles the prompt-injection screener shared by Syncer and
+// EventWatcher. The zero value screens nothing.
+type screening struct {
+	screener     *screen.Screener
+	onQuarantine QuarantineFunc
+}
+
+// screenIssue checks the title and body of an issue. Issues written by
+// authorized users are trusted and not screened.
+func (sc screening) screenIssue(title, body string, authorized bool) *screen.Finding {
+	if authorized {
+		return nil
+	}
+	return sc.screener.Screen(context.Background(), title+"\n\n"+body)
+}
+
+// screenComment checks a comment. Comments by unauthorized users are
+// screened in full; for authorized users only the text they quote from
+// others is screened.
+func (sc screening) screenComment(body string, authorized bool) *screen.Finding {
+	if authorized {
+		body = screen.QuotedText(body)
+	}
+	return sc.screener.Screen(context.Background(), body)
+}
+
+// quarantine records finding on iss. source describes the flagged content
+// (e.g. "issue body", "comment #123 by @bob"). The returned reason should be
+// passed to notify once iss has been saved. The flagged excerpt is only
+// logged: the reason is stored in the issue file and sent to the
+// superintendent, so it must not repeat the flagged text.
+func quarantine(iss *issue.Issue, source string, finding *screen.Finding) string {
+	reason := fmt.Sprintf("%s: %s (%s)", source, finding.Reason, finding.Rule)
+	iss.Quarantine(reason, time.Now())
+	log.Printf("[screen] quarantined %s: %s: %s", iss.ID, source, finding)
+	return reason
+}
+
+// quarantineBody is quarantine for a flagged issue title or body, which is
+// then withheld from the issue file agents read.
+func quarantineBody(iss *issue.Issue, source string, finding *screen.Finding) string {
+	iss.BodyQuarantined = true
+	return quarantine(iss, source, finding)
+}
+
+// notify reports a saved quarantine to the configured callback.
+func (sc screening) notify(issueID, reason string) {
+	if sc.onQuarantine != nil {
+		sc.onQuarantine(issueID, reason)
+	}
+}
+
+// isApproval reports whether comment c releases iss from pending approval:
+// it must come from an authorized user, contain "/approve" and, for a
+// quarantined issue, be posted after the quarantine.
+func isApproval(iss *issue.Issue, c issue.Comment, authorizedUsers []string) bool {
+	if !isAuthorized(c.Author, authorizedUsers) || !strings.Contains(strings.ToLower(c.Body), "/approve") {
+		return false
+	}
+	if !iss.QuarantinedAt.IsZero() && !c.CreatedAt.After(iss.QuarantinedAt) {
+		return false
+	}
+	return true
+}
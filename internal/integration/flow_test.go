@@ -18,7 +18,9 @@ func waitForTeams(t *testing.T, orc *orchestrator.Orchestrator, want int, timeou
 	t.Helper()
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		if orc.Teams().Count() >= want {
+		// Count includes teams still being created; wait until they are
+		// listed.
+		if len(orc.Teams().List()) >= want {
 			return
 		}
 		time.Sleep(10 * time.Millisecond)
@@ -119,7 +121,7 @@ func TestIssueToTeamCreateFlow(t *testing.T) {
 	}
 
 	// Step 4: Verify issue was updated (poll until AssignedTeam is set by async goroutine)
-	// Note: teams.Count() increases when pendingCount is incremented (before Create returns),
+	// Note: teams.Count() increases when the team is marked pending (before Create returns),
 	// so we need a separate poll to wait for the issue assignment to be written to disk.
 	updated := waitForAssignment(t, store, iss.ID, 5*time.Second)
 	if updated.Status != issue.StatusInProgress {
@@ -1,7 +1,10 @@
 package issue
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -10,6 +13,8 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/ytnobody/madflow/internal/filelock"
 )
 
 // Comment represents a GitHub issue comment.
@@ -24,6 +29,9 @@ type Comment struct {
 	// have IsBot=false, which allows the orchestrator and other consumers to
 	// distinguish human-initiated discussions from automated agent status posts.
 	IsBot bool `toml:"is_bot,omitempty"`
+	// Quarantined is true when prompt-injection screening flagged this comment.
+	// Consumers must not forward the body to agents.
+	Quarantined bool `toml:"quarantined,omitempty"`
 }
 
 // IsBotLogin reports whether the given GitHub login belongs to a bot account.
@@ -51,12 +59,93 @@ type Issue struct {
 	// PendingApproval is set to true when an issue is created by a user not in
 	// authorized_users. The issue will not be assigned to a team until an
 	// authorized user posts a comment containing "/approve".
-	PendingApproval bool      `toml:"pending_approval,omitempty"`
+	PendingApproval bool `toml:"pending_approval,omitempty"`
+	// QuarantineReason explains why prompt-injection screening put the issue
+	// back into pending approval. Empty when the issue is not quarantined.
+	QuarantineReason string `toml:"quarantine_reason,omitempty"`
+	// QuarantinedAt is when the issue was quarantined. Only "/approve"
+	// comments posted after this time release the quarantine.
+	QuarantinedAt time.Time `toml:"quarantined_at,omitempty"`
+	// BodyQuarantined is true when screening flagged the title or body
+	// (rather than a comment).
+	BodyQuarantined bool      `toml:"body_quarantined,omitempty"`
 	Repos           []string  `toml:"repos,omitempty"`
 	Labels          []string  `toml:"labels,omitempty"`
 	Body            string    `toml:"body"`
 	Acceptance      string    `toml:"acceptance,omitempty"`
 	Comments        []Comment `toml:"comments,omitempty"`
+	// VerifiedCommits records, per repository name, the feature branch
+	// commit that last passed VERIFY. It is cleared when a VERIFY fails.
+	VerifiedCommits map[string]string `toml:"verified_commits,omitempty"`
+	// LessonScored is set once a finished local issue has been scored for
+	// lessons, so that it is not scored again.
+	LessonScored bool `toml:"lesson_scored,omitempty"`
+}
+
+// Quarantine marks the issue as pending approval because screening flagged
+// its content. The first reason is kept when the issue is already quarantined.
+func (iss *Issue) Quarantine(reason string, at time.Time) {
+	iss.PendingApproval = true
+	if iss.QuarantineReason == "" {
+		iss.QuarantineReason = reason
+	}
+	iss.QuarantinedAt = at
+}
+
+// Approve clears PendingApproval and any quarantine.
+func (iss *Issue) Approve() {
+	iss.PendingApproval = false
+	iss.QuarantineReason = ""
+	iss.QuarantinedAt = time.Time{}
+	iss.BodyQuarantined = false
+}
+
+// QuarantinePlaceholder replaces flagged text in the issue file that agents
+// read while the issue is quarantined.
+const QuarantinePlaceholder = "[withheld: flagged by prompt-injection screening, pending /approve]"
+
+// withheld returns a copy of a quarantined issue with the flagged title, body
+// and comment bodies replaced by QuarantinePlaceholder.
+func (iss *Issue) withheld() *Issue {
+	w := *iss
+	if w.BodyQuarantined {
+		w.Title = QuarantinePlaceholder
+		w.Body = QuarantinePlaceholder
+	}
+	w.Comments = make([]Comment, len(iss.Comments))
+	for i, c := range iss.Comments {
+		if c.Quarantined {
+			c.Body = QuarantinePlaceholder
+		}
+		w.Comments[i] = c
+	}
+	return &w
+}
+
+// restore puts the text withheld from a quarantined issue back from orig,
+// the issue as it was before the text was withheld. Everything else, such
+// as the status or assignment, is kept.
+func (iss *Issue) restore(orig *Issue) {
+	if iss.BodyQuarantined {
+		iss.Title = orig.Title
+		iss.Body = orig.Body
+	}
+	for i, c := range iss.Comments {
+		if !c.Quarantined {
+			continue
+		}
+		for _, oc := range orig.Comments {
+			if oc.ID == c.ID {
+				iss.Comments[i].Body = oc.Body
+				break
+			}
+		}
+	}
+}
+
+// IsQuarantined reports whether screening has quarantined the issue.
+func (iss *Issue) IsQuarantined() bool {
+	return iss.QuarantineReason != ""
 }
 
 // HasComment checks whether a comment with the given ID already exists.
@@ -83,12 +172,17 @@ type StatusFilter struct {
 	Status *Status // nil means all
 }
 
+// Store keeps one TOML file per issue in dir. Agents read these files, so
+// while an issue is quarantined its file holds placeholders for the flagged
+// text and the original is kept in a "quarantine" directory next to dir
+// until /approve.
 type Store struct {
-	dir string
+	dir           string
+	quarantineDir string
 }
 
 func NewStore(dir string) *Store {
-	return &Store{dir: dir}
+	return &Store{dir: dir, quarantineDir: filepath.Join(filepath.Dir(dir), "quarantine")}
 }
 
 func (s *Store) Dir() string {
@@ -96,11 +190,19 @@ func (s *Store) Dir() string {
 }
 
 // Create creates a new local issue with auto-incremented ID.
+// The store lock is held while the next ID is chosen so that concurrent
+// callers cannot allocate the same ID.
 func (s *Store) Create(title, body string) (*Issue, error) {
 	if err := os.MkdirAll(s.dir, 0700); err != nil {
 		return nil, fmt.Errorf("create issues dir: %w", err)
 	}
 
+	lock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
 	nextNum, err := s.nextLocalNum()
 	if err != nil {
 		return nil, err
@@ -114,20 +216,38 @@ func (s *Store) Create(title, body string) (*Issue, error) {
 		Body:         body,
 	}
 
-	if err := s.write(issue); err != nil {
+	if err := s.writeLocked(issue); err != nil {
 		return nil, err
 	}
 	return issue, nil
 }
 
-// Get retrieves an issue by ID.
+// Get retrieves an issue by ID. The issue file is authoritative, including
+// edits agents make to it; for a quarantined issue only the withheld text is
+// taken from the stored original.
 func (s *Store) Get(id string) (*Issue, error) {
-	path := s.path(id)
+	issue, err := decodeFile(s.path(id), id)
+	if err != nil {
+		return nil, err
+	}
+	if issue.IsQuarantined() {
+		orig, err := decodeFile(s.quarantinePath(id), id)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if orig != nil {
+			issue.restore(orig)
+		}
+	}
+	return issue, nil
+}
+
+// decodeFile reads the issue at path.
+func decodeFile(path, id string) (*Issue, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read issue %s: %w", id, err)
 	}
-
 	var issue Issue
 	if err := toml.Unmarshal(data, &issue); err != nil {
 		return nil, fmt.Errorf("parse issue %s: %w", id, err)
@@ -153,6 +273,7 @@ func (s *Store) List(filter StatusFilter) ([]*Issue, error) {
 		id := strings.TrimSuffix(entry.Name(), ".toml")
 		issue, err := s.Get(id)
 		if err != nil {
+			log.Printf("[issue] skipping unreadable issue file %s: %v", entry.Name(), err)
 			continue
 		}
 		if filter.Status != nil && issue.Status != *filter.Status {
@@ -197,32 +318,77 @@ func (s *Store) Update(issue *Issue) error {
 // Delete removes an issue file by ID. It is idempotent: deleting a
 // non-existent issue returns nil.
 func (s *Store) Delete(id string) error {
-	err := os.Remove(s.path(id))
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("delete issue %s: %w", id, err)
+	for _, path := range []string{s.path(id), s.quarantinePath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete issue %s: %w", id, err)
+		}
 	}
 	return nil
 }
 
+// write encodes issue and replaces its file atomically under the store lock,
+// so readers never observe a partially written file.
 func (s *Store) write(issue *Issue) error {
-	path := s.path(issue.ID)
-	f, err := os.Create(path)
+	lock, err := s.lock()
 	if err != nil {
-		return fmt.Errorf("create issue file: %w", err)
+		return err
 	}
-	defer f.Close()
+	defer lock.Release()
+	return s.writeLocked(issue)
+}
 
-	encoder := toml.NewEncoder(f)
-	if err := encoder.Encode(issue); err != nil {
+// writeLocked is write without taking the store lock. A quarantined issue is
+// written in full to the quarantine directory, which only the MADFLOW user
+// can read, and with its flagged text withheld to the issue file; once
+// released, the quarantined copy is removed.
+func (s *Store) writeLocked(issue *Issue) error {
+	if !issue.IsQuarantined() {
+		if err := encodeFile(s.path(issue.ID), issue); err != nil {
+			return err
+		}
+		if err := os.Remove(s.quarantinePath(issue.ID)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("release quarantined issue %s: %w", issue.ID, err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(s.quarantineDir, 0700); err != nil {
+		return fmt.Errorf("write issue %s: %w", issue.ID, err)
+	}
+	if err := encodeFile(s.quarantinePath(issue.ID), issue); err != nil {
+		return err
+	}
+	return encodeFile(s.path(issue.ID), issue.withheld())
+}
+
+// encodeFile writes issue as TOML to path atomically.
+func encodeFile(path string, issue *Issue) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(issue); err != nil {
 		return fmt.Errorf("write issue: %w", err)
 	}
+	if err := filelock.WriteFileAtomic(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write issue %s: %w", issue.ID, err)
+	}
 	return nil
 }
 
+// lock takes the store-wide advisory lock ("<dir>/.store.lock").
+func (s *Store) lock() (*filelock.Lock, error) {
+	lock, err := filelock.Acquire(filepath.Join(s.dir, ".store"))
+	if err != nil {
+		return nil, fmt.Errorf("lock issue store: %w", err)
+	}
+	return lock, nil
+}
+
 func (s *Store) path(id string) string {
 	return filepath.Join(s.dir, id+".toml")
 }
 
+func (s *Store) quarantinePath(id string) string {
+	return filepath.Join(s.quarantineDir, id+".toml")
+}
+
 func (s *Store) nextLocalNum() (int, error) {
 	entries, err := os.ReadDir(s.dir)
 	if err != nil {
@@ -0,0 +1,273 @@
+package lessons
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/filelock"
+)
+
+// statsFile records, per issue, the lessons that were active when the issue
+// was assigned and the scores it got.
+const statsFile = "lessons-stats.json"
+
+// retiredFile collects the retired lessons in the lessons file format, so
+// that they can be imported back.
+const retiredFile = "lessons-retired.txt"
+
+// issueStats is the record of an issue in the stats file.
+type issueStats struct {
+	// Assigned is the day the issue was first assigned to a team.
+	Assigned string `json:"assigned"`
+	// Repos are the repositories the issue involves.
+	Repos []string `json:"repos,omitempty"`
+	// Lessons are the keys of the lessons active at assignment (see
+	// lessonKey).
+	Lessons []string `json:"lessons,omitempty"`
+	// Scores are the scores per stream; the lowest is kept when an issue is
+	// scored again (e.g. after a revert).
+	Scores map[agent.Role]int `json:"scores,omitempty"`
+}
+
+// lessonKey identifies a lesson in the stats file. Editing or merging a
+// lesson changes its key, so its statistics start over.
+func lessonKey(l Lesson) string {
+	return string(l.role()) + "|" + l.Repo + "|" + l.Text
+}
+
+// ScoreStats summarizes the scores of a set of issues.
+type ScoreStats struct {
+	Issues int
+	Total  int
+}
+
+// Mean returns the average score, or 0 when there are no issues.
+func (s ScoreStats) Mean() float64 {
+	if s.Issues == 0 {
+		return 0
+	}
+	return float64(s.Total) / float64(s.Issues)
+}
+
+func (s *ScoreStats) add(score int) {
+	s.Issues++
+	s.Total += score
+}
+
+// Effect compares the scores of the issues a lesson applies to (those of
+// its stream and, for a repository lesson, of its repository) with and
+// without the lesson active when they were assigned.
+type Effect struct {
+	Lesson Lesson
+	// Before are the issues assigned while the lesson was not active,
+	// mostly those before it was added.
+	Before ScoreStats
+	// After are the issues assigned while the lesson was active.
+	After ScoreStats
+}
+
+// Change returns the difference between the average scores after and
+// before the lesson. ok is false when either side has no issues.
+func (e Effect) Change() (change float64, ok bool) {
+	if e.Before.Issues == 0 || e.After.Issues == 0 {
+		return 0, false
+	}
+	return e.After.Mean() - e.Before.Mean(), true
+}
+
+// noImprovement reports whether the lesson was active for at least n scored
+// issues without raising their average score.
+func (e Effect) noImprovement(n int) bool {
+	change, ok := e.Change()
+	return ok && e.After.Issues >= n && change <= 0
+}
+
+// statsPath returns the path of the stats file.
+func (m *Manager) statsPath() string {
+	return filepath.Join(m.DataDir, statsFile)
+}
+
+// loadStats reads the stats file; a missing file is empty.
+func loadStats(path string) (map[string]*issueStats, error) {
+	stats := make(map[string]*issueStats)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return stats, nil
+}
+
+// updateStats applies fn to the stats file under its lock, and writes it
+// when fn reports a change.
+func (m *Manager) updateStats(fn func(map[string]*issueStats) bool) error {
+	path := m.statsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("mkdir for %s: %w", path, err)
+	}
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	stats, err := loadStats(path)
+	if err != nil {
+		return err
+	}
+	if !fn(stats) {
+		return nil
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFileAtomic(path, data, 0600)
+}
+
+// RecordAssignment records the lessons active when issueID is assigned to a
+// team working in repos: all superintendent lessons (shown during issue
+// patrol) and the engineer lessons injected for repos. Only the first
+// assignment of an issue is recorded; a restarted team does not change it.
+func (m *Manager) RecordAssignment(issueID string, repos []string) error {
+	lessons, err := LoadLessons(m.LessonsPath())
+	if err != nil {
+		return err
+	}
+	var active []string
+	for _, l := range lessons {
+		if l.role() == agent.RoleSuperintendent || l.Repo == "" || slices.Contains(repos, l.Repo) {
+			active = append(active, lessonKey(l))
+		}
+	}
+	return m.updateStats(func(stats map[string]*issueStats) bool {
+		if _, ok := stats[issueID]; ok {
+			return false
+		}
+		stats[issueID] = &issueStats{Assigned: time.Now().Format(dateLayout), Repos: repos, Lessons: active}
+		return true
+	})
+}
+
+// Assigned reports whether the assignment of issueID has been recorded by
+// RecordAssignment.
+func (m *Manager) Assigned(issueID string) (bool, error) {
+	stats, err := loadStats(m.statsPath())
+	if err != nil {
+		return false, err
+	}
+	_, ok := stats[issueID]
+	return ok, nil
+}
+
+// recordScore records the score of a scored issue. Issues without an
+// assignment record (e.g. assigned before the stats were kept) are not
+// tracked.
+func (m *Manager) recordScore(result *ScoringResult) error {
+	role := result.Role
+	if role == "" {
+		role = agent.RoleSuperintendent
+	}
+	return m.updateStats(func(stats map[string]*issueStats) bool {
+		rec, ok := stats[result.IssueID]
+		if !ok {
+			return false
+		}
+		if prev, ok := rec.Scores[role]; ok && prev <= result.Score {
+			return false
+		}
+		if rec.Scores == nil {
+			rec.Scores = make(map[agent.Role]int)
+		}
+		rec.Scores[role] = result.Score
+		return true
+	})
+}
+
+// Effects returns the effect of each lesson of the lessons file, in file
+// order.
+func (m *Manager) Effects() ([]Effect, error) {
+	lessons, err := LoadLessons(m.LessonsPath())
+	if err != nil {
+		return nil, err
+	}
+	stats, err := loadStats(m.statsPath())
+	if err != nil {
+		return nil, err
+	}
+	return effects(lessons, stats), nil
+}
+
+func effects(lessons []Lesson, stats map[string]*issueStats) []Effect {
+	out := make([]Effect, len(lessons))
+	for i, l := range lessons {
+		out[i].Lesson = l
+		key := lessonKey(l)
+		for _, rec := range stats {
+			score, ok := rec.Scores[l.role()]
+			if !ok || (l.Repo != "" && !slices.Contains(rec.Repos, l.Repo)) {
+				continue
+			}
+			if slices.Contains(rec.Lessons, key) {
+				out[i].After.add(score)
+			} else {
+				out[i].Before.add(score)
+			}
+		}
+	}
+	return out
+}
+
+// retireLessons removes the unpinned lessons that were active for at least
+// RetireAfter scored issues without raising their average score, and
+// appends them to the retired lessons file.
+func (m *Manager) retireLessons() error {
+	if m.RetireAfter <= 0 {
+		return nil
+	}
+	effs, err := m.Effects()
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(effs, func(e Effect) bool { return !e.Lesson.Pinned && e.noImprovement(m.RetireAfter) }) {
+		return nil
+	}
+	stats, err := loadStats(m.statsPath())
+	if err != nil {
+		return err
+	}
+	var retired []Lesson
+	err = m.Update(func(lessons []Lesson) ([]Lesson, error) {
+		var kept []Lesson
+		for _, e := range effects(lessons, stats) {
+			if !e.Lesson.Pinned && e.noImprovement(m.RetireAfter) {
+				change, _ := e.Change()
+				log.Printf("[lessons] retiring lesson without improvement after %d issues (%+.1f): %s", e.After.Issues, change, FormatLesson(e.Lesson))
+				retired = append(retired, e.Lesson)
+				continue
+			}
+			kept = append(kept, e.Lesson)
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, l := range retired {
+		if err := AppendLesson(filepath.Join(m.DataDir, retiredFile), l); err != nil {
+			return fmt.Errorf("record retired lesson: %w", err)
+		}
+	}
+	return nil
+}
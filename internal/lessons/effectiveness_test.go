@@ -0,0 +1,121 @@
+package lessons
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/agent"
+)
+
+func TestEffects(t *testing.T) {
+	m := &Manager{DataDir: t.TempDir()}
+	record := func(issueID string, repos ...string) {
+		t.Helper()
+		if err := m.RecordAssignment(issueID, repos); err != nil {
+			t.Fatalf("RecordAssignment(%s): %v", issueID, err)
+		}
+	}
+	score := func(issueID string, role agent.Role, score int) {
+		t.Helper()
+		if err := m.recordScore(&ScoringResult{IssueID: issueID, Role: role, Score: score}); err != nil {
+			t.Fatalf("recordScore(%s): %v", issueID, err)
+		}
+	}
+
+	// local-001 is assigned before there are lessons.
+	record("local-001", "app")
+	if err := SaveLessons(m.LessonsPath(), []Lesson{
+		{Risk: RiskHigh, Role: agent.RoleSuperintendent, Text: "完了条件を書くこと"},
+		{Risk: RiskMedium, Role: agent.RoleEngineer, Repo: "app", Text: "テストを先に書くこと"},
+		{Risk: RiskMedium, Role: agent.RoleEngineer, Repo: "web", Text: "画面を確認すること"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	record("local-002", "app")
+	record("local-003", "web")
+	record("local-002", "web") // a restarted team does not change the record
+
+	score("local-001", "", 50)
+	score("local-001", agent.RoleEngineer, 60)
+	score("local-002", agent.RoleSuperintendent, 90)
+	score("local-002", agent.RoleEngineer, 80)
+	score("local-002", agent.RoleEngineer, 100) // the lowest score is kept
+	score("local-003", agent.RoleSuperintendent, 70)
+	score("local-003", agent.RoleEngineer, 40)
+	score("local-009", agent.RoleEngineer, 0) // never assigned: not tracked
+
+	effects, err := m.Effects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		before, after ScoreStats
+		change        float64
+		ok            bool
+	}{
+		{ScoreStats{1, 50}, ScoreStats{2, 160}, 30, true},
+		{ScoreStats{1, 60}, ScoreStats{1, 80}, 20, true},
+		{ScoreStats{}, ScoreStats{1, 40}, 0, false},
+	}
+	if len(effects) != len(want) {
+		t.Fatalf("got %d effects, want %d", len(effects), len(want))
+	}
+	for i, w := range want {
+		e := effects[i]
+		change, ok := e.Change()
+		if e.Before != w.before || e.After != w.after || change != w.change || ok != w.ok {
+			t.Errorf("effect of %q = before %+v, after %+v, change %v (%v); want %+v, %+v, %v (%v)",
+				e.Lesson.Text, e.Before, e.After, change, ok, w.before, w.after, w.change, w.ok)
+		}
+	}
+}
+
+func TestRetireLessons(t *testing.T) {
+	m := &Manager{DataDir: t.TempDir(), RetireAfter: 2}
+	if err := m.RecordAssignment("local-001", nil); err != nil {
+		t.Fatal(err)
+	}
+	kept := Lesson{Risk: RiskHigh, Role: agent.RoleSuperintendent, Pinned: true, Text: "仕様を確認すること"}
+	if err := SaveLessons(m.LessonsPath(), []Lesson{
+		{Risk: RiskLow, Role: agent.RoleSuperintendent, Text: "効果のない教訓"},
+		kept,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"local-002", "local-003"} {
+		if err := m.RecordAssignment(id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Scored through processResult, which retires lessons after recording.
+	for _, r := range []ScoringResult{
+		{IssueID: "local-001", Score: 80},
+		{IssueID: "local-002", Score: 70},
+	} {
+		if err := m.processResult(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _ := LoadLessons(m.LessonsPath()); len(got) != 2 {
+		t.Fatalf("retired after 1 issue: %v", got)
+	}
+
+	if err := m.processResult(&ScoringResult{IssueID: "local-003", Score: 80}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadLessons(m.LessonsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != kept {
+		t.Errorf("lessons after retiring = %v, want only the pinned one", got)
+	}
+	retired, err := LoadLessons(filepath.Join(m.DataDir, retiredFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retired) != 1 || retired[0].Text != "効果のない教訓" {
+		t.Errorf("retired lessons = %v", retired)
+	}
+}
@@ -0,0 +1,241 @@
+package lessons
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/chatlog"
+)
+
+// changesRequestedTag starts the superintendent's modification instructions
+// after a review (see the superintendent prompt).
+const changesRequestedTag = "[Changes Requested]"
+
+// Repo is a repository scanned for reverted merges.
+type Repo struct {
+	Name string
+	Path string
+}
+
+// ProcessEngineerWork scores the engineer's work on a finished issue (see
+// ScoreEngineerWork) and generates an engineer lesson when the score is
+// below the threshold.
+func (m *Manager) ProcessEngineerWork(src LocalSource) error {
+	return m.processResult(ScoreEngineerWork(src))
+}
+
+// ScoreEngineerWork scores the engineer's work on an issue from the chatlog:
+//
+//   - VERIFY failures and PR_MERGE refusals for failing CI, when repeated
+//   - the superintendent requesting changes after a review
+//
+// The lesson is tagged with the issue's repository when it involves only one.
+func ScoreEngineerWork(src LocalSource) *ScoringResult {
+	id := src.Issue.ID
+	result := &ScoringResult{IssueID: id, Score: 100, Role: agent.RoleEngineer}
+	if len(src.RepoNames) == 1 {
+		result.Repo = src.RepoNames[0]
+	}
+	msgs := issueMessages(src.ChatlogPath, mentionPattern(id))
+
+	if n := checkFailures(msgs); n >= 2 {
+		risk := RiskMedium
+		if n >= 4 {
+			risk = RiskHigh
+		}
+		result.Failures = append(result.Failures, Failure{
+			Description: fmt.Sprintf("CI・検証の失敗が繰り返された (%d回)", n),
+			Risk:        risk,
+			Points:      min(10*n, 40),
+		})
+	}
+	if n := reviewRejections(msgs); n > 0 {
+		risk := RiskMedium
+		if n >= 3 {
+			risk = RiskHigh
+		}
+		result.Failures = append(result.Failures, Failure{
+			Description: fmt.Sprintf("レビューで修正を求められた (%d回)", n),
+			Risk:        risk,
+			Points:      min(15*n, 45),
+		})
+	}
+
+	for _, f := range result.Failures {
+		result.Score -= f.Points
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	return result
+}
+
+// checkFailures counts the failed VERIFY runs and the PR_MERGE refusals for
+// failing CI. The orchestrator reports each of them to the superintendent
+// once, whoever sent the command.
+func checkFailures(msgs []chatlog.Message) int {
+	n := 0
+	for _, m := range msgs {
+		if m.Sender == "orchestrator" && m.Recipient == "superintendent" &&
+			(strings.Contains(m.Body, "検証に失敗しました") || strings.Contains(m.Body, "CI が成功していません")) {
+			n++
+		}
+	}
+	return n
+}
+
+// reviewRejections counts the superintendent's modification instructions
+// to engineers after a review.
+func reviewRejections(msgs []chatlog.Message) int {
+	n := 0
+	for _, m := range msgs {
+		if m.Sender == "superintendent" && strings.HasPrefix(m.Recipient, "engineer") &&
+			strings.Contains(m.Body, changesRequestedTag) {
+			n++
+		}
+	}
+	return n
+}
+
+// revertsFile records, per repository, the revert commits on develop that
+// were already scored.
+const revertsFile = "lessons-reverts.json"
+
+var (
+	revertedCommitRe = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,40})`)
+	issueTrailerRe   = regexp.MustCompile(`(?m)^\s*(?:\* )?Madflow-Issue: (\S+)\s*$`)
+)
+
+// ProcessReverts scans the develop branch of the repositories for revert
+// commits not seen before, and generates an engineer lesson, tagged with
+// the repository, for each issue whose merged commits were reverted. The
+// reverted commits are attributed to issues by their Madflow-Issue trailer,
+// or by the feature branch named in a merge commit's subject.
+//
+// The first scan of a repository only records the reverts already there.
+func (m *Manager) ProcessReverts(repos []Repo, develop string) error {
+	path := filepath.Join(m.DataDir, revertsFile)
+	seen := make(map[string][]string)
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &seen); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	changed := false
+	for _, r := range repos {
+		known, scanned := seen[r.Name]
+		if !scanned {
+			known = []string{}
+		}
+		for _, rv := range developReverts(r.Path, developRef(r.Path, develop)) {
+			if slices.Contains(known, rv.hash) {
+				continue
+			}
+			known = append(known, rv.hash)
+			if !scanned {
+				continue
+			}
+			for _, id := range revertedIssues(r.Path, rv.reverted, m.FeaturePrefix) {
+				result := &ScoringResult{IssueID: id, Score: 100, Role: agent.RoleEngineer, Repo: r.Name}
+				result.Failures = []Failure{{Description: "マージ後にリバートされた", Risk: RiskHigh, Points: 40}}
+				result.Score -= 40
+				if err := m.processResult(result); err != nil {
+					return err
+				}
+			}
+		}
+		if !scanned || len(known) != len(seen[r.Name]) {
+			seen[r.Name] = known
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.DataDir, 0700); err != nil {
+		return fmt.Errorf("mkdir for %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// revert is a revert commit and the commit it reverts.
+type revert struct {
+	hash     string
+	reverted string
+}
+
+// developReverts returns the revert commits on ref, oldest first.
+func developReverts(repo, ref string) []revert {
+	out, err := exec.Command("git", "-C", repo, "log", "--reverse", "--format=%H%x1f%s%x1f%b%x1e",
+		"--grep", "This reverts commit", ref).Output()
+	if err != nil {
+		return nil
+	}
+	var reverts []revert
+	for _, rec := range strings.Split(string(out), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(rec), "\x1f", 3)
+		// Squash merges can quote "This reverts commit" from a branch's
+		// own commits; only actual revert commits count.
+		if len(fields) < 3 || !strings.HasPrefix(fields[1], "Revert ") {
+			continue
+		}
+		if m := revertedCommitRe.FindStringSubmatch(fields[2]); m != nil {
+			reverts = append(reverts, revert{hash: fields[0], reverted: m[1]})
+		}
+	}
+	return reverts
+}
+
+// revertedIssues returns the issues of the reverted commit: the
+// Madflow-Issue trailers of the commit or, for a merge commit, of the
+// commits it merged, or else the feature branch named in its subject.
+func revertedIssues(repo, commit, featurePrefix string) []string {
+	out, err := exec.Command("git", "-C", repo, "log", "--format=%B", commit+"^1.."+commit).Output()
+	if err != nil {
+		// A root commit has no parent.
+		if out, err = exec.Command("git", "-C", repo, "log", "-1", "--format=%B", commit).Output(); err != nil {
+			return nil
+		}
+	}
+	var ids []string
+	for _, m := range issueTrailerRe.FindAllStringSubmatch(string(out), -1) {
+		if !slices.Contains(ids, m[1]) {
+			ids = append(ids, m[1])
+		}
+	}
+	if len(ids) > 0 || featurePrefix == "" {
+		return ids
+	}
+	subject, err := exec.Command("git", "-C", repo, "log", "-1", "--format=%s", commit).Output()
+	if err != nil {
+		return nil
+	}
+	branchRe := regexp.MustCompile(regexp.QuoteMeta(featurePrefix) + `([A-Za-z0-9_.-]*[A-Za-z0-9_])`)
+	if m := branchRe.FindStringSubmatch(string(subject)); m != nil {
+		return []string{m[1]}
+	}
+	return nil
+}
+
+// developRef returns origin/<develop> when the repository has it, since
+// merges land there first, and develop otherwise.
+func developRef(repo, develop string) string {
+	if exec.Command("git", "-C", repo, "rev-parse", "-q", "--verify", "origin/"+develop).Run() == nil {
+		return "origin/" + develop
+	}
+	return develop
+}
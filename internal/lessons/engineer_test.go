@@ -0,0 +1,103 @@
+package lessons
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+func TestScoreEngineerWork(t *testing.T) {
+	chatlogPath := writeChatlog(t,
+		"[2026-01-01T10:00:00] [@superintendent] orchestrator: [verify] local-001: feature/issue-local-001 の検証に失敗しました: app: test FAILED。合格するまでレビューと PR_MERGE は行わないでください。",
+		// The engineer's own copy of a result is not counted again.
+		"[2026-01-01T10:00:01] [@engineer-1] orchestrator: NACK id=1 VERIFY local-001: 検証に失敗しました: app: test FAILED。",
+		"[2026-01-01T10:20:00] [@superintendent] orchestrator: [verify] local-001: feature/issue-local-001 の検証に失敗しました: app: lint FAILED。合格するまでレビューと PR_MERGE は行わないでください。",
+		"[2026-01-01T11:00:00] [@engineer-1] superintendent: [Changes Requested] local-001: エラー処理が抜けています。",
+		"[2026-01-01T11:30:00] [@superintendent] orchestrator: NACK id=2 PR_MERGE local-001 に失敗しました: どの PR もマージしていません: app#3 の CI が成功していません",
+		// Another issue's failures do not count.
+		"[2026-01-01T12:00:00] [@superintendent] orchestrator: [verify] local-0011: feature/issue-local-0011 の検証に失敗しました: app: test FAILED。",
+	)
+	r := ScoreEngineerWork(LocalSource{
+		Issue:       &issue.Issue{ID: "local-001"},
+		ChatlogPath: chatlogPath,
+		RepoNames:   []string{"app"},
+	})
+	want := "CI・検証の失敗が繰り返された (3回), レビューで修正を求められた (1回)"
+	if got := descriptions(r); got != want {
+		t.Errorf("failures = %s, want %s", got, want)
+	}
+	if r.Score != 55 || r.Role != agent.RoleEngineer || r.Repo != "app" {
+		t.Errorf("result = %+v", r)
+	}
+
+	// A single failure is not a lesson, and an issue spanning several
+	// repositories is not tagged with one.
+	chatlogPath = writeChatlog(t,
+		"[2026-01-01T10:00:00] [@superintendent] orchestrator: [verify] local-002: feature/issue-local-002 の検証に失敗しました: app: test FAILED。",
+	)
+	r = ScoreEngineerWork(LocalSource{
+		Issue:       &issue.Issue{ID: "local-002"},
+		ChatlogPath: chatlogPath,
+		RepoNames:   []string{"app", "lib"},
+	})
+	if r.Score != 100 || len(r.Failures) != 0 || r.Repo != "" {
+		t.Errorf("result = %+v, failures: %s", r, descriptions(r))
+	}
+}
+
+func TestProcessReverts(t *testing.T) {
+	repo := initRepo(t)
+	commitFile := func(name, msg, issueID string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(msg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git(t, repo, "add", name)
+		git(t, repo, "commit", "-m", msg, "-m", "Madflow-Issue: "+issueID)
+	}
+	commitFile("old.txt", "feat: old", "local-001")
+	git(t, repo, "revert", "--no-edit", "HEAD")
+
+	dataDir := t.TempDir()
+	m := &Manager{DataDir: dataDir, FeaturePrefix: "feature/issue-"}
+	repos := []Repo{{Name: "app", Path: repo}}
+
+	// The first scan only records the existing revert.
+	if err := m.ProcessReverts(repos, "develop"); err != nil {
+		t.Fatalf("ProcessReverts: %v", err)
+	}
+	if lessons, _ := LoadLessons(m.LessonsPath()); len(lessons) != 0 {
+		t.Fatalf("first scan generated lessons: %+v", lessons)
+	}
+
+	// A reverted merge of a feature branch is attributed to the issue
+	// through the merged commits' trailers.
+	git(t, repo, "checkout", "-b", "feature/issue-local-002")
+	commitFile("new.txt", "feat: new", "local-002")
+	git(t, repo, "checkout", "develop")
+	git(t, repo, "merge", "--no-ff", "feature/issue-local-002", "-m", "Merge branch 'feature/issue-local-002' into develop")
+	git(t, repo, "revert", "--no-edit", "-m", "1", "HEAD")
+
+	if err := m.ProcessReverts(repos, "develop"); err != nil {
+		t.Fatalf("ProcessReverts: %v", err)
+	}
+	lessons, _ := LoadLessons(filepath.Join(dataDir, "lessons.txt"))
+	if len(lessons) != 1 {
+		t.Fatalf("lessons = %+v, want 1", lessons)
+	}
+	if l := lessons[0]; l.Role != agent.RoleEngineer || l.Repo != "app" || l.Risk != RiskHigh || !strings.Contains(l.Text, "テスト") {
+		t.Errorf("lesson = %+v", l)
+	}
+
+	// Reverts already seen are not scored again.
+	if err := m.ProcessReverts(repos, "develop"); err != nil {
+		t.Fatalf("ProcessReverts: %v", err)
+	}
+	if lessons, _ := LoadLessons(m.LessonsPath()); len(lessons) != 1 {
+		t.Errorf("lessons after rescan = %d, want 1", len(lessons))
+	}
+}
@@ -1,21 +1,27 @@
 // Package lessons implements the feedback loop that scores issue instruction
 // quality after a PR merge, generates lessons from failures, and injects
-// accumulated lessons into the Superintendent's patrol prompts.
+// accumulated lessons into the Superintendent's patrol prompts. A second
+// stream of lessons, scored from the engineers' work, is injected into the
+// engineers' system prompts.
 package lessons
 
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/filelock"
+	"github.com/ytnobody/madflow/internal/llm"
 )
 
 // RiskLevel represents the severity level of a lesson.
@@ -41,32 +47,53 @@ type Failure struct {
 	Points      int // points deducted
 }
 
-// ScoringResult holds the result of scoring an issue's instruction quality.
+// ScoringResult holds the result of scoring an issue's instruction quality,
+// or the engineer's work on it.
 type ScoringResult struct {
 	IssueID  string
 	Score    int
 	Failures []Failure
+	// Role is the stream the lesson goes to: agent.RoleSuperintendent for
+	// instruction quality, agent.RoleEngineer for the engineer's work.
+	Role agent.Role
+	// Repo is the repository the lesson is tagged with; empty for all.
+	Repo string
 }
 
 // Lesson represents a single lesson entry.
 type Lesson struct {
 	Risk RiskLevel
+	// Role is the stream of the lesson (agent.RoleSuperintendent or
+	// agent.RoleEngineer).
+	Role agent.Role
+	// Repo limits an engineer lesson to the teams working in the repository.
+	// Empty means all repositories.
+	Repo string
+	// Pinned lessons are never trimmed or merged away.
+	Pinned bool
+	// Issue is the issue the lesson was generated from, if any.
+	Issue string
+	// Date is the day the lesson was generated or added (2006-01-02).
+	Date string
 	Text string
 }
 
-// maxLessons is the maximum number of lessons kept in the file.
-const maxLessons = 15
+// dateLayout is the format of Lesson.Date.
+const dateLayout = "2006-01-02"
 
-// lessonsMgmtModel is the Anthropic model used for lesson generation and merging.
-const lessonsMgmtModel = "claude-haiku-4-5"
+// maxLessons is the maximum number of lessons kept per stream.
+const maxLessons = 15
 
-// anthropicEndpoint is the Anthropic Messages API endpoint.
-const anthropicEndpoint = "https://api.anthropic.com/v1/messages"
+// streams are the lesson streams, in the order they are saved.
+var streams = []agent.Role{agent.RoleSuperintendent, agent.RoleEngineer}
 
-// anthropicAPIVersion is the Anthropic API version header value.
-const anthropicAPIVersion = "2023-06-01"
+// completionTimeout bounds each LLM call for lesson generation and merging.
+const completionTimeout = 2 * time.Minute
 
-// ParseLesson parses a lesson line in the format "[危険度] text".
+// ParseLesson parses a lesson line in the format "[危険度] text", optionally
+// followed by tags before the text:
+// "[高][engineer][repo:app][pinned][issue:gh-1][date:2026-01-02] text".
+// A line without a role tag is a superintendent lesson.
 // Returns an error for lines that do not match the expected format.
 func ParseLesson(line string) (Lesson, error) {
 	line = strings.TrimSpace(line)
@@ -77,14 +104,63 @@ func ParseLesson(line string) (Lesson, error) {
 	if end == -1 {
 		return Lesson{}, fmt.Errorf("invalid lesson format (missing ']'): %q", line)
 	}
-	risk := RiskLevel(line[1:end])
-	text := strings.TrimSpace(line[end+1:])
-	return Lesson{Risk: risk, Text: text}, nil
+	l := Lesson{Risk: RiskLevel(line[1:end]), Role: agent.RoleSuperintendent}
+	rest := line[end+1:]
+	// Only known tags are consumed, so a text starting with a bracket
+	// (e.g. "[Clarification Needed]") is kept.
+	for strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end == -1 {
+			break
+		}
+		tag := rest[1:end]
+		if repo, ok := strings.CutPrefix(tag, "repo:"); ok && repo != "" {
+			l.Repo = repo
+		} else if id, ok := strings.CutPrefix(tag, "issue:"); ok && id != "" {
+			l.Issue = id
+		} else if date, ok := strings.CutPrefix(tag, "date:"); ok && date != "" {
+			l.Date = date
+		} else if tag == "pinned" {
+			l.Pinned = true
+		} else if r := agent.Role(tag); r == agent.RoleSuperintendent || r == agent.RoleEngineer {
+			l.Role = r
+		} else {
+			break
+		}
+		rest = rest[end+1:]
+	}
+	l.Text = strings.TrimSpace(rest)
+	return l, nil
 }
 
-// FormatLesson formats a lesson as "[危険度] text".
+// FormatLesson formats a lesson as "[危険度] text", with the tags that are
+// set: "[高][engineer][repo:app][pinned][issue:gh-1][date:2026-01-02] text".
 func FormatLesson(l Lesson) string {
-	return fmt.Sprintf("[%s] %s", l.Risk, l.Text)
+	var tags string
+	if l.Role != "" && l.Role != agent.RoleSuperintendent {
+		tags += "[" + string(l.Role) + "]"
+	}
+	if l.Repo != "" {
+		tags += "[repo:" + l.Repo + "]"
+	}
+	if l.Pinned {
+		tags += "[pinned]"
+	}
+	if l.Issue != "" {
+		tags += "[issue:" + l.Issue + "]"
+	}
+	if l.Date != "" {
+		tags += "[date:" + l.Date + "]"
+	}
+	return fmt.Sprintf("[%s]%s %s", l.Risk, tags, l.Text)
+}
+
+// role returns the stream of the lesson.
+func (l Lesson) role() agent.Role {
+	if l.Role == "" {
+		return agent.RoleSuperintendent
+	}
+	return l.Role
 }
 
 // LoadLessons reads lessons from a file.
@@ -146,19 +222,79 @@ func AppendLesson(path string, lesson Lesson) error {
 type Manager struct {
 	// DataDir is the MADFLOW data directory (e.g. ~/.madflow/MADFLOW).
 	DataDir string
-	// AnthropicAPIKey is the API key for LLM-based lesson generation and merging.
-	// If empty, template-based fallback is used for generation, and simple
-	// risk-based trimming is used instead of LLM merging.
-	AnthropicAPIKey string
+	// File is the lessons file. Empty means <DataDir>/lessons.txt; a file
+	// outside the data directory can be shared by several projects.
+	File string
+	// Completer generates and merges lessons with the project's utility
+	// model ([lessons] model). If nil, template-based fallback is used for
+	// generation, and simple risk-based trimming is used instead of LLM
+	// merging.
+	Completer llm.Completer
 	// FeaturePrefix is the feature branch prefix (e.g. "feature/issue-").
 	FeaturePrefix string
+	// RetireAfter is the number of scored issues a lesson is active for
+	// before it is retired if their average score did not improve (see
+	// Effects). 0 or less never retires lessons.
+	RetireAfter int
 }
 
 // LessonsPath returns the absolute path to the lessons file.
 func (m *Manager) LessonsPath() string {
+	if m.File != "" {
+		return m.File
+	}
 	return filepath.Join(m.DataDir, "lessons.txt")
 }
 
+// Update replaces the lessons with the result of fn, holding a lock on the
+// lessons file so that processes sharing it do not overwrite each other.
+func (m *Manager) Update(fn func([]Lesson) ([]Lesson, error)) error {
+	path := m.LessonsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("mkdir for lessons file: %w", err)
+	}
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	lessons, err := LoadLessons(path)
+	if err != nil {
+		return err
+	}
+	if lessons, err = fn(lessons); err != nil {
+		return err
+	}
+	return SaveLessons(path, lessons)
+}
+
+// Import adds the lessons of the file at path that are not in the lessons
+// file yet (same role, repository and text), then applies the per-stream
+// limit. It returns the number of lessons added.
+func (m *Manager) Import(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	imported, err := LoadLessons(path)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	err = m.Update(func(lessons []Lesson) ([]Lesson, error) {
+		for _, l := range imported {
+			if !slices.ContainsFunc(lessons, func(e Lesson) bool {
+				return e.role() == l.role() && e.Repo == l.Repo && e.Text == l.Text
+			}) {
+				lessons = append(lessons, l)
+				added++
+			}
+		}
+		return m.limitLessons(lessons), nil
+	})
+	return added, err
+}
+
 // InjectLessons returns a formatted string of all lessons suitable for
 // prepending to the Superintendent's patrol prompt.
 // Returns an empty string when no lessons exist.
@@ -168,24 +304,49 @@ func (m *Manager) InjectLessons() string {
 		log.Printf("[lessons] InjectLessons: load failed: %v", err)
 		return ""
 	}
-	if len(lessons) == 0 {
+	var sb strings.Builder
+	for _, l := range lessons {
+		if l.role() == agent.RoleSuperintendent {
+			fmt.Fprintf(&sb, "[%s] %s\n", l.Risk, l.Text)
+		}
+	}
+	if sb.Len() == 0 {
 		return ""
 	}
+	return "## 過去の失敗から学んだ教訓\n\n" +
+		"以下は過去のIssue指示品質の採点で70点未満だったIssueから生成された教訓です。\n" +
+		"Issueをエンジニアに割り当てる際は必ずこれらの教訓を参照し、同じ失敗を繰り返さないようにしてください：\n\n" +
+		sb.String() + "\n"
+}
 
+// InjectEngineerLessons returns the engineer lessons for a team working in
+// the given repositories, formatted for appending to the engineer's system
+// prompt: those tagged with one of the repositories and those without a
+// repository tag. Returns an empty string when there are none.
+func (m *Manager) InjectEngineerLessons(repos []string) string {
+	lessons, err := LoadLessons(m.LessonsPath())
+	if err != nil {
+		log.Printf("[lessons] InjectEngineerLessons: load failed: %v", err)
+		return ""
+	}
 	var sb strings.Builder
-	sb.WriteString("## 過去の失敗から学んだ教訓\n\n")
-	sb.WriteString("以下は過去のIssue指示品質の採点で70点未満だったIssueから生成された教訓です。\n")
-	sb.WriteString("Issueをエンジニアに割り当てる際は必ずこれらの教訓を参照し、同じ失敗を繰り返さないようにしてください：\n\n")
 	for _, l := range lessons {
-		sb.WriteString(FormatLesson(l))
-		sb.WriteByte('\n')
+		if l.role() == agent.RoleEngineer && (l.Repo == "" || slices.Contains(repos, l.Repo)) {
+			fmt.Fprintf(&sb, "[%s] %s\n", l.Risk, l.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
 	}
-	sb.WriteByte('\n')
-	return sb.String()
+	return "## 過去の失敗から学んだ教訓\n\n" +
+		"以下は過去のエンジニアの作業（CI・検証の失敗、レビューでの差し戻し、マージ後のリバート）から生成された教訓です。\n" +
+		"実装・検証・レビュー依頼の際はこれらの教訓を参照し、同じ失敗を繰り返さないようにしてください：\n\n" +
+		sb.String()
 }
 
 // ProcessMergedIssue scores the issue, generates a lesson if needed, and
-// manages the lesson count. It is a no-op for local issues (id prefix "local-").
+// manages the lesson count. It is a no-op for local issues (id prefix
+// "local-"), which are scored by ProcessLocalIssue.
 func (m *Manager) ProcessMergedIssue(issueID, owner, repo string, issueNumber int) error {
 	// Only process GitHub-synced issues
 	if strings.HasPrefix(issueID, "local-") || owner == "" || repo == "" || issueNumber <= 0 {
@@ -197,9 +358,24 @@ func (m *Manager) ProcessMergedIssue(issueID, owner, repo string, issueNumber in
 	if err != nil {
 		return fmt.Errorf("score issue %s: %w", issueID, err)
 	}
+	return m.processResult(result)
+}
 
+// processResult records the score of a scored issue, retires the lessons
+// that show no improvement, and generates and stores a lesson when the
+// issue is below the quality threshold.
+func (m *Manager) processResult(result *ScoringResult) error {
+	issueID := result.IssueID
 	log.Printf("[lessons] issue %s scored %d/100 (failures: %d)", issueID, result.Score, len(result.Failures))
 
+	// Every score counts for the effectiveness of the lessons.
+	if err := m.recordScore(result); err != nil {
+		log.Printf("[lessons] record score of %s: %v", issueID, err)
+	}
+	if err := m.retireLessons(); err != nil {
+		log.Printf("[lessons] retire lessons: %v", err)
+	}
+
 	if result.Score >= 70 || len(result.Failures) == 0 {
 		log.Printf("[lessons] issue %s passed quality threshold (score=%d), no lesson generated", issueID, result.Score)
 		return nil
@@ -211,19 +387,20 @@ func (m *Manager) ProcessMergedIssue(issueID, owner, repo string, issueNumber in
 		log.Printf("[lessons] lesson generation failed for %s (using fallback): %v", issueID, err)
 		lesson = fallbackLesson(result.Failures)
 	}
+	lesson.Role = result.Role
+	lesson.Repo = result.Repo
+	lesson.Issue = issueID
+	lesson.Date = time.Now().Format(dateLayout)
 
 	log.Printf("[lessons] generated lesson for %s: %s", issueID, FormatLesson(lesson))
 
-	// Append the lesson to the file
-	if err := AppendLesson(m.LessonsPath(), lesson); err != nil {
+	// Append the lesson and manage the 15-lesson limit
+	if err := m.Update(func(lessons []Lesson) ([]Lesson, error) {
+		return m.limitLessons(append(lessons, lesson)), nil
+	}); err != nil {
 		return fmt.Errorf("append lesson: %w", err)
 	}
 
-	// Manage the 15-lesson limit
-	if err := m.manageLessonsCount(); err != nil {
-		log.Printf("[lessons] manageLessonsCount: %v (continuing)", err)
-	}
-
 	return nil
 }
 
@@ -365,15 +542,11 @@ func hasMultiplePRs(fullRepo, featureHead string) bool {
 	return len(prs) >= 2
 }
 
-// generateLesson calls the Anthropic API to generate a 1-line lesson in Japanese.
-// Returns an error when the API key is not available or the call fails.
+// generateLesson asks the utility model to generate a 1-line lesson in Japanese.
+// Returns an error when there is no model or the call fails.
 func (m *Manager) generateLesson(result *ScoringResult) (Lesson, error) {
-	apiKey := m.AnthropicAPIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-	}
-	if apiKey == "" {
-		return Lesson{}, fmt.Errorf("ANTHROPIC_API_KEY not set")
+	if m.Completer == nil {
+		return Lesson{}, fmt.Errorf("no utility model configured")
 	}
 
 	// Determine the highest-risk failure
@@ -389,21 +562,26 @@ func (m *Manager) generateLesson(result *ScoringResult) (Lesson, error) {
 		fmt.Fprintf(&failureLines, "- [%s] %s\n", f.Risk, f.Description)
 	}
 
+	subject, advice := "Issue指示品質", "次回のIssue指示でどうすればよかったか"
+	if result.Role == agent.RoleEngineer {
+		subject, advice = "エンジニアの作業品質", "次回の実装・検証・レビュー依頼でどうすればよかったか"
+	}
+
 	prompt := fmt.Sprintf(`あなたはソフトウェア開発プロジェクトの品質改善アドバイザーです。
 
-以下のIssue指示品質の採点結果（%d/100点）を見て、同じ失敗を繰り返さないための教訓を1行の日本語で生成してください。
+以下の%sの採点結果（%d/100点）を見て、同じ失敗を繰り返さないための教訓を1行の日本語で生成してください。
 
 検出された失敗：
 %s
 条件：
-- 教訓は「次回のIssue指示でどうすればよかったか」を具体的に述べること
+- 教訓は「%s」を具体的に述べること
 - 1行、50文字以内で簡潔に
 - 「〜すること」「〜を確認すること」などの行動指針の形式で
 - 教訓テキストのみを出力（角括弧や危険度は含めない）
 
-教訓：`, result.Score, failureLines.String())
+教訓：`, subject, result.Score, failureLines.String(), advice)
 
-	text, err := callAnthropicSimple(apiKey, prompt)
+	text, err := m.complete(prompt)
 	if err != nil {
 		return Lesson{}, err
 	}
@@ -434,54 +612,92 @@ func fallbackLesson(failures []Failure) Lesson {
 		"[Clarification Needed] コメントが存在した": "仕様が曖昧なままEngineerに渡さず、先に仕様を人間に確認してから指示すること",
 		"Superintendentが直接実装した":            "EngineerやOrchestratorが応答しない場合の対応手順を見直し、直接実装に頼らないようにすること",
 		"PRが2本以上作成された":                     "重複PRが発生しないようブランチとPR管理を徹底し、作業開始前に既存PRを確認すること",
+		"監督への仕様確認が発生した":                    "Engineerが質問せずに着手できるよう、変更対象と期待する挙動をIssueに明記すること",
+		"ブランチが2本以上作成された":                   "同じIssueでブランチを作り直さないよう、作業範囲と既存ブランチを確認してから指示すること",
+		"CI・検証の失敗が繰り返された":                  "VERIFY を送る前にビルド・lint・テストを手元で実行し、すべて通ることを確認すること",
+		"レビューで修正を求められた":                    "レビュー依頼の前に完了条件を一つずつ確認し、差分を自分でレビューしてから依頼すること",
+		"マージ後にリバートされた":                     "既存の挙動を壊さないよう、変更の影響範囲のテストを追加・実行してからレビューを依頼すること",
 	}
 
-	if text, ok := templates[topFailure.Description]; ok {
+	// Counts such as " (3回)" are not part of the template key.
+	desc, _, _ := strings.Cut(topFailure.Description, " (")
+	if text, ok := templates[desc]; ok {
 		return Lesson{Risk: highestRisk, Text: text}
 	}
 	return Lesson{Risk: highestRisk, Text: "Issueの指示品質を向上させ、エンジニアが迷わず実装できるよう仕様を明確にすること"}
 }
 
-// manageLessonsCount ensures there are at most maxLessons lessons.
-// Uses LLM to merge similar lessons first, then falls back to trimming.
+// manageLessonsCount ensures there are at most maxLessons lessons in each
+// stream of the lessons file (see limitLessons).
 func (m *Manager) manageLessonsCount() error {
-	lessons, err := LoadLessons(m.LessonsPath())
-	if err != nil {
-		return err
+	return m.Update(func(lessons []Lesson) ([]Lesson, error) {
+		return m.limitLessons(lessons), nil
+	})
+}
+
+// limitLessons returns the lessons with at most maxLessons in each stream.
+// Uses LLM to merge similar lessons first, then falls back to trimming.
+// Streams within the limit are left as they are, and pinned lessons are
+// always kept.
+func (m *Manager) limitLessons(lessons []Lesson) []Lesson {
+	byRole := make(map[agent.Role][]Lesson)
+	over := false
+	for _, l := range lessons {
+		byRole[l.role()] = append(byRole[l.role()], l)
+		over = over || len(byRole[l.role()]) > maxLessons
 	}
-	if len(lessons) <= maxLessons {
-		return nil
+	if !over {
+		return lessons
 	}
 
-	log.Printf("[lessons] %d lessons exceed limit of %d, consolidating...", len(lessons), maxLessons)
-
-	// Try LLM-based merging first
-	apiKey := m.AnthropicAPIKey
-	if apiKey == "" {
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-	}
+	var result []Lesson
+	for _, role := range streams {
+		stream := byRole[role]
+		if len(stream) > maxLessons {
+			log.Printf("[lessons] %d %s lessons exceed limit of %d, consolidating...", len(stream), role, maxLessons)
+			var pinned, unpinned []Lesson
+			for _, l := range stream {
+				if l.Pinned {
+					pinned = append(pinned, l)
+				} else {
+					unpinned = append(unpinned, l)
+				}
+			}
+			// Try LLM-based merging first
+			if m.Completer != nil && len(unpinned) > 1 {
+				merged, err := m.mergeLessonsWithLLM(unpinned)
+				if err != nil {
+					log.Printf("[lessons] LLM merging failed: %v, falling back to trim", err)
+				} else {
+					for i := range merged {
+						merged[i].Role = role
+						merged[i].Pinned = false
+					}
+					stream = append(pinned, merged...)
+					log.Printf("[lessons] LLM merging resulted in %d %s lessons", len(stream), role)
+				}
+			}
 
-	if apiKey != "" {
-		merged, err := mergeLessonsWithLLM(apiKey, lessons)
-		if err != nil {
-			log.Printf("[lessons] LLM merging failed: %v, falling back to trim", err)
-		} else {
-			lessons = merged
-			log.Printf("[lessons] LLM merging resulted in %d lessons", len(lessons))
+			// If still over limit, trim by risk level
+			if len(stream) > maxLessons {
+				stream = trimLessons(stream)
+				log.Printf("[lessons] trimmed to %d %s lessons", len(stream), role)
+			}
 		}
+		result = append(result, stream...)
 	}
+	return result
+}
 
-	// If still over limit, trim by risk level
-	if len(lessons) > maxLessons {
-		lessons = trimLessons(lessons)
-		log.Printf("[lessons] trimmed to %d lessons", len(lessons))
-	}
-
-	return SaveLessons(m.LessonsPath(), lessons)
+// complete sends prompt to the utility model.
+func (m *Manager) complete(prompt string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	return m.Completer.Complete(ctx, prompt)
 }
 
-// mergeLessonsWithLLM asks the Anthropic API to merge semantically similar lessons.
-func mergeLessonsWithLLM(apiKey string, lessons []Lesson) ([]Lesson, error) {
+// mergeLessonsWithLLM asks the utility model to merge semantically similar lessons.
+func (m *Manager) mergeLessonsWithLLM(lessons []Lesson) ([]Lesson, error) {
 	var sb strings.Builder
 	for i, l := range lessons {
 		fmt.Fprintf(&sb, "%d. %s\n", i+1, FormatLesson(l))
@@ -493,15 +709,17 @@ func mergeLessonsWithLLM(apiKey string, lessons []Lesson) ([]Lesson, error) {
 %s
 ルール：
 - 意味が重複または非常に近い教訓を1行に統合する
+- [repo:...] タグが異なる教訓は統合せず、統合後もタグをそのまま残す
+- [issue:...] と [date:...] タグは、統合元のうち最も新しい教訓のものを残す
 - 統合後も危険度は元のうち最も高いものを使用する
 - 統合により内容が失われないよう、統合後のテキストに重要な要点を含める
-- 出力形式: 各行に "[危険度] 教訓テキスト" の形式で教訓を1件ずつ出力
+- 出力形式: 各行に "[危険度] 教訓テキスト" の形式で教訓を1件ずつ出力（タグは危険度の直後に付ける）
 - 危険度は「高」「中」「低」のいずれか
 - テキストのみ出力（番号やコメント不要）
 
 統合後の教訓リスト：`, len(lessons), sb.String())
 
-	text, err := callAnthropicSimple(apiKey, prompt)
+	text, err := m.complete(prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -535,6 +753,8 @@ func mergeLessonsWithLLM(apiKey string, lessons []Lesson) ([]Lesson, error) {
 
 // trimLessons removes lowest-risk lessons until at most maxLessons remain.
 // Within the same risk level, earlier (older) lessons are removed first.
+// Pinned lessons are never removed, so more may remain when over
+// maxLessons are pinned.
 func trimLessons(lessons []Lesson) []Lesson {
 	if len(lessons) <= maxLessons {
 		return lessons
@@ -566,7 +786,7 @@ func trimLessons(lessons []Lesson) []Lesson {
 			if toRemove == 0 {
 				break
 			}
-			if il.lesson.Risk == checkRisk {
+			if il.lesson.Risk == checkRisk && !il.lesson.Pinned {
 				removed[il.idx] = true
 				toRemove--
 			}
@@ -584,84 +804,3 @@ func trimLessons(lessons []Lesson) []Lesson {
 	}
 	return result
 }
-
-// --- Anthropic API helpers ---
-
-type simpleAnthropicRequest struct {
-	Model     string                   `json:"model"`
-	MaxTokens int                      `json:"max_tokens"`
-	Messages  []simpleAnthropicMessage `json:"messages"`
-}
-
-type simpleAnthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type simpleAnthropicResponse struct {
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	Error *struct {
-		Type    string `json:"type"`
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
-}
-
-// callAnthropicSimple makes a simple (non-agentic) call to the Anthropic API.
-func callAnthropicSimple(apiKey, prompt string) (string, error) {
-	reqBody := simpleAnthropicRequest{
-		Model:     lessonsMgmtModel,
-		MaxTokens: 1024,
-		Messages: []simpleAnthropicMessage{
-			{Role: "user", Content: prompt},
-		},
-	}
-
-	data, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("marshal anthropic request: %w", err)
-	}
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	req, err := http.NewRequest(http.MethodPost, anthropicEndpoint, bytes.NewReader(data))
-	if err != nil {
-		return "", fmt.Errorf("create anthropic request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", apiKey)
-	req.Header.Set("anthropic-version", anthropicAPIVersion)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("anthropic API call: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("read anthropic response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("anthropic API HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
-	}
-
-	var apiResp simpleAnthropicResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("unmarshal anthropic response: %w", err)
-	}
-
-	if apiResp.Error != nil {
-		return "", fmt.Errorf("anthropic API error (%s): %s", apiResp.Error.Type, apiResp.Error.Message)
-	}
-
-	for _, block := range apiResp.Content {
-		if block.Type == "text" && block.Text != "" {
-			return block.Text, nil
-		}
-	}
-
-	return "", fmt.Errorf("no text content in anthropic response")
-}
@@ -1,11 +1,14 @@
 package lessons
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ytnobody/madflow/internal/agent"
 )
 
 func TestParseLesson(t *testing.T) {
@@ -133,6 +136,7 @@ func TestInjectLessons_WithLessons(t *testing.T) {
 	lessons := []Lesson{
 		{Risk: RiskHigh, Text: "重要な教訓"},
 		{Risk: RiskMedium, Text: "中程度の教訓"},
+		{Risk: RiskLow, Pinned: true, Issue: "gh-7", Date: "2026-03-01", Text: "固定された教訓"},
 	}
 	if err := SaveLessons(path, lessons); err != nil {
 		t.Fatalf("SaveLessons: %v", err)
@@ -150,6 +154,9 @@ func TestInjectLessons_WithLessons(t *testing.T) {
 	if !strings.Contains(result, "[中] 中程度の教訓") {
 		t.Errorf("InjectLessons: missing medium-risk lesson: %q", result)
 	}
+	if !strings.Contains(result, "[低] 固定された教訓") || strings.Contains(result, "pinned") || strings.Contains(result, "gh-7") {
+		t.Errorf("InjectLessons: bookkeeping tags should not reach the prompt: %q", result)
+	}
 }
 
 func TestTrimLessons(t *testing.T) {
@@ -298,3 +305,206 @@ func TestManageLessonsCount_Under15(t *testing.T) {
 		t.Errorf("manageLessonsCount under 15: got %d lessons, want 10", len(loaded))
 	}
 }
+
+func TestParseFormatLesson_Tags(t *testing.T) {
+	line := "[高][engineer][repo:app] テストを追加すること"
+	l, err := ParseLesson(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Risk != RiskHigh || l.Role != agent.RoleEngineer || l.Repo != "app" || l.Text != "テストを追加すること" {
+		t.Errorf("ParseLesson(%q) = %+v", line, l)
+	}
+	if got := FormatLesson(l); got != line {
+		t.Errorf("FormatLesson() = %q, want %q", got, line)
+	}
+
+	// Untagged lines are superintendent lessons, and unknown brackets are text.
+	l, err = ParseLesson("[中] [Clarification Needed] を減らすこと")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Role != agent.RoleSuperintendent || l.Repo != "" || l.Text != "[Clarification Needed] を減らすこと" {
+		t.Errorf("ParseLesson() = %+v", l)
+	}
+	if got := FormatLesson(l); got != "[中] [Clarification Needed] を減らすこと" {
+		t.Errorf("FormatLesson() = %q", got)
+	}
+}
+
+func TestInjectEngineerLessons(t *testing.T) {
+	dir := t.TempDir()
+	lessons := []Lesson{
+		{Risk: RiskHigh, Text: "監督向けの教訓"},
+		{Risk: RiskHigh, Role: agent.RoleEngineer, Text: "全リポジトリ向けの教訓"},
+		{Risk: RiskMedium, Role: agent.RoleEngineer, Repo: "app", Text: "app向けの教訓"},
+		{Risk: RiskLow, Role: agent.RoleEngineer, Repo: "lib", Text: "lib向けの教訓"},
+	}
+	if err := SaveLessons(filepath.Join(dir, "lessons.txt"), lessons); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{DataDir: dir}
+
+	got := m.InjectEngineerLessons([]string{"app"})
+	for _, want := range []string{"[高] 全リポジトリ向けの教訓", "[中] app向けの教訓"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("InjectEngineerLessons: missing %q in %q", want, got)
+		}
+	}
+	for _, unwanted := range []string{"監督向けの教訓", "lib向けの教訓"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("InjectEngineerLessons: unexpected %q in %q", unwanted, got)
+		}
+	}
+
+	if sup := m.InjectLessons(); !strings.Contains(sup, "監督向けの教訓") || strings.Contains(sup, "app向けの教訓") {
+		t.Errorf("InjectLessons = %q, want only the superintendent stream", sup)
+	}
+}
+
+func TestManageLessonsCount_PerStream(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lessons.txt")
+	m := &Manager{DataDir: dir}
+
+	var lessons []Lesson
+	for i := range 10 {
+		lessons = append(lessons, Lesson{Risk: RiskMedium, Text: fmt.Sprintf("superintendent%d", i)})
+	}
+	for i := range maxLessons + 3 {
+		lessons = append(lessons, Lesson{Risk: RiskLow, Role: agent.RoleEngineer, Repo: "app", Text: fmt.Sprintf("engineer%d", i)})
+	}
+	if err := SaveLessons(path, lessons); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.manageLessonsCount(); err != nil {
+		t.Fatalf("manageLessonsCount: %v", err)
+	}
+
+	loaded, _ := LoadLessons(path)
+	counts := make(map[agent.Role]int)
+	for _, l := range loaded {
+		counts[l.Role]++
+		if l.Text == "engineer0" {
+			t.Error("oldest engineer lesson should have been trimmed")
+		}
+	}
+	if counts[agent.RoleSuperintendent] != 10 || counts[agent.RoleEngineer] != maxLessons {
+		t.Errorf("lessons per stream = %v, want 10 superintendent and %d engineer", counts, maxLessons)
+	}
+}
+
+func TestParseFormatLesson_Metadata(t *testing.T) {
+	line := "[中][engineer][pinned][issue:gh-1][date:2026-03-01] テストを先に書くこと"
+	l, err := ParseLesson(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.Pinned || l.Issue != "gh-1" || l.Date != "2026-03-01" || l.Text != "テストを先に書くこと" {
+		t.Errorf("ParseLesson(%q) = %+v", line, l)
+	}
+	if got := FormatLesson(l); got != line {
+		t.Errorf("FormatLesson() = %q, want %q", got, line)
+	}
+}
+
+func TestTrimLessons_KeepsPinned(t *testing.T) {
+	var lessons []Lesson
+	for i := range maxLessons + 2 {
+		lessons = append(lessons, Lesson{Risk: RiskLow, Pinned: i < 2, Text: fmt.Sprintf("low%d", i)})
+	}
+	trimmed := trimLessons(lessons)
+	if len(trimmed) != maxLessons {
+		t.Fatalf("trimLessons: got %d lessons, want %d", len(trimmed), maxLessons)
+	}
+	if trimmed[0].Text != "low0" || trimmed[1].Text != "low1" {
+		t.Errorf("trimLessons: pinned lessons should be kept: %+v", trimmed[:2])
+	}
+	for _, l := range trimmed {
+		if l.Text == "low2" || l.Text == "low3" {
+			t.Errorf("trimLessons: oldest unpinned lesson %q should have been removed", l.Text)
+		}
+	}
+
+	// More pinned lessons than the limit are all kept.
+	for i := range lessons {
+		lessons[i].Pinned = true
+	}
+	if got := trimLessons(lessons); len(got) != len(lessons) {
+		t.Errorf("trimLessons with all pinned: got %d lessons, want %d", len(got), len(lessons))
+	}
+}
+
+func TestProcessResultRecordsSource(t *testing.T) {
+	shared := filepath.Join(t.TempDir(), "shared", "lessons.txt")
+	m := &Manager{DataDir: t.TempDir(), File: shared}
+	result := &ScoringResult{
+		IssueID:  "local-001",
+		Score:    40,
+		Failures: []Failure{{Description: "派生・修正Issueが発生した", Risk: RiskHigh, Points: 30}},
+	}
+	if err := m.processResult(result); err != nil {
+		t.Fatal(err)
+	}
+	lessons, err := LoadLessons(shared)
+	if err != nil || len(lessons) != 1 {
+		t.Fatalf("lessons in the shared file = %+v (%v)", lessons, err)
+	}
+	if l := lessons[0]; l.Issue != "local-001" || l.Date == "" {
+		t.Errorf("lesson should record its source issue and date: %+v", l)
+	}
+}
+
+// fakeCompleter answers every prompt with text and records the prompts.
+type fakeCompleter struct {
+	text    string
+	prompts []string
+}
+
+func (f *fakeCompleter) Complete(_ context.Context, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	return f.text, nil
+}
+
+func TestGenerateLessonWithCompleter(t *testing.T) {
+	c := &fakeCompleter{text: "[高] 完了条件をIssueに明記すること\n"}
+	m := &Manager{DataDir: t.TempDir(), Completer: c}
+	result := &ScoringResult{
+		IssueID:  "local-001",
+		Score:    40,
+		Role:     agent.RoleEngineer,
+		Failures: []Failure{{Description: "派生・修正Issueが発生した", Risk: RiskHigh, Points: 30}},
+	}
+	l, err := m.generateLesson(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Text != "完了条件をIssueに明記すること" || l.Risk != RiskHigh {
+		t.Errorf("generateLesson = %+v", l)
+	}
+	if len(c.prompts) != 1 || !strings.Contains(c.prompts[0], "エンジニアの作業品質") {
+		t.Errorf("prompts = %q", c.prompts)
+	}
+}
+
+func TestLimitLessonsMergesWithCompleter(t *testing.T) {
+	var merged strings.Builder
+	for i := range maxLessons - 1 {
+		fmt.Fprintf(&merged, "[中] merged%d\n", i)
+	}
+	c := &fakeCompleter{text: merged.String()}
+	m := &Manager{DataDir: t.TempDir(), Completer: c}
+
+	lessons := []Lesson{{Risk: RiskLow, Pinned: true, Text: "pinned"}}
+	for i := range maxLessons + 2 {
+		lessons = append(lessons, Lesson{Risk: RiskMedium, Text: fmt.Sprintf("lesson%d", i)})
+	}
+	got := m.limitLessons(lessons)
+	if len(got) != maxLessons || got[0].Text != "pinned" || !got[0].Pinned {
+		t.Fatalf("limitLessons = %+v", got)
+	}
+	if len(c.prompts) != 1 || strings.Contains(c.prompts[0], "pinned") {
+		t.Errorf("pinned lessons should not be sent for merging: %q", c.prompts)
+	}
+}
@@ -0,0 +1,262 @@
+package lessons
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/chatlog"
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+// manyCommits is the number of commits for one issue above which the issue
+// is considered to have needed too much rework.
+const manyCommits = 20
+
+// LocalSource is the local data an issue is scored from when it has no
+// GitHub issue and PRs to query.
+type LocalSource struct {
+	// Issue is the finished issue.
+	Issue *issue.Issue
+	// Issues are all known issues; those referring to Issue count as derived.
+	Issues []*issue.Issue
+	// ChatlogPath is the chatlog. Only the messages it still holds are used,
+	// so issues should be scored before it is truncated.
+	ChatlogPath string
+	// RepoPaths are the repositories the issue was worked on in.
+	RepoPaths []string
+	// RepoNames are the names of those repositories; engineer lessons are
+	// tagged with the repository when there is only one.
+	RepoNames []string
+	// Develop is the develop branch, the base of the feature branches.
+	Develop string
+}
+
+// ProcessLocalIssue scores a finished issue from local data (see
+// ScoreLocalIssue) and, like ProcessMergedIssue, generates a lesson when
+// the score is below the threshold.
+func (m *Manager) ProcessLocalIssue(src LocalSource) error {
+	result := ScoreLocalIssue(src, m.FeaturePrefix)
+	return m.processResult(result)
+}
+
+// ScoreLocalIssue scores the instruction quality of an issue from local data:
+//
+//   - other issues referring to it (derived/fix issues)
+//   - clarification questions in its comments and in the chatlog
+//   - the superintendent announcing a direct implementation in the chatlog
+//   - more than one branch, revert commits, or many commits for the issue in
+//     the repositories' git history
+//   - the issue's team being restarted (a new team, or the engineer process
+//     exiting unexpectedly)
+func ScoreLocalIssue(src LocalSource, featurePrefix string) *ScoringResult {
+	id := src.Issue.ID
+	result := &ScoringResult{IssueID: id, Score: 100}
+	fail := func(desc string, risk RiskLevel, points int) {
+		result.Failures = append(result.Failures, Failure{Description: desc, Risk: risk, Points: points})
+		result.Score -= points
+	}
+	mentions := mentionPattern(id)
+
+	for _, other := range src.Issues {
+		if other.ID != id && (mentions.MatchString(other.Body) || mentions.MatchString(other.Title)) {
+			fail("派生・修正Issueが発生した", RiskHigh, 30)
+			break
+		}
+	}
+
+	msgs := issueMessages(src.ChatlogPath, mentions)
+	switch {
+	case hasLocalClarificationComment(src.Issue):
+		fail("[Clarification Needed] コメントが存在した", RiskMedium, 20)
+	case hasClarificationMessage(msgs):
+		fail("監督への仕様確認が発生した", RiskMedium, 20)
+	}
+
+	if hasDirectImplMessage(msgs) {
+		fail("Superintendentが直接実装した", RiskMedium, 20)
+	}
+
+	if restarts := teamRestarts(msgs, id); restarts > 0 {
+		fail(fmt.Sprintf("チームが再起動された (%d回)", restarts), RiskMedium, 15)
+	}
+
+	var branches []string
+	commits := make(map[string]string)
+	for _, path := range src.RepoPaths {
+		branches = append(branches, issueBranches(path, mentions)...)
+		for hash, subject := range issueCommits(path, id, src.Develop, featurePrefix) {
+			commits[hash] = subject
+		}
+	}
+	if len(branches) >= 2 {
+		fail("ブランチが2本以上作成された", RiskLow, 15)
+	}
+	reverts := 0
+	for _, subject := range commits {
+		if strings.HasPrefix(subject, "Revert ") {
+			reverts++
+		}
+	}
+	if reverts > 0 {
+		fail(fmt.Sprintf("リバートが発生した (%d件)", reverts), RiskLow, 15)
+	}
+	if len(commits) > manyCommits {
+		fail(fmt.Sprintf("コミット数が多すぎた (%d件)", len(commits)), RiskLow, 10)
+	}
+
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	return result
+}
+
+// mentionPattern matches the issue ID as a whole word, so that local-001
+// does not match local-0011 but does match feature/issue-local-001.
+func mentionPattern(issueID string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(issueID) + `($|[^A-Za-z0-9_])`)
+}
+
+// hasLocalClarificationComment reports whether a comment of the issue asks
+// for clarification.
+func hasLocalClarificationComment(iss *issue.Issue) bool {
+	for _, c := range iss.Comments {
+		if strings.Contains(c.Body, "[Clarification Needed]") || strings.Contains(c.Body, "[Question]") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasClarificationMessage reports whether an engineer asked the
+// superintendent about the issue (see the engineer prompt).
+func hasClarificationMessage(msgs []chatlog.Message) bool {
+	for _, m := range msgs {
+		if m.Recipient == "superintendent" && strings.HasPrefix(m.Sender, "engineer") &&
+			(strings.Contains(m.Body, "確認があります") || strings.Contains(m.Body, "[Clarification Needed]")) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDirectImplMessage reports whether the superintendent announced that
+// it implements the issue itself.
+func hasDirectImplMessage(msgs []chatlog.Message) bool {
+	for _, m := range msgs {
+		if m.Sender == "superintendent" &&
+			(strings.Contains(m.Body, "implement directly") || strings.Contains(m.Body, "直接実装")) {
+			return true
+		}
+	}
+	return false
+}
+
+// teamRestarts counts the teams assigned to the issue after the first one
+// and the unexpected exits of their engineers.
+func teamRestarts(msgs []chatlog.Message, issueID string) int {
+	teams, exits := 0, 0
+	for _, m := range msgs {
+		switch {
+		case strings.Contains(m.Body, "イシュー "+issueID+" の実装をお願いします"):
+			teams++
+		case strings.Contains(m.Body, "異常終了したため再起動します"):
+			exits++
+		}
+	}
+	return max(teams-1, 0) + exits
+}
+
+// issueMessages returns the chatlog messages that mention the issue.
+// Continuation lines of multi-line messages are joined to their message.
+func issueMessages(path string, mentions *regexp.Regexp) []chatlog.Message {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var all []chatlog.Message
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if msg, err := chatlog.ParseMessage(line); err == nil {
+			all = append(all, msg)
+		} else if len(all) > 0 {
+			all[len(all)-1].Body += "\n" + line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("[lessons] read chatlog %s: %v", path, err)
+	}
+
+	var msgs []chatlog.Message
+	for _, m := range all {
+		if mentions.MatchString(m.Body) {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// issueBranches returns the names of the local and remote branches in repo
+// that mention the issue, without the remote name.
+func issueBranches(repo string, mentions *regexp.Regexp) []string {
+	out, err := exec.Command("git", "-C", repo, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes").Output()
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, ref := range strings.Fields(string(out)) {
+		name, ok := strings.CutPrefix(ref, "refs/heads/")
+		if !ok {
+			// refs/remotes/<remote>/<branch>
+			parts := strings.SplitN(ref, "/", 4)
+			if len(parts) < 4 {
+				continue
+			}
+			name = parts[3]
+		}
+		if mentions.MatchString(name) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// issueCommits returns the subjects, by hash, of the commits made for the
+// issue in repo: those with a "Madflow-Issue: <id>" trailer anywhere, and
+// those on the feature branch that are not in develop yet.
+func issueCommits(repo, issueID, develop, featurePrefix string) map[string]string {
+	commits := make(map[string]string)
+	add := func(args ...string) {
+		out, err := exec.Command("git", append([]string{"-C", repo, "log", "--format=%H %s"}, args...)...).Output()
+		if err != nil {
+			return
+		}
+		for line := range strings.Lines(string(out)) {
+			hash, subject, _ := strings.Cut(strings.TrimSpace(line), " ")
+			if hash != "" {
+				commits[hash] = subject
+			}
+		}
+	}
+	add("--all", "--grep", "^Madflow-Issue: "+issueID+"$")
+	branch := featurePrefix + issueID
+	for _, ref := range []string{branch, "origin/" + branch} {
+		for _, base := range []string{"origin/" + develop, develop} {
+			if exec.Command("git", "-C", repo, "rev-parse", "-q", "--verify", base).Run() == nil {
+				add(base + ".." + ref)
+				break
+			}
+		}
+	}
+	return commits
+}
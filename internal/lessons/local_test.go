@@ -0,0 +1,146 @@
+package lessons
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// initRepo creates a repository with a develop branch.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git(t, dir, "init", "-b", "develop")
+	git(t, dir, "config", "user.email", "test@test.com")
+	git(t, dir, "config", "user.name", "Test User")
+	git(t, dir, "commit", "--allow-empty", "-m", "initial commit")
+	return dir
+}
+
+func writeChatlog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "chatlog.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func descriptions(r *ScoringResult) string {
+	var ds []string
+	for _, f := range r.Failures {
+		ds = append(ds, f.Description)
+	}
+	return strings.Join(ds, ", ")
+}
+
+func TestScoreLocalIssue_Clean(t *testing.T) {
+	repo := initRepo(t)
+	git(t, repo, "checkout", "-b", "feature/issue-local-001")
+	git(t, repo, "commit", "--allow-empty", "-m", "feat: add x", "-m", "Madflow-Issue: local-001")
+	git(t, repo, "checkout", "develop")
+	git(t, repo, "merge", "--no-ff", "feature/issue-local-001", "-m", "merge")
+	git(t, repo, "branch", "-D", "feature/issue-local-001")
+
+	iss := &issue.Issue{ID: "local-001", Title: "Add x", Body: "add x"}
+	chatlogPath := writeChatlog(t,
+		"[2026-01-01T10:00:00] [@engineer-1] superintendent: イシュー local-001 の実装をお願いします。あなたにアサインしました。",
+		// Another issue's team restarting does not count.
+		"[2026-01-01T10:05:00] [@engineer-2] superintendent: イシュー local-0011 の実装をお願いします。あなたにアサインしました。",
+		"[2026-01-01T10:06:00] [@engineer-2] superintendent: イシュー local-0011 の実装をお願いします。あなたにアサインしました。",
+	)
+	r := ScoreLocalIssue(LocalSource{
+		Issue:       iss,
+		Issues:      []*issue.Issue{iss, {ID: "local-0011", Body: "unrelated"}},
+		ChatlogPath: chatlogPath,
+		RepoPaths:   []string{repo},
+		Develop:     "develop",
+	}, "feature/issue-")
+	if r.Score != 100 || len(r.Failures) != 0 {
+		t.Errorf("score = %d, failures: %s", r.Score, descriptions(r))
+	}
+}
+
+func TestScoreLocalIssue_Failures(t *testing.T) {
+	repo := initRepo(t)
+	git(t, repo, "checkout", "-b", "feature/issue-local-002")
+	for range manyCommits {
+		git(t, repo, "commit", "--allow-empty", "-m", "wip")
+	}
+	git(t, repo, "commit", "--allow-empty", "-m", `Revert "wip"`)
+	git(t, repo, "branch", "feature/issue-local-002-retry")
+	git(t, repo, "checkout", "develop")
+
+	iss := &issue.Issue{
+		ID:       "local-002",
+		Title:    "Improve y",
+		Comments: []issue.Comment{{Body: "**[Question]** by `engineer-1`\n\nWhich y?"}},
+	}
+	derived := &issue.Issue{ID: "local-003", Title: "Fix regression", Body: "The change for local-002 broke z."}
+	chatlogPath := writeChatlog(t,
+		"[2026-01-01T10:00:00] [@engineer-1] superintendent: イシュー local-002 の実装をお願いします。あなたにアサインしました。",
+		"[2026-01-01T10:10:00] [@superintendent] orchestrator: engineer-1 が異常終了したため再起動します。イシュー: local-002",
+		"[2026-01-01T11:00:00] [@orchestrator] superintendent: Requested TEAM_CREATE for local-002 3 times with no response. The Superintendent will implement directly.",
+	)
+	r := ScoreLocalIssue(LocalSource{
+		Issue:       iss,
+		Issues:      []*issue.Issue{iss, derived},
+		ChatlogPath: chatlogPath,
+		RepoPaths:   []string{repo},
+		Develop:     "develop",
+	}, "feature/issue-")
+
+	want := []string{
+		"派生・修正Issueが発生した",
+		"[Clarification Needed] コメントが存在した",
+		"Superintendentが直接実装した",
+		"チームが再起動された (1回)",
+		"ブランチが2本以上作成された",
+		"リバートが発生した (1件)",
+		"コミット数が多すぎた (21件)",
+	}
+	if got := descriptions(r); got != strings.Join(want, ", ") {
+		t.Errorf("failures = %s\nwant %s", got, strings.Join(want, ", "))
+	}
+	if r.Score != 0 {
+		t.Errorf("score = %d, want 0", r.Score)
+	}
+}
+
+func TestScoreLocalIssue_ChatlogClarification(t *testing.T) {
+	iss := &issue.Issue{ID: "local-004"}
+	chatlogPath := writeChatlog(t,
+		"[2026-01-01T10:00:00] [@engineer-1] superintendent: チーム 1 の engineer として以下の作業を開始します。",
+		"イシュー: local-004",
+		"[2026-01-01T10:01:00] [@superintendent] engineer-1: イシューlocal-004について確認があります。XとYどちらを指しますか？",
+	)
+	r := ScoreLocalIssue(LocalSource{Issue: iss, ChatlogPath: chatlogPath}, "feature/issue-")
+	if got := descriptions(r); got != "監督への仕様確認が発生した" || r.Score != 80 {
+		t.Errorf("score = %d, failures: %s", r.Score, got)
+	}
+}
+
+func TestIssueMessagesJoinsContinuationLines(t *testing.T) {
+	path := writeChatlog(t,
+		"[2026-01-01T10:00:00] [@superintendent] engineer-1: チーム 1 の engineer として以下の作業を開始します。",
+		"イシュー: local-005",
+		"タイトル: Something",
+	)
+	msgs := issueMessages(path, mentionPattern("local-005"))
+	if len(msgs) != 1 || !strings.HasSuffix(msgs[0].Body, "タイトル: Something") {
+		t.Errorf("messages = %+v", msgs)
+	}
+}
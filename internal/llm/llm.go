@@ -0,0 +1,283 @@
+// Package llm provides single-shot text completions, without tools, for
+// utility tasks such as generating and merging lessons. The backend is
+// chosen by the model name, like the agents' backends:
+//
+//   - "anthropic/<model>": the Anthropic Messages API (ANTHROPIC_API_KEY)
+//   - "gemini-*": the Gemini API (GOOGLE_API_KEY or GEMINI_API_KEY)
+//   - "openai/<model>": an OpenAI-compatible chat completions API
+//     (OPENAI_API_KEY, optional for local servers)
+//   - "copilot/<model>": the GitHub Copilot CLI
+//   - anything else: the Claude CLI
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Completer returns the model's answer to a single prompt.
+type Completer interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// ErrNoBackend is returned by New for models without a backend ("" and the
+// "test" model).
+var ErrNoBackend = errors.New("no completion backend")
+
+// Options configures New.
+type Options struct {
+	// BaseURL is the base URL of the OpenAI-compatible API (e.g.
+	// http://localhost:11434/v1). Defaults to $OPENAI_BASE_URL, then
+	// https://api.openai.com/v1.
+	BaseURL string
+}
+
+const (
+	anthropicEndpoint   = "https://api.anthropic.com/v1/messages"
+	anthropicAPIVersion = "2023-06-01"
+	geminiEndpointFmt   = "https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent"
+	openAIBaseURL       = "https://api.openai.com/v1"
+	maxTokens           = 1024
+	httpTimeout         = 60 * time.Second
+)
+
+// New returns the Completer for model.
+func New(model string, opts Options) (Completer, error) {
+	switch {
+	case model == "" || model == "test":
+		return nil, fmt.Errorf("model %q: %w", model, ErrNoBackend)
+	case strings.HasPrefix(model, "anthropic/"):
+		return &anthropicClient{model: strings.TrimPrefix(model, "anthropic/"), url: anthropicEndpoint}, nil
+	case strings.HasPrefix(model, "gemini-"):
+		return &geminiClient{model: model, url: fmt.Sprintf(geminiEndpointFmt, model)}, nil
+	case strings.HasPrefix(model, "openai/"):
+		base := opts.BaseURL
+		if base == "" {
+			base = os.Getenv("OPENAI_BASE_URL")
+		}
+		if base == "" {
+			base = openAIBaseURL
+		}
+		return &openAIClient{model: strings.TrimPrefix(model, "openai/"), url: strings.TrimSuffix(base, "/") + "/chat/completions"}, nil
+	case strings.HasPrefix(model, "copilot/"):
+		return &cliClient{name: "copilot", args: []string{"--no-color", "--model", strings.TrimPrefix(model, "copilot/"), "-p"}}, nil
+	default:
+		return &cliClient{name: "claude", args: []string{"--print", "--output-format", "text", "--model", model}}, nil
+	}
+}
+
+// DefaultModel returns the utility model for a project whose agents use
+// agentModel: a small model of the same backend.
+func DefaultModel(agentModel string) string {
+	switch {
+	case agentModel == "test":
+		return "test"
+	case strings.HasPrefix(agentModel, "anthropic/"):
+		return "anthropic/claude-haiku-4-5"
+	case strings.HasPrefix(agentModel, "gemini-"):
+		return "gemini-2.5-flash"
+	case strings.HasPrefix(agentModel, "copilot/"), strings.HasPrefix(agentModel, "openai/"):
+		return agentModel
+	default:
+		return "claude-haiku-4-5"
+	}
+}
+
+// cliClient runs a CLI in print mode with the prompt as its last argument.
+type cliClient struct {
+	name string
+	args []string
+}
+
+func (c *cliClient) Complete(ctx context.Context, prompt string) (string, error) {
+	cmd := exec.CommandContext(ctx, c.name, append(c.args, prompt)...)
+	// Allow nested claude invocations, as for the agents.
+	var env []string
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, "CLAUDECODE=") && !strings.HasPrefix(e, "CLAUDE_CODE_ENTRYPOINT=") {
+			env = append(env, e)
+		}
+	}
+	cmd.Env = env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("%s failed: %w\nstderr: %s", c.name, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// anthropicClient calls the Anthropic Messages API.
+type anthropicClient struct {
+	model string
+	url   string
+}
+
+func (c *anthropicClient) Complete(ctx context.Context, prompt string) (string, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return "", fmt.Errorf("ANTHROPIC_API_KEY not set")
+	}
+	req := map[string]any{
+		"model":      c.model,
+		"max_tokens": maxTokens,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+	}
+	var resp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Error *struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+	headers := map[string]string{"x-api-key": apiKey, "anthropic-version": anthropicAPIVersion}
+	if err := postJSON(ctx, c.url, headers, req, &resp); err != nil {
+		return "", fmt.Errorf("anthropic API: %w", err)
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("anthropic API error (%s): %s", resp.Error.Type, resp.Error.Message)
+	}
+	for _, block := range resp.Content {
+		if block.Type == "text" && block.Text != "" {
+			return block.Text, nil
+		}
+	}
+	return "", fmt.Errorf("no text content in anthropic response")
+}
+
+// geminiClient calls the Gemini generateContent API.
+type geminiClient struct {
+	model string
+	url   string
+}
+
+func (c *geminiClient) Complete(ctx context.Context, prompt string) (string, error) {
+	apiKey := os.Getenv("GOOGLE_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if apiKey == "" {
+		return "", fmt.Errorf("GOOGLE_API_KEY (or GEMINI_API_KEY) is not set")
+	}
+	req := map[string]any{
+		"contents": []map[string]any{{"role": "user", "parts": []map[string]string{{"text": prompt}}}},
+	}
+	var resp struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+	if err := postJSON(ctx, c.url, map[string]string{"x-goog-api-key": apiKey}, req, &resp); err != nil {
+		return "", fmt.Errorf("gemini API: %w", err)
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("gemini API error: %s", resp.Error.Message)
+	}
+	if len(resp.Candidates) == 0 {
+		return "", fmt.Errorf("gemini API returned no candidates")
+	}
+	var sb strings.Builder
+	for _, p := range resp.Candidates[0].Content.Parts {
+		sb.WriteString(p.Text)
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("no text content in gemini response")
+	}
+	return sb.String(), nil
+}
+
+// openAIClient calls an OpenAI-compatible chat completions API.
+type openAIClient struct {
+	model string
+	url   string
+}
+
+func (c *openAIClient) Complete(ctx context.Context, prompt string) (string, error) {
+	req := map[string]any{
+		"model":      c.model,
+		"max_tokens": maxTokens,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error,omitempty"`
+	}
+	headers := map[string]string{}
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		headers["Authorization"] = "Bearer " + key
+	}
+	if err := postJSON(ctx, c.url, headers, req, &resp); err != nil {
+		return "", fmt.Errorf("openai API: %w", err)
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("openai API error: %s", resp.Error.Message)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no text content in openai response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// postJSON posts body as JSON to url and decodes the JSON response into out.
+func postJSON(ctx context.Context, url string, headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: httpTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"claude-haiku-4-5", "claude"},
+		{"haiku", "claude"},
+		{"copilot/gpt-5-mini", "copilot"},
+		{"anthropic/claude-haiku-4-5", "anthropic"},
+		{"gemini-2.5-flash", "gemini"},
+		{"openai/gpt-4o-mini", "openai"},
+	}
+	for _, tt := range tests {
+		c, err := New(tt.model, Options{})
+		if err != nil {
+			t.Fatalf("New(%q): %v", tt.model, err)
+		}
+		var got string
+		switch c := c.(type) {
+		case *cliClient:
+			got = c.name
+		case *anthropicClient:
+			got = "anthropic"
+		case *geminiClient:
+			got = "gemini"
+		case *openAIClient:
+			got = "openai"
+		}
+		if got != tt.want {
+			t.Errorf("New(%q) = %T, want the %s backend", tt.model, c, tt.want)
+		}
+	}
+
+	for _, model := range []string{"", "test"} {
+		if _, err := New(model, Options{}); !errors.Is(err, ErrNoBackend) {
+			t.Errorf("New(%q) error = %v, want ErrNoBackend", model, err)
+		}
+	}
+
+	c, _ := New("openai/llama3", Options{BaseURL: "http://localhost:11434/v1/"})
+	if url := c.(*openAIClient).url; url != "http://localhost:11434/v1/chat/completions" {
+		t.Errorf("openai url = %q", url)
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	tests := map[string]string{
+		"claude-opus-4-6":           "claude-haiku-4-5",
+		"sonnet":                    "claude-haiku-4-5",
+		"anthropic/claude-opus-4-6": "anthropic/claude-haiku-4-5",
+		"gemini-2.5-pro":            "gemini-2.5-flash",
+		"copilot/gpt-5":             "copilot/gpt-5",
+		"test":                      "test",
+	}
+	for agentModel, want := range tests {
+		if got := DefaultModel(agentModel); got != want {
+			t.Errorf("DefaultModel(%q) = %q, want %q", agentModel, got, want)
+		}
+	}
+}
+
+// serve returns a server that checks the request header and answers with
+// the JSON response.
+func serve(t *testing.T, header, value string, response any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAPIClients(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "ant-key")
+	t.Setenv("GOOGLE_API_KEY", "google-key")
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	ctx := context.Background()
+
+	srv := serve(t, "x-api-key", "ant-key", map[string]any{
+		"content": []map[string]string{{"type": "text", "text": "from anthropic"}},
+	})
+	if got, err := (&anthropicClient{model: "claude-haiku-4-5", url: srv.URL}).Complete(ctx, "hi"); err != nil || got != "from anthropic" {
+		t.Errorf("anthropic: %q, %v", got, err)
+	}
+
+	srv = serve(t, "x-goog-api-key", "google-key", map[string]any{
+		"candidates": []map[string]any{{"content": map[string]any{"parts": []map[string]string{{"text": "from gemini"}}}}},
+	})
+	if got, err := (&geminiClient{model: "gemini-2.5-flash", url: srv.URL}).Complete(ctx, "hi"); err != nil || got != "from gemini" {
+		t.Errorf("gemini: %q, %v", got, err)
+	}
+
+	srv = serve(t, "Authorization", "Bearer openai-key", map[string]any{
+		"choices": []map[string]any{{"message": map[string]string{"content": "from openai"}}},
+	})
+	if got, err := (&openAIClient{model: "gpt-4o-mini", url: srv.URL}).Complete(ctx, "hi"); err != nil || got != "from openai" {
+		t.Errorf("openai: %q, %v", got, err)
+	}
+
+	srv = serve(t, "x-api-key", "ant-key", map[string]any{
+		"error": map[string]string{"type": "overloaded_error", "message": "busy"},
+	})
+	if _, err := (&anthropicClient{model: "claude-haiku-4-5", url: srv.URL}).Complete(ctx, "hi"); err == nil {
+		t.Error("anthropic: expected an error for an error response")
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := (&anthropicClient{model: "claude-haiku-4-5", url: srv.URL}).Complete(ctx, "hi"); err == nil {
+		t.Error("anthropic: expected an error without ANTHROPIC_API_KEY")
+	}
+}
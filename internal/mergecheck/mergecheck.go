@@ -0,0 +1,243 @@
+// Package mergecheck merges the feature branches of concurrent teams together
+// on top of develop in a scratch worktree and runs the project's tests there,
+// to find branches that pass on their own but break develop when merged
+// together.
+package mergecheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/shell"
+)
+
+// Dir is the scratch worktree under a repository's .worktrees/. The
+// leading dot keeps it apart from team worktrees.
+const Dir = ".integration"
+
+// maxOutputLines is how much of the end of the test output a Result keeps.
+const maxOutputLines = 80
+
+// Branch is a team's feature branch.
+type Branch struct {
+	Name    string
+	IssueID string
+	Team    int
+}
+
+// Conflict is a pair of branches that cannot be merged together. B is nil
+// when A conflicts with the base itself.
+type Conflict struct {
+	A, B  *Branch
+	Files []string
+}
+
+// Label names the issues of c, e.g. "gh-1 × gh-2", or "gh-1 × base" for a
+// conflict with the base.
+func (c Conflict) Label() string {
+	if c.B == nil {
+		return c.A.IssueID + " × base"
+	}
+	return c.A.IssueID + " × " + c.B.IssueID
+}
+
+// Options configure a check of one repository.
+type Options struct {
+	// Base is the revision the branches are merged onto, e.g. origin/develop.
+	Base     string
+	Branches []Branch
+	// TestCommand is run in the merged tree. Empty skips the tests.
+	TestCommand string
+	Timeout     time.Duration
+	// Command builds the test command, e.g. in a sandbox. nil uses
+	// shell.Command.
+	Command shell.CommandFunc
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Base string
+	// Merged are the branches in the merged tree that was tested: all
+	// branches except those that conflict with the base or with a branch
+	// merged before them.
+	Merged    []Branch
+	Conflicts []Conflict
+	// Tested is set when the test command ran; TestErr is its error and
+	// Output the end of its output.
+	Tested  bool
+	TestErr error
+	Output  string
+}
+
+// OK reports whether all branches merged and the tests, if any, passed.
+func (r *Result) OK() bool {
+	return len(r.Conflicts) == 0 && r.TestErr == nil
+}
+
+// Run checks the branches of repo. Branches are merged in order; each pair
+// is tried on its own so that every conflicting pair is found.
+func Run(ctx context.Context, repo *git.Repo, opts Options) (*Result, error) {
+	path := filepath.Join(repo.Path(), ".worktrees", Dir)
+	if _, err := os.Stat(path); err == nil {
+		// Left over from an interrupted check.
+		if err := repo.RemoveWorktree(path); err != nil {
+			repo.PruneWorktrees()
+			os.RemoveAll(path)
+		}
+	}
+	if err := repo.AddDetachedWorktree(path, opts.Base); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := repo.RemoveWorktree(path); err != nil {
+			repo.PruneWorktrees()
+			os.RemoveAll(path)
+		}
+	}()
+	wt := git.NewRepo(path)
+	res := &Result{Base: opts.Base}
+
+	// merge merges branches onto the base and returns the conflicted files
+	// of the first that does not merge cleanly, and its index.
+	merge := func(branches ...string) (int, []string, error) {
+		if err := wt.ResetHard(opts.Base); err != nil {
+			return 0, nil, err
+		}
+		for i, b := range branches {
+			report, err := wt.UpdateFromBase(b, false)
+			if err != nil {
+				return 0, nil, err
+			}
+			if report != nil {
+				files := make([]string, len(report.Files))
+				for j, f := range report.Files {
+					files[j] = f.Path
+				}
+				return i, files, nil
+			}
+		}
+		return -1, nil, nil
+	}
+
+	var clean []int
+	for i := range opts.Branches {
+		at, files, err := merge(opts.Branches[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		if at >= 0 {
+			res.Conflicts = append(res.Conflicts, Conflict{A: &opts.Branches[i], Files: files})
+			continue
+		}
+		clean = append(clean, i)
+	}
+	for x, i := range clean {
+		for _, j := range clean[x+1:] {
+			at, files, err := merge(opts.Branches[i].Name, opts.Branches[j].Name)
+			if err != nil {
+				return nil, err
+			}
+			if at >= 0 {
+				res.Conflicts = append(res.Conflicts, Conflict{A: &opts.Branches[i], B: &opts.Branches[j], Files: files})
+			}
+		}
+	}
+
+	// Merge everything that merges, in order.
+	if err := wt.ResetHard(opts.Base); err != nil {
+		return nil, err
+	}
+	for _, i := range clean {
+		report, err := wt.UpdateFromBase(opts.Branches[i].Name, false)
+		if err != nil {
+			return nil, err
+		}
+		if report == nil {
+			res.Merged = append(res.Merged, opts.Branches[i])
+		}
+	}
+
+	if opts.TestCommand != "" && len(res.Merged) > 0 {
+		tctx := ctx
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			tctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+		command := opts.Command
+		if command == nil {
+			command = shell.Command
+		}
+		out, err := command(tctx, path, opts.TestCommand).CombinedOutput()
+		if err != nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", opts.Timeout)
+		}
+		res.Tested, res.TestErr, res.Output = true, err, tail(string(out), maxOutputLines)
+	}
+	return res, ctx.Err()
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = append([]string{fmt.Sprintf("... (%d lines omitted)", len(lines)-n)}, lines[len(lines)-n:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Signature identifies the outcome, so that an unchanged outcome is not
+// reported again: the merged branches, the conflicts and whether the tests
+// failed.
+func (r *Result) Signature() string {
+	var parts []string
+	for _, b := range r.Merged {
+		parts = append(parts, "+"+b.Name)
+	}
+	for _, c := range r.Conflicts {
+		s := "x" + c.A.Name
+		if c.B != nil {
+			s += "/" + c.B.Name
+		}
+		parts = append(parts, s)
+	}
+	if r.TestErr != nil {
+		parts = append(parts, "fail")
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, " ")
+}
+
+// Markdown renders the result as a report.
+func (r *Result) Markdown(repoName string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Integration check: %s\n\nBase: %s\n\n", repoName, r.Base)
+	b.WriteString("## Merged branches\n\n")
+	if len(r.Merged) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, br := range r.Merged {
+		fmt.Fprintf(&b, "- %s (team %d, %s)\n", br.Name, br.Team, br.IssueID)
+	}
+	if len(r.Conflicts) > 0 {
+		b.WriteString("\n## Conflicts\n\n")
+		for _, c := range r.Conflicts {
+			fmt.Fprintf(&b, "- %s: %s\n", c.Label(), strings.Join(c.Files, ", "))
+		}
+	}
+	if r.Tested {
+		status := "passed"
+		if r.TestErr != nil {
+			status = "FAILED: " + r.TestErr.Error()
+		}
+		fmt.Fprintf(&b, "\n## Tests\n\n%s\n\n```\n%s\n```\n", status, r.Output)
+	}
+	return b.String()
+}
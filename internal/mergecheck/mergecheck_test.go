@@ -0,0 +1,113 @@
+package mergecheck
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// branchRepo returns a repository with develop and one branch per entry of
+// files, each writing the given file contents on top of develop.
+func branchRepo(t *testing.T, files map[string]map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "develop")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	os.WriteFile(filepath.Join(dir, "shared.txt"), []byte("base\n"), 0644)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "initial commit")
+	for branch, contents := range files {
+		runGit(t, dir, "checkout", "-q", "-b", branch, "develop")
+		for name, content := range contents {
+			os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		}
+		runGit(t, dir, "add", ".")
+		runGit(t, dir, "commit", "-m", branch)
+	}
+	runGit(t, dir, "checkout", "-q", "develop")
+	return dir
+}
+
+func TestRunFindsPairwiseConflicts(t *testing.T) {
+	dir := branchRepo(t, map[string]map[string]string{
+		"f/a": {"shared.txt": "from a\n"},
+		"f/b": {"shared.txt": "from b\n"},
+		"f/c": {"c.txt": "c\n"},
+	})
+	res, err := Run(t.Context(), git.NewRepo(dir), Options{
+		Base:        "develop",
+		Branches:    []Branch{{Name: "f/a", IssueID: "a", Team: 1}, {Name: "f/b", IssueID: "b", Team: 2}, {Name: "f/c", IssueID: "c", Team: 3}},
+		TestCommand: "test -f c.txt && test -f shared.txt",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Label() != "a × b" || strings.Join(res.Conflicts[0].Files, ",") != "shared.txt" {
+		t.Fatalf("conflicts = %+v", res.Conflicts)
+	}
+	var merged []string
+	for _, b := range res.Merged {
+		merged = append(merged, b.Name)
+	}
+	if strings.Join(merged, ",") != "f/a,f/c" {
+		t.Errorf("merged = %v, want f/a,f/c", merged)
+	}
+	if !res.Tested || res.TestErr != nil {
+		t.Errorf("tests: tested=%v err=%v output=%s", res.Tested, res.TestErr, res.Output)
+	}
+	if res.OK() {
+		t.Error("a result with conflicts is not OK")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".worktrees", Dir)); !os.IsNotExist(err) {
+		t.Error("scratch worktree should be removed")
+	}
+}
+
+func TestRunReportsTestFailure(t *testing.T) {
+	// Each branch alone passes "at most one of a.txt, b.txt"; merged they fail.
+	dir := branchRepo(t, map[string]map[string]string{
+		"f/a": {"a.txt": "a\n"},
+		"f/b": {"b.txt": "b\n"},
+	})
+	res, err := Run(t.Context(), git.NewRepo(dir), Options{
+		Base:        "develop",
+		Branches:    []Branch{{Name: "f/a", IssueID: "a"}, {Name: "f/b", IssueID: "b"}},
+		TestCommand: "echo checking; ! { test -f a.txt && test -f b.txt; }",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 0 || len(res.Merged) != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	if res.TestErr == nil || !strings.Contains(res.Output, "checking") || res.OK() {
+		t.Errorf("want a test failure, got err=%v output=%q", res.TestErr, res.Output)
+	}
+	md := res.Markdown("app")
+	if !strings.Contains(md, "FAILED") || !strings.Contains(md, "- f/b") {
+		t.Errorf("report:\n%s", md)
+	}
+	if sig := res.Signature(); sig != "+f/a +f/b fail" {
+		t.Errorf("Signature = %q", sig)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("1\n2\n3\n", 2); got != "... (1 lines omitted)\n2\n3" {
+		t.Errorf("tail = %q", got)
+	}
+}
@@ -0,0 +1,165 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+// ConflictsDir is the directory inside the data directory that holds the
+// conflict reports of feature branches that could not be updated.
+const ConflictsDir = "conflicts"
+
+// runBranchUpdate periodically brings the feature branches of active teams
+// up to date with develop, so that conflicts are found and resolved by the
+// owning team before review rather than in the PR.
+func (o *Orchestrator) runBranchUpdate(ctx context.Context, cfg *config.Config) {
+	interval := time.Duration(cfg.Branches.UpdateIntervalMinutes) * time.Minute
+	log.Printf("[branch-update] started (interval: %v, strategy: %s)", interval, cfg.Branches.UpdateStrategy)
+
+	// reported remembers, per worktree, the branch and develop commits of
+	// the last conflict reported, so that it is not reported again.
+	reported := make(map[string]string)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[branch-update] stopped")
+			return
+		case <-ticker.C:
+			o.updateTeamBranches(cfg, reported)
+		}
+	}
+}
+
+// updateTeamBranches updates the feature branch of every active, unpaused
+// team in each of its repositories.
+func (o *Orchestrator) updateTeamBranches(cfg *config.Config, reported map[string]string) {
+	bases := make(map[string]string) // repo name -> develop revision
+	for _, info := range o.teams.List() {
+		if info.IssueID == "" || info.Paused {
+			continue
+		}
+		eng, ok := o.teams.Engineer(info.ID)
+		if !ok {
+			continue
+		}
+		for _, r := range o.teamRepos(cfg, info.IssueID) {
+			base, ok := bases[r.Name]
+			if !ok {
+				repo, found := o.repos[r.Name]
+				if !found {
+					repo = git.NewRepo(r.Path)
+				}
+				var err error
+				if base, err = latestDevelop(repo, cfg.Branches); err != nil {
+					log.Printf("[branch-update] %s: %v", r.Name, err)
+				}
+				bases[r.Name] = base
+			}
+			if base == "" {
+				continue
+			}
+			o.updateTeamBranch(cfg, eng, info.ID, info.IssueID, r, base, reported)
+		}
+	}
+}
+
+// updateTeamBranch merges base into the team's feature branch, or rebases
+// the branch onto it, in the team's worktree of repo. Nothing is done while
+// the engineer is in a turn, has uncommitted changes or has checked out
+// another branch. The engineer is told about the update, or is sent a
+// conflict report when it failed; the branch is left as it was then.
+func (o *Orchestrator) updateTeamBranch(cfg *config.Config, eng *agent.Agent, teamNum int, issueID string, repo config.RepoConfig, base string, reported map[string]string) {
+	path := teamWorktreePath(repo.Path, cfg.GhLogin, teamNum, issueID)
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	wt := git.NewRepo(path)
+	branch := cfg.Branches.FeaturePrefix + issueID
+	if cur, err := wt.CurrentBranch(); err != nil || cur != branch {
+		return
+	}
+	behind, err := wt.CountCommits("HEAD", base)
+	if err != nil || behind == 0 {
+		return
+	}
+	head, _ := wt.RevParse("HEAD")
+	baseRev, _ := wt.RevParse(base)
+	if reported[path] == head+".."+baseRev {
+		return
+	}
+	if clean, err := wt.IsClean(); err != nil || !clean {
+		return
+	}
+
+	rebase := cfg.Branches.UpdateStrategy == "rebase"
+	var report *git.ConflictReport
+	if !eng.WhileIdle(func() { report, err = wt.UpdateFromBase(base, rebase) }) {
+		return
+	}
+	engineerID := agent.AgentID{Role: agent.RoleEngineer, TeamNum: teamNum}.String()
+	switch {
+	case err != nil:
+		log.Printf("[branch-update] team %d: %s in %s: %v", teamNum, branch, repo.Name, err)
+	case report != nil:
+		reported[path] = head + ".." + baseRev
+		reportPath, werr := o.writeConflictReport(issueID, repo.Name, report.Markdown(branch))
+		if werr != nil {
+			log.Printf("[branch-update] team %d: %v", teamNum, werr)
+		}
+		log.Printf("[branch-update] team %d: %s in %s conflicts with %s: %s", teamNum, branch, repo.Name, base, report.Summary())
+		o.appendOrLog(engineerID, "orchestrator", fmt.Sprintf(
+			"[%s] %s は %s とコンフリクトするため自動 %s を中止しました (ブランチは変更していません)。コンフリクト: %s。詳細: %s。レビュー依頼の前に %s を取り込み、コンフリクトを解消してください。",
+			repo.Name, branch, base, report.Op, report.Summary(), reportPath, base))
+	default:
+		delete(reported, path)
+		pushed := ""
+		if wt.BranchExists("origin/" + branch) {
+			// --no-verify: the team's guard hooks refuse force pushes, and
+			// this update is not the engineer's change.
+			args := []string{"--no-verify", branch}
+			if rebase {
+				args = []string{"--no-verify", "--force-with-lease", branch}
+			}
+			if perr := wt.Push("origin", args...); perr != nil {
+				log.Printf("[branch-update] team %d: push %s in %s: %v", teamNum, branch, repo.Name, perr)
+				pushed = " push に失敗したので、手動で push してください。"
+			} else {
+				pushed = " origin に push しました。"
+			}
+		}
+		op := "マージ"
+		if rebase {
+			op = "リベース"
+		}
+		log.Printf("[branch-update] team %d: updated %s in %s (%d commit(s) from %s)", teamNum, branch, repo.Name, behind, base)
+		o.appendOrLog(engineerID, "orchestrator", fmt.Sprintf(
+			"[%s] %s の %d コミットを %s に%sしました。%s作業を続ける前に git log で確認してください。",
+			repo.Name, base, behind, branch, op, pushed))
+	}
+}
+
+// writeConflictReport saves a conflict report in the data directory and
+// returns its path.
+func (o *Orchestrator) writeConflictReport(issueID, repoName, report string) (string, error) {
+	dir := filepath.Join(o.dataDir, ConflictsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("write conflict report: %w", err)
+	}
+	path := filepath.Join(dir, issueID+"-"+repoName+".md")
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		return "", fmt.Errorf("write conflict report: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,113 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBranchUpdateTestOrchestrator returns an orchestrator with a team
+// working on an issue of "app", whose feature branch changed a.txt.
+func newBranchUpdateTestOrchestrator(t *testing.T) (*Orchestrator, string, string) {
+	t.Helper()
+	orc, iss := newRoutingTestOrchestrator(t)
+	iss.Repos = []string{"app"}
+	orc.Store().Update(iss)
+	app := orc.Config().Project.Repos[0].Path
+	runGit(t, app, "branch", "develop")
+
+	tm, err := orc.teams.Create(t.Context(), iss.ID, iss.Title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt := teamWorktreePath(app, "alice", tm.ID, iss.ID)
+	os.WriteFile(filepath.Join(wt, "a.txt"), []byte("feature\n"), 0644)
+	runGit(t, wt, "add", "a.txt")
+	runGit(t, wt, "commit", "-m", "feature change")
+	return orc, app, wt
+}
+
+// commitOnDevelop commits file with content on develop in the main worktree.
+func commitOnDevelop(t *testing.T, repo, file, content string) {
+	t.Helper()
+	runGit(t, repo, "checkout", "develop")
+	os.WriteFile(filepath.Join(repo, file), []byte(content), 0644)
+	runGit(t, repo, "add", file)
+	runGit(t, repo, "commit", "-m", "develop change")
+	runGit(t, repo, "checkout", "main")
+}
+
+// updateUntil runs branch updates until the chatlog contains marker.
+func updateUntil(t *testing.T, orc *Orchestrator, reported map[string]string, marker string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		orc.updateTeamBranches(orc.Config(), reported)
+		log := readChatlog(t, orc.dataDir)
+		if strings.Contains(log, marker) {
+			return log
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %q message:\n%s", marker, log)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestBranchUpdateMergesDevelop(t *testing.T) {
+	for _, strategy := range []string{"merge", "rebase"} {
+		t.Run(strategy, func(t *testing.T) {
+			orc, app, wt := newBranchUpdateTestOrchestrator(t)
+			orc.cfg.Branches.UpdateStrategy = strategy
+			commitOnDevelop(t, app, "b.txt", "develop\n")
+
+			log := updateUntil(t, orc, map[string]string{}, "[app] develop の 1 コミット")
+			if !strings.Contains(log, "@engineer-1") {
+				t.Errorf("the message should go to the team's engineer:\n%s", log)
+			}
+			if n := runGit(t, wt, "rev-list", "--count", "HEAD..develop"); n != "0" {
+				t.Errorf("branch still %s commit(s) behind develop", n)
+			}
+			if _, err := os.Stat(filepath.Join(wt, "b.txt")); err != nil {
+				t.Error("develop's change is missing from the worktree")
+			}
+		})
+	}
+}
+
+func TestBranchUpdateReportsConflict(t *testing.T) {
+	orc, app, wt := newBranchUpdateTestOrchestrator(t)
+	commitOnDevelop(t, app, "a.txt", "develop\n")
+	head := runGit(t, wt, "rev-parse", "HEAD")
+
+	reported := map[string]string{}
+	log := updateUntil(t, orc, reported, "コンフリクト: a.txt (1: L1)")
+	if runGit(t, wt, "rev-parse", "HEAD") != head || runGit(t, wt, "status", "--porcelain") != "" {
+		t.Error("the branch should be left as it was")
+	}
+	report, err := os.ReadFile(filepath.Join(orc.dataDir, ConflictsDir, "local-001-app.md"))
+	if err != nil || !strings.Contains(string(report), "## a.txt") || !strings.Contains(string(report), "develop") {
+		t.Errorf("conflict report = %q, %v", report, err)
+	}
+
+	// The same conflict is not reported again.
+	orc.updateTeamBranches(orc.Config(), reported)
+	if again := readChatlog(t, orc.dataDir); strings.Count(again, "コンフリクト:") != strings.Count(log, "コンフリクト:") {
+		t.Errorf("conflict reported twice:\n%s", again)
+	}
+}
+
+func TestBranchUpdateSkipsDirtyWorktree(t *testing.T) {
+	orc, app, wt := newBranchUpdateTestOrchestrator(t)
+	commitOnDevelop(t, app, "b.txt", "develop\n")
+	os.WriteFile(filepath.Join(wt, "wip.txt"), []byte("wip\n"), 0644)
+	head := runGit(t, wt, "rev-parse", "HEAD")
+
+	time.Sleep(200 * time.Millisecond) // let the engineer finish its first turn
+	orc.updateTeamBranches(orc.Config(), map[string]string{})
+	if runGit(t, wt, "rev-parse", "HEAD") != head {
+		t.Error("a worktree with uncommitted changes should not be updated")
+	}
+}
@@ -0,0 +1,280 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ytnobody/madflow/internal/chatlog"
+)
+
+// Command is one orchestrator command line:
+//
+//	NAME [ARG ...] [--key=value | --flag ...]
+//
+// e.g. `TEAM_CREATE gh-12 --id=req-7`. Tokens are separated by whitespace;
+// double quotes group a token containing spaces (`--reason="needs review"`).
+// A chatlog message may contain several commands, one per line.
+type Command struct {
+	Name    string
+	Args    []string
+	Options map[string]string
+	// ID correlates the ACK/NACK replies with the request. It is the --id
+	// option when given, otherwise one assigned by the orchestrator.
+	ID string
+	// Sender is the agent that sent the command; replies are addressed to it.
+	Sender string
+	// Line is the original command line.
+	Line string
+
+	// replied is closed once the handler's ACK or NACK has been sent.
+	// Handlers that continue in the background wait on it before sending
+	// their DONE (or failure NACK) reply.
+	replied chan struct{}
+}
+
+// Arg returns positional argument i, or "" if it is absent.
+func (c Command) Arg(i int) string {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
+
+// Option returns the value of --key, or "" if it is absent.
+func (c Command) Option(key string) string {
+	return c.Options[key]
+}
+
+// Reply statuses. ACK: the command was accepted (or completed synchronously).
+// NACK: the command was rejected or failed. DONE: an accepted command that
+// runs in the background has completed.
+const (
+	replyACK  = "ACK"
+	replyNACK = "NACK"
+	replyDONE = "DONE"
+)
+
+// commandHandler executes cmd. The returned text is sent in the ACK reply
+// (the command name is used when it is empty); an error is sent as a NACK.
+type commandHandler func(o *Orchestrator, ctx context.Context, cmd Command) (string, error)
+
+// commandSpec describes a registered command.
+type commandSpec struct {
+	name    string
+	usage   string
+	summary string
+	minArgs int
+	// options lists the accepted --options besides --id.
+	options []string
+	handle  commandHandler
+}
+
+// commandRegistry holds the commands understood by the orchestrator, by name.
+var commandRegistry = make(map[string]*commandSpec)
+
+// registerCommand adds spec to the registry. Commands register themselves
+// from init functions next to their handlers.
+func registerCommand(spec commandSpec) {
+	if _, dup := commandRegistry[spec.name]; dup {
+		panic("orchestrator: duplicate command " + spec.name)
+	}
+	commandRegistry[spec.name] = &spec
+}
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "TEAM_CREATE",
+		usage:   "TEAM_CREATE <issue-id>",
+		summary: "form a team for an open issue",
+		minArgs: 1,
+		handle:  (*Orchestrator).handleTeamCreate,
+	})
+	registerCommand(commandSpec{
+		name:    "TEAM_DISBAND",
+		usage:   "TEAM_DISBAND <issue-id>",
+		summary: "disband the team working on an issue and clean its worktrees",
+		minArgs: 1,
+		handle:  (*Orchestrator).handleTeamDisband,
+	})
+	registerCommand(commandSpec{
+		name:    "WAKE_GITHUB",
+		usage:   "WAKE_GITHUB",
+		summary: "resume GitHub polling after dormancy",
+		handle:  (*Orchestrator).handleWakeGitHub,
+	})
+	registerCommand(commandSpec{
+		name:    "PATROL_COMPLETE",
+		usage:   "PATROL_COMPLETE",
+		summary: "report that the issue patrol is done (resets the patrol timer)",
+		handle:  (*Orchestrator).handlePatrolComplete,
+	})
+	registerCommand(commandSpec{
+		name:    "HELP",
+		usage:   "HELP [command]",
+		summary: "list the commands, or describe one",
+		handle:  (*Orchestrator).handleHelp,
+	})
+}
+
+// commandLikeRe matches words that look like a command name (e.g. FOO_BAR)
+// so that misspelled or unsupported commands are answered with a NACK.
+// Other text sent to the orchestrator is ignored.
+var commandLikeRe = regexp.MustCompile(`^[A-Z][A-Z0-9]*_[A-Z0-9_]+$`)
+
+// parseCommands extracts the command lines from a chatlog message body.
+// A line is a command when its first word is a registered command name or
+// looks like one (see commandLikeRe).
+func parseCommands(body string) []Command {
+	var cmds []Command
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		tokens := tokenizeCommand(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		name := tokens[0]
+		if _, ok := commandRegistry[name]; !ok && !commandLikeRe.MatchString(name) {
+			continue
+		}
+		cmd := Command{Name: name, Options: make(map[string]string), Line: line}
+		for _, tok := range tokens[1:] {
+			if key, ok := strings.CutPrefix(tok, "--"); ok && key != "" {
+				k, v, hasValue := strings.Cut(key, "=")
+				if !hasValue {
+					v = "true"
+				}
+				cmd.Options[k] = v
+				continue
+			}
+			cmd.Args = append(cmd.Args, tok)
+		}
+		cmd.ID = cmd.Options["id"]
+		delete(cmd.Options, "id")
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// tokenizeCommand splits line at whitespace. Double quotes group characters
+// (including whitespace) into one token and are removed; an unterminated
+// quote extends to the end of the line.
+func tokenizeCommand(line string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inToken, quoted := false, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inToken = true
+		case !quoted && (r == ' ' || r == '\t'):
+			if inToken {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inToken = false
+			}
+		default:
+			cur.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// commandSeq numbers commands that arrive without an --id.
+var commandSeq atomic.Int64
+
+// handleCommand processes the orchestrator commands in a chatlog message.
+// Every command is answered with an ACK or NACK addressed to the sender.
+func (o *Orchestrator) handleCommand(ctx context.Context, msg chatlog.Message) {
+	cmds := parseCommands(msg.Body)
+	if len(cmds) == 0 {
+		log.Printf("[orchestrator] message from %s contains no command: %s", msg.Sender, strings.TrimSpace(msg.Body))
+		return
+	}
+	for _, cmd := range cmds {
+		cmd.Sender = msg.Sender
+		if cmd.Sender == "" {
+			cmd.Sender = "superintendent"
+		}
+		if cmd.ID == "" {
+			cmd.ID = fmt.Sprintf("cmd-%d", commandSeq.Add(1))
+		}
+		o.dispatchCommand(ctx, cmd)
+	}
+}
+
+// dispatchCommand validates cmd against its spec, runs the handler and sends
+// the reply.
+func (o *Orchestrator) dispatchCommand(ctx context.Context, cmd Command) {
+	cmd.replied = make(chan struct{})
+	defer close(cmd.replied)
+
+	spec, ok := commandRegistry[cmd.Name]
+	if !ok {
+		log.Printf("[orchestrator] unknown command from %s: %s", cmd.Sender, cmd.Line)
+		o.replyCommand(cmd, replyNACK, fmt.Sprintf("%s: unknown command (send HELP for the list)", cmd.Name))
+		return
+	}
+	if len(cmd.Args) < spec.minArgs {
+		log.Printf("[orchestrator] %s: missing arguments: %s", cmd.Name, cmd.Line)
+		o.replyCommand(cmd, replyNACK, fmt.Sprintf("%s: missing arguments (usage: %s)", cmd.Name, spec.usage))
+		return
+	}
+	for k := range cmd.Options {
+		if !slices.Contains(spec.options, k) {
+			o.replyCommand(cmd, replyNACK, fmt.Sprintf("%s: unknown option --%s (usage: %s)", cmd.Name, k, spec.usage))
+			return
+		}
+	}
+
+	text, err := spec.handle(o, ctx, cmd)
+	if err != nil {
+		o.replyCommand(cmd, replyNACK, err.Error())
+		return
+	}
+	if text == "" {
+		text = cmd.Name
+	}
+	o.replyCommand(cmd, replyACK, text)
+}
+
+// replyCommand sends "<status> id=<id> <text>" to the sender of cmd.
+func (o *Orchestrator) replyCommand(cmd Command, status, text string) {
+	o.appendOrLog(cmd.Sender, "orchestrator", fmt.Sprintf("%s id=%s %s", status, cmd.ID, text))
+}
+
+// handleHelp lists the registered commands, or describes one.
+func (o *Orchestrator) handleHelp(_ context.Context, cmd Command) (string, error) {
+	if name := cmd.Arg(0); name != "" {
+		spec, ok := commandRegistry[strings.ToUpper(name)]
+		if !ok {
+			return "", fmt.Errorf("HELP: unknown command %s", name)
+		}
+		text := fmt.Sprintf("%s — %s", spec.usage, spec.summary)
+		if len(spec.options) > 0 {
+			text += fmt.Sprintf(" (options: --%s)", strings.Join(spec.options, ", --"))
+		}
+		return text, nil
+	}
+	names := make([]string, 0, len(commandRegistry))
+	for name := range commandRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		spec := commandRegistry[name]
+		parts = append(parts, fmt.Sprintf("%s — %s", spec.usage, spec.summary))
+	}
+	return "commands: " + strings.Join(parts, " | ") + " | every command accepts --id=<id> to correlate replies", nil
+}
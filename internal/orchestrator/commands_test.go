@@ -0,0 +1,143 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommands(t *testing.T) {
+	body := "了解しました。\n" +
+		"TEAM_CREATE gh-12 --id=req-7\n" +
+		"  RELEASE --reason=\"weekly release\" --dry-run  \n" +
+		"FOO_BAR x\n" +
+		"This is not a command."
+	cmds := parseCommands(body)
+	if len(cmds) != 3 {
+		t.Fatalf("got %d commands, want 3: %+v", len(cmds), cmds)
+	}
+
+	if cmds[0].Name != "TEAM_CREATE" || cmds[0].ID != "req-7" || !reflect.DeepEqual(cmds[0].Args, []string{"gh-12"}) {
+		t.Errorf("cmds[0] = %+v", cmds[0])
+	}
+	if _, ok := cmds[0].Options["id"]; ok {
+		t.Error("--id should not be kept in Options")
+	}
+
+	if cmds[1].Name != "RELEASE" || cmds[1].Option("reason") != "weekly release" || cmds[1].Option("dry-run") != "true" {
+		t.Errorf("cmds[1] = %+v", cmds[1])
+	}
+	if cmds[1].Line != `RELEASE --reason="weekly release" --dry-run` {
+		t.Errorf("cmds[1].Line = %q", cmds[1].Line)
+	}
+
+	if cmds[2].Name != "FOO_BAR" || cmds[2].Arg(0) != "x" || cmds[2].Arg(1) != "" {
+		t.Errorf("cmds[2] = %+v", cmds[2])
+	}
+}
+
+func TestTokenizeCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"A  b\tc", []string{"A", "b", "c"}},
+		{`A "b c" d`, []string{"A", "b c", "d"}},
+		{`A --k="v w"`, []string{"A", "--k=v w"}},
+		{`A "unterminated x`, []string{"A", "unterminated x"}},
+	}
+	for _, tt := range tests {
+		if got := tokenizeCommand(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenizeCommand(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func newCommandTestOrchestrator(t *testing.T) (*Orchestrator, string) {
+	t.Helper()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "issues"), 0755)
+	return New(testConfig(dir), dir, t.TempDir()), dir
+}
+
+func readChatlog(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "chatlog.txt"))
+	if err != nil {
+		t.Fatalf("read chatlog: %v", err)
+	}
+	return string(data)
+}
+
+func TestCommandReplies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"unknown command", "TEAM_EXPLODE gh-1 --id=a1", []string{"NACK id=a1", "TEAM_EXPLODE: unknown command"}},
+		{"missing argument", "TEAM_CREATE --id=a2", []string{"NACK id=a2", "usage: TEAM_CREATE <issue-id>"}},
+		{"unknown option", "PATROL_COMPLETE --force --id=a3", []string{"NACK id=a3", "unknown option --force"}},
+		{"handler error", "TEAM_CREATE gh-404 --id=a4", []string{"NACK id=a4", "イシューが見つかりません"}},
+		{"accepted", "PATROL_COMPLETE --id=a5", []string{"ACK id=a5"}},
+		{"generated id", "PATROL_COMPLETE", []string{"ACK id=cmd-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orc, dir := newCommandTestOrchestrator(t)
+			sendCommand(orc, t.Context(), tt.body)
+			log := readChatlog(t, dir)
+			if !strings.Contains(log, "[@superintendent] orchestrator:") {
+				t.Errorf("reply not addressed to the sender:\n%s", log)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(log, w) {
+					t.Errorf("chatlog missing %q:\n%s", w, log)
+				}
+			}
+		})
+	}
+}
+
+func TestFreeTextIsNotAnswered(t *testing.T) {
+	orc, dir := newCommandTestOrchestrator(t)
+	sendCommand(orc, t.Context(), "ありがとうございます。進捗を確認します。")
+	if data, _ := os.ReadFile(filepath.Join(dir, "chatlog.txt")); len(data) != 0 {
+		t.Errorf("free text should not be answered, chatlog:\n%s", data)
+	}
+}
+
+func TestMultipleCommandsInOneMessage(t *testing.T) {
+	orc, dir := newCommandTestOrchestrator(t)
+	sendCommand(orc, t.Context(), "PATROL_COMPLETE --id=p1\nHELP --id=h1")
+	log := readChatlog(t, dir)
+	for _, w := range []string{"ACK id=p1", "ACK id=h1"} {
+		if !strings.Contains(log, w) {
+			t.Errorf("chatlog missing %q:\n%s", w, log)
+		}
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	orc, dir := newCommandTestOrchestrator(t)
+	sendCommand(orc, t.Context(), "HELP")
+	log := readChatlog(t, dir)
+	for name := range commandRegistry {
+		if !strings.Contains(log, name) {
+			t.Errorf("HELP does not list %s:\n%s", name, log)
+		}
+	}
+
+	sendCommand(orc, t.Context(), "HELP team_disband --id=h2")
+	if log := readChatlog(t, dir); !strings.Contains(log, "ACK id=h2 TEAM_DISBAND <issue-id>") {
+		t.Errorf("HELP TEAM_DISBAND reply missing:\n%s", log)
+	}
+
+	sendCommand(orc, t.Context(), "HELP NOPE --id=h3")
+	if log := readChatlog(t, dir); !strings.Contains(log, "NACK id=h3") {
+		t.Errorf("HELP for an unknown command should NACK:\n%s", log)
+	}
+}
@@ -0,0 +1,95 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/teamgit"
+)
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "GUARD_APPROVE",
+		usage:   "GUARD_APPROVE <issue-id> [--revoke]",
+		summary: "allow the team of the issue to commit and push changes to [guardrails] protected paths (superintendent only); --revoke withdraws the approval",
+		minArgs: 1,
+		options: []string{"revoke"},
+		handle:  (*Orchestrator).handleGuardApprove,
+	})
+}
+
+// defaultGuardApprovalDir returns the directory of the GUARD_APPROVE
+// markers: a per-user cache directory, outside the repositories and the data
+// directory that sandboxed agents can write.
+func defaultGuardApprovalDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Printf("[orchestrator] guardrails: no user cache directory, GUARD_APPROVE is unavailable: %v", err)
+		return ""
+	}
+	return filepath.Join(dir, "madflow", "guard-approvals")
+}
+
+// pathWithin reports whether path is dir or lies below it.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// handleGuardApprove approves (or revokes the approval of) changes to the
+// protected paths in the team's worktrees. Only the superintendent and the
+// operator may send it; the approval ends when the team's worktrees are set
+// up again for another issue.
+func (o *Orchestrator) handleGuardApprove(_ context.Context, cmd Command) (string, error) {
+	if cmd.Sender != "superintendent" && cmd.Sender != "operator" {
+		return "", fmt.Errorf("GUARD_APPROVE は拒否されました: 承認できるのは監督だけです")
+	}
+	issueID := normalizeIssueID(cmd.Arg(0))
+	if issueID == "" {
+		return "", fmt.Errorf("GUARD_APPROVE は拒否されました: イシューIDが不正です: %s", cmd.Arg(0))
+	}
+	teamNum, ok := o.teams.FindByIssue(issueID)
+	if !ok {
+		return "", fmt.Errorf("GUARD_APPROVE %s は拒否されました: このイシューを担当するチームがありません", issueID)
+	}
+	cfg := o.Config()
+	if cfg.Guardrails.Disabled {
+		return "", fmt.Errorf("GUARD_APPROVE %s は拒否されました: [guardrails] は無効です", issueID)
+	}
+	if o.guardApprovalDir == "" {
+		return "", fmt.Errorf("GUARD_APPROVE %s は拒否されました: 承認を保存するディレクトリがありません", issueID)
+	}
+	approve := cmd.Option("revoke") == ""
+
+	var done []string
+	for _, r := range o.teamRepos(cfg, issueID) {
+		path := teamWorktreePath(r.Path, cfg.GhLogin, teamNum, issueID)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := teamgit.ApproveProtectedPaths(git.NewRepo(path), o.guardApprovalDir, approve); err != nil {
+			log.Printf("[orchestrator] team %d: GUARD_APPROVE in %s: %v", teamNum, r.Name, err)
+			continue
+		}
+		done = append(done, r.Name)
+	}
+	if len(done) == 0 {
+		return "", fmt.Errorf("GUARD_APPROVE %s は拒否されました: チームのワークツリーに guardrail のフックがありません", issueID)
+	}
+
+	engineerID := agent.AgentID{Role: agent.RoleEngineer, TeamNum: teamNum}.String()
+	repos := strings.Join(done, ", ")
+	if !approve {
+		o.appendOrLog(engineerID, "orchestrator", fmt.Sprintf("%s: 保護パスの変更の承認が取り消されました (%s)。", issueID, repos))
+		return fmt.Sprintf("GUARD_APPROVE %s: 保護パスの変更の承認を取り消しました (%s)。", issueID, repos), nil
+	}
+	o.appendOrLog(engineerID, "orchestrator", fmt.Sprintf("%s: 監督が保護パス (%s) の変更を承認しました (%s)。commit と push を再実行してください。",
+		issueID, strings.Join(cfg.Guardrails.ProtectedPaths, ", "), repos))
+	return fmt.Sprintf("GUARD_APPROVE %s: 保護パスの変更を承認しました (%s)。", issueID, repos), nil
+}
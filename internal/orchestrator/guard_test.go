@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGuardApprove(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	iss.Repos = []string{"app"}
+	orc.Store().Update(iss)
+	orc.cfg.Guardrails.ProtectedPaths = []string{"ci/*"}
+	orc.guardApprovalDir = t.TempDir()
+	tm, err := orc.teams.Create(t.Context(), iss.ID, iss.Title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt := teamWorktreePath(orc.Config().Project.Repos[0].Path, "alice", tm.ID, iss.ID)
+	os.MkdirAll(filepath.Join(wt, "ci"), 0755)
+	os.WriteFile(filepath.Join(wt, "ci", "build.sh"), []byte("make\n"), 0644)
+	runGit(t, wt, "add", "ci")
+	commit := func() (string, bool) {
+		cmd := exec.Command("git", "commit", "-m", "ci: change build")
+		cmd.Dir = wt
+		out, err := cmd.CombinedOutput()
+		return string(out), err == nil
+	}
+	if out, ok := commit(); ok || !strings.Contains(out, "ci/build.sh") {
+		t.Fatalf("commit to a protected path should be refused: %s", out)
+	}
+	engineerID := fmt.Sprintf("engineer-%d", tm.ID)
+	if log := readChatlog(t, orc.dataDir); !strings.Contains(log, "[@"+engineerID+"] orchestrator: [guardrail] changes to protected paths") {
+		t.Errorf("the violation should be reported to the engineer:\n%s", log)
+	}
+
+	sendEngineerCommand(orc, t, "GUARD_APPROVE "+iss.ID+" --id=g1")
+	waitForReply(t, orc.dataDir, "NACK id=g1")
+	if out, ok := commit(); ok {
+		t.Fatalf("an engineer's GUARD_APPROVE should not approve: %s", out)
+	}
+	// Nothing in the worktree's git directory grants an approval.
+	gitDir := runGit(t, wt, "rev-parse", "--absolute-git-dir")
+	os.WriteFile(filepath.Join(gitDir, "madflow-hooks", "protected-paths-approved"), nil, 0644)
+	if out, ok := commit(); ok {
+		t.Fatalf("a marker written by the engineer should not approve: %s", out)
+	}
+
+	sendCommand(orc, t.Context(), "GUARD_APPROVE "+iss.ID+" --id=g2")
+	log := waitForReply(t, orc.dataDir, ": ACK id=g2")
+	if !strings.Contains(log, "[@"+engineerID+"] orchestrator: "+iss.ID+": 監督が保護パス (ci/*) の変更を承認しました") {
+		t.Errorf("the engineer should be told about the approval:\n%s", log)
+	}
+	if out, ok := commit(); !ok {
+		t.Fatalf("commit should succeed after approval: %s", out)
+	}
+
+	sendCommand(orc, t.Context(), "GUARD_APPROVE "+iss.ID+" --revoke --id=g3")
+	waitForReply(t, orc.dataDir, ": ACK id=g3")
+	os.WriteFile(filepath.Join(wt, "ci", "test.sh"), []byte("make test\n"), 0644)
+	runGit(t, wt, "add", "ci")
+	if out, ok := commit(); ok {
+		t.Errorf("commit should be refused after revoking: %s", out)
+	}
+}
@@ -0,0 +1,191 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/mergecheck"
+	"github.com/ytnobody/madflow/internal/team"
+)
+
+// IntegrationDir is the directory inside the data directory that holds the
+// latest integration check report of each repository.
+const IntegrationDir = "integration"
+
+// runIntegrationCheck periodically merges develop and the feature branches
+// of all active teams together in each repository and runs the configured
+// tests, so that branches which only break develop in combination are
+// found before any of them is merged.
+func (o *Orchestrator) runIntegrationCheck(ctx context.Context, cfg *config.Config) {
+	interval := time.Duration(cfg.IntegrationCheck.IntervalMinutes) * time.Minute
+	log.Printf("[integration-check] started (interval: %v)", interval)
+
+	// failed holds, per repository, the signature of the last failure
+	// reported, so that an unchanged failure is not reported again.
+	failed := make(map[string]string)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[integration-check] stopped")
+			return
+		case <-ticker.C:
+			o.checkIntegration(ctx, cfg, failed)
+		}
+	}
+}
+
+// checkIntegration runs one integration check of every repository with at
+// least two team branches.
+func (o *Orchestrator) checkIntegration(ctx context.Context, cfg *config.Config, failed map[string]string) {
+	branches := o.teamBranches(cfg)
+	sandbox, err := o.agentSandbox()
+	if err != nil {
+		log.Printf("[integration-check] %v", err)
+		return
+	}
+	for _, r := range cfg.Project.Repos {
+		if ctx.Err() != nil {
+			return
+		}
+		if len(branches[r.Name]) < 2 {
+			// Nothing to combine; a single branch is checked by its own CI.
+			delete(failed, r.Name)
+			continue
+		}
+		repo, ok := o.repos[r.Name]
+		if !ok {
+			repo = git.NewRepo(r.Path)
+		}
+		base, err := latestDevelop(repo, cfg.Branches)
+		if err != nil {
+			log.Printf("[integration-check] %s: %v", r.Name, err)
+			continue
+		}
+		res, err := mergecheck.Run(ctx, repo, mergecheck.Options{
+			Base:        base,
+			Branches:    branches[r.Name],
+			TestCommand: cfg.IntegrationCheck.TestCommand,
+			Timeout:     time.Duration(cfg.IntegrationCheck.TimeoutMinutes) * time.Minute,
+			Command:     sandbox.ShellCommand,
+		})
+		if err != nil {
+			log.Printf("[integration-check] %s: %v", r.Name, err)
+			continue
+		}
+		reportPath, err := o.writeIntegrationReport(r.Name, res.Markdown(r.Name))
+		if err != nil {
+			log.Printf("[integration-check] %s: %v", r.Name, err)
+		}
+
+		if res.OK() {
+			log.Printf("[integration-check] %s: %d branch(es) merge and pass together", r.Name, len(res.Merged))
+			if _, ok := failed[r.Name]; ok {
+				delete(failed, r.Name)
+				o.appendOrLog("superintendent", "orchestrator", fmt.Sprintf(
+					"[integration-check] %s: 以前報告した問題は解消しました (%d ブランチをまとめてマージしてもコンフリクト・テスト失敗はありません)。", r.Name, len(res.Merged)))
+			}
+			continue
+		}
+		sig := res.Signature()
+		if failed[r.Name] == sig {
+			continue
+		}
+		failed[r.Name] = sig
+		o.reportIntegrationFailure(r.Name, len(branches[r.Name]), res, reportPath)
+	}
+}
+
+// teamBranches returns, per repository name, the feature branches of the
+// active teams that exist in that repository, ordered by team number.
+func (o *Orchestrator) teamBranches(cfg *config.Config) map[string][]mergecheck.Branch {
+	teams := o.teams.List()
+	slices.SortFunc(teams, func(a, b team.TeamInfo) int { return a.ID - b.ID })
+
+	branches := make(map[string][]mergecheck.Branch)
+	for _, info := range teams {
+		if info.IssueID == "" {
+			continue
+		}
+		name := cfg.Branches.FeaturePrefix + info.IssueID
+		for _, r := range o.teamRepos(cfg, info.IssueID) {
+			repo, ok := o.repos[r.Name]
+			if !ok {
+				repo = git.NewRepo(r.Path)
+			}
+			if repo.BranchExists(name) {
+				branches[r.Name] = append(branches[r.Name], mergecheck.Branch{Name: name, IssueID: info.IssueID, Team: info.ID})
+			}
+		}
+	}
+	return branches
+}
+
+// reportIntegrationFailure tells the superintendent what failed and each
+// affected engineer what concerns its branch: the conflicts it is part of,
+// and test failures of a merge that includes it.
+func (o *Orchestrator) reportIntegrationFailure(repoName string, nBranches int, res *mergecheck.Result, reportPath string) {
+	var problems []string
+	for _, c := range res.Conflicts {
+		problems = append(problems, fmt.Sprintf("コンフリクト %s (%s)", c.Label(), strings.Join(c.Files, ", ")))
+	}
+	if res.TestErr != nil {
+		problems = append(problems, fmt.Sprintf("テスト失敗: %v", res.TestErr))
+	}
+	log.Printf("[integration-check] %s: %s", repoName, strings.Join(problems, "; "))
+	o.appendOrLog("superintendent", "orchestrator", fmt.Sprintf(
+		"[integration-check] %s: %s と並行中の %d ブランチをまとめてマージすると問題があります: %s。詳細: %s。解消されるまで該当 PR の PR_MERGE は保留してください。",
+		repoName, res.Base, nBranches, strings.Join(problems, "; "), reportPath))
+
+	notes := make(map[int][]string) // team -> messages
+	for _, c := range res.Conflicts {
+		if c.B == nil {
+			notes[c.A.Team] = append(notes[c.A.Team], fmt.Sprintf("%s が %s とコンフリクトします (%s)。%s を取り込んで解消してください", c.A.Name, res.Base, strings.Join(c.Files, ", "), res.Base))
+			continue
+		}
+		for _, pair := range [][2]*mergecheck.Branch{{c.A, c.B}, {c.B, c.A}} {
+			mine, other := pair[0], pair[1]
+			notes[mine.Team] = append(notes[mine.Team], fmt.Sprintf("%s は %s (team %d) のブランチと %s でコンフリクトします。どちらを先にマージするか、相手チームと superintendent に相談してください", mine.Name, other.IssueID, other.Team, strings.Join(c.Files, ", ")))
+		}
+	}
+	if res.TestErr != nil {
+		for _, b := range res.Merged {
+			notes[b.Team] = append(notes[b.Team], fmt.Sprintf("%s を他の %d ブランチと合わせてマージするとテストが失敗します (%v)。あなたの変更が関係していないか確認してください", b.Name, len(res.Merged)-1, res.TestErr))
+		}
+	}
+	nums := make([]int, 0, len(notes))
+	for num := range notes {
+		nums = append(nums, num)
+	}
+	slices.Sort(nums)
+	for _, num := range nums {
+		engineerID := agent.AgentID{Role: agent.RoleEngineer, TeamNum: num}.String()
+		o.appendOrLog(engineerID, "orchestrator", fmt.Sprintf("[integration-check] %s: %s。詳細: %s", repoName, strings.Join(notes[num], "。"), reportPath))
+	}
+}
+
+// writeIntegrationReport saves the latest integration check report of a
+// repository in the data directory and returns its path.
+func (o *Orchestrator) writeIntegrationReport(repoName, report string) (string, error) {
+	dir := filepath.Join(o.dataDir, IntegrationDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("write integration report: %w", err)
+	}
+	path := filepath.Join(dir, repoName+".md")
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		return "", fmt.Errorf("write integration report: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,61 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIntegrationCheckReportsConflictingTeams(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	iss.Repos = []string{"app"}
+	orc.Store().Update(iss)
+	other, _ := orc.Store().Create("Other change", "body")
+	other.Repos = []string{"app"}
+	orc.Store().Update(other)
+	app := orc.Config().Project.Repos[0].Path
+	runGit(t, app, "branch", "develop")
+	orc.cfg.IntegrationCheck.TestCommand = "true"
+
+	for i, id := range []string{iss.ID, other.ID} {
+		tm, err := orc.teams.Create(t.Context(), id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wt := teamWorktreePath(app, "alice", tm.ID, id)
+		os.WriteFile(filepath.Join(wt, "shared.txt"), []byte(strings.Repeat("x", i+1)+"\n"), 0644)
+		runGit(t, wt, "add", "shared.txt")
+		runGit(t, wt, "commit", "-m", "change "+id)
+	}
+
+	failed := map[string]string{}
+	orc.checkIntegration(t.Context(), orc.Config(), failed)
+	log := readChatlog(t, orc.dataDir)
+	for _, want := range []string{
+		"[@superintendent] orchestrator: [integration-check] app: develop と並行中の 2 ブランチ",
+		"コンフリクト local-001 × local-002 (shared.txt)",
+		"[@engineer-1] orchestrator: [integration-check] app: feature/issue-local-001 は local-002 (team 2)",
+		"[@engineer-2] orchestrator: [integration-check] app: feature/issue-local-002 は local-001 (team 1)",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("chatlog missing %q:\n%s", want, log)
+		}
+	}
+	if report, err := os.ReadFile(filepath.Join(orc.dataDir, IntegrationDir, "app.md")); err != nil || !strings.Contains(string(report), "local-001 × local-002") {
+		t.Errorf("report = %q, %v", report, err)
+	}
+
+	// An unchanged failure is not reported again.
+	orc.checkIntegration(t.Context(), orc.Config(), failed)
+	if again := readChatlog(t, orc.dataDir); again != log {
+		t.Errorf("failure reported twice:\n%s", again)
+	}
+
+	// When the branches merge together again, the fix is announced.
+	runGit(t, teamWorktreePath(app, "alice", 2, other.ID), "reset", "--hard", "develop")
+	orc.checkIntegration(t.Context(), orc.Config(), failed)
+	if log := readChatlog(t, orc.dataDir); !strings.Contains(log, "以前報告した問題は解消しました") {
+		t.Errorf("resolution not announced:\n%s", log)
+	}
+}
@@ -2,9 +2,11 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/ytnobody/madflow/internal/config"
 	githubPkg "github.com/ytnobody/madflow/internal/github"
 	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/lessons"
 	"github.com/ytnobody/madflow/internal/team"
 )
 
@@ -60,6 +63,20 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestSandboxWritablePathsAreAbsolute(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg := testConfig(".")
+	cfg.Sandbox = &config.SandboxConfig{WritablePaths: []string{"~/go/pkg/mod"}}
+	orc := New(cfg, ".madflow", t.TempDir())
+
+	home, _ := os.UserHomeDir()
+	want := []string{dir, filepath.Join(dir, ".madflow"), filepath.Join(home, "go/pkg/mod")}
+	if got := orc.sandboxWritablePaths(orc.Config()); !slices.Equal(got, want) {
+		t.Errorf("sandboxWritablePaths() = %v, want %v", got, want)
+	}
+}
+
 func TestNewWithGitHub(t *testing.T) {
 	dir := t.TempDir()
 	cfg := testConfig(dir)
@@ -115,6 +132,26 @@ func TestHandleCommand(t *testing.T) {
 	orc.handleCommand(t.Context(), msg)
 }
 
+// sendCommand delivers body to orc as a superintendent message.
+func sendCommand(orc *Orchestrator, ctx context.Context, body string) {
+	orc.handleCommand(ctx, chatlog.Message{Sender: "superintendent", Recipient: "orchestrator", Body: body})
+}
+
+// waitForTeamAgents waits until the async TEAM_CREATE for issueID has
+// created the team's agents, so that team creation (which writes into the
+// data directory) does not race with the removal of the test's temp dirs.
+func waitForTeamAgents(t *testing.T, orc *Orchestrator, issueID string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := orc.Teams().FindByIssue(issueID); ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for the team of %s", issueID)
+}
+
 func TestHandleTeamCreateMissingID(t *testing.T) {
 	dir := t.TempDir()
 	cfg := testConfig(dir)
@@ -123,7 +160,7 @@ func TestHandleTeamCreateMissingID(t *testing.T) {
 	orc := New(cfg, dir, t.TempDir())
 
 	// TEAM_CREATE without issue ID should not panic
-	orc.handleTeamCreate(t.Context(), "TEAM_CREATE")
+	sendCommand(orc, t.Context(), "TEAM_CREATE")
 }
 
 func TestHandleTeamDisbandMissingID(t *testing.T) {
@@ -134,7 +171,7 @@ func TestHandleTeamDisbandMissingID(t *testing.T) {
 	orc := New(cfg, dir, t.TempDir())
 
 	// TEAM_DISBAND without issue ID should not panic
-	orc.handleTeamDisband("TEAM_DISBAND")
+	sendCommand(orc, t.Context(), "TEAM_DISBAND")
 }
 
 func TestChatLogPath(t *testing.T) {
@@ -473,7 +510,7 @@ func TestHandleTeamCreateRejectsClosedIssue(t *testing.T) {
 	defer cancel()
 
 	teamsBefore := orc.Teams().Count()
-	orc.handleTeamCreate(ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+	sendCommand(orc, ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
 
 	// No new team should be created.
 	if orc.Teams().Count() != teamsBefore {
@@ -515,7 +552,7 @@ func TestHandleTeamCreateRejectsAlreadyAssigned(t *testing.T) {
 	defer cancel()
 
 	teamsBefore := orc.Teams().Count()
-	orc.handleTeamCreate(ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+	sendCommand(orc, ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
 
 	// No new team should be created.
 	if orc.Teams().Count() != teamsBefore {
@@ -562,7 +599,7 @@ func TestHandleTeamCreateRejectsActiveTeam(t *testing.T) {
 	}
 
 	teamsBefore := orc.Teams().Count()
-	orc.handleTeamCreate(ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+	sendCommand(orc, ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
 
 	// No new team should be created.
 	if orc.Teams().Count() != teamsBefore {
@@ -936,7 +973,7 @@ func TestHandleTeamCreateUsesIdleTeam(t *testing.T) {
 	iss, _ := orc.Store().Create("New Issue for Idle Team", "body")
 
 	// Call TEAM_CREATE — should reuse an idle team instead of failing.
-	orc.handleTeamCreate(ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+	sendCommand(orc, ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
 
 	// Team count must not increase (no new team created).
 	if orc.Teams().Count() != 2 {
@@ -1002,7 +1039,7 @@ func TestHandleTeamCreateCreatesNewTeamWhenNoIdle(t *testing.T) {
 	iss, _ := orc.Store().Create("New Issue No Idle", "body")
 
 	// Call TEAM_CREATE — all existing teams are busy, so it must try to create a new one.
-	orc.handleTeamCreate(ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+	sendCommand(orc, ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
 
 	// Give the async goroutine a moment to start.
 	time.Sleep(200 * time.Millisecond)
@@ -1091,6 +1128,7 @@ func TestWatchCommandsPicksUpEarlyTeamCreate(t *testing.T) {
 		if getErr == nil && updated.Status == issue.StatusInProgress {
 			cancel() // success — stop watchCommands
 			<-done
+			waitForTeamAgents(t, orc, iss.ID)
 			return
 		}
 		time.Sleep(50 * time.Millisecond)
@@ -1343,7 +1381,8 @@ func TestHandleTeamCreateMalformedIssueID(t *testing.T) {
 	// Simulate the superintendent sending TEAM_CREATE with appended Japanese text,
 	// mimicking the exact pattern observed in the gh-121 incident.
 	malformed := "TEAM_CREATE gh-99（2回目の要求）。チームアサインをお願いします。"
-	orc.handleTeamCreate(ctx, malformed)
+	sendCommand(orc, ctx, malformed)
+	waitForTeamAgents(t, orc, "gh-99")
 
 	// The issue should have been transitioned to in_progress (assigned to a team),
 	// meaning the malformed ID was normalized and the lookup succeeded.
@@ -1403,7 +1442,7 @@ func TestHandleTeamCreateRejectsWhenAtMaxCapacityAllBusy(t *testing.T) {
 	iss, _ := orc.Store().Create("New Issue Cannot Assign", "body")
 
 	// Call TEAM_CREATE — all slots are full with busy teams; no idle team exists.
-	orc.handleTeamCreate(ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+	sendCommand(orc, ctx, fmt.Sprintf("TEAM_CREATE %s", iss.ID))
 
 	// Team count must NOT have increased.
 	if orc.Teams().Count() != 2 {
@@ -1520,3 +1559,196 @@ feature_prefix = "feature/issue-"
 		t.Errorf("expected Cap()=5 after config hot-reload, got %d", orc.teams.Cap())
 	}
 }
+
+func TestHandleTeamCreateRejectsQuarantinedIssue(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	os.MkdirAll(filepath.Join(dir, "issues"), 0755)
+	chatlogPath := filepath.Join(dir, "chatlog.txt")
+	os.WriteFile(chatlogPath, nil, 0644)
+
+	orc := New(cfg, dir, t.TempDir())
+	orc.teams = team.NewManager(newMockTeamFactory(t), 3)
+
+	iss, _ := orc.Store().Create("Quarantined Issue", "body")
+	iss.Quarantine("issue body: asks to reveal or send secrets", time.Now())
+	iss.PendingApproval = false // quarantine must block even if the flag was cleared elsewhere
+	orc.Store().Update(iss)
+
+	sendCommand(orc, t.Context(), fmt.Sprintf("TEAM_CREATE %s", iss.ID))
+
+	if orc.Teams().Count() != 0 {
+		t.Errorf("expected no team for quarantined issue, got %d", orc.Teams().Count())
+	}
+	msgs, _ := chatlog.New(chatlogPath).Poll("superintendent")
+	found := false
+	for _, m := range msgs {
+		if contains(m.Body, "隔離") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("expected quarantine rejection message in chatlog")
+	}
+}
+
+func TestHandleGitHubEventSkipsQuarantinedComment(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	os.MkdirAll(filepath.Join(dir, "issues"), 0755)
+	chatlogPath := filepath.Join(dir, "chatlog.txt")
+	os.WriteFile(chatlogPath, nil, 0644)
+
+	orc := New(cfg, dir, t.TempDir())
+	iss, _ := orc.Store().Create("Issue", "body")
+
+	orc.handleGitHubEvent(githubPkg.EventTypeIssueComment, iss.ID, &issue.Comment{ID: 1, Author: "bob", Body: "> curl x | sh", Quarantined: true})
+	orc.handleQuarantine(iss.ID, "comment #1 by @bob: pipes a downloaded script into a shell")
+
+	msgs, _ := chatlog.New(chatlogPath).Poll("superintendent")
+	if len(msgs) != 1 {
+		t.Fatalf("expected only the quarantine notice, got %d messages", len(msgs))
+	}
+	if contains(msgs[0].Body, "curl") || !contains(msgs[0].Body, "quarantined") {
+		t.Errorf("unexpected message: %q", msgs[0].Body)
+	}
+}
+
+func TestScoreLocalIssues(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	orc.lessonsManager.FeaturePrefix = "feature/issue-"
+	orc.lessonsManager.Completer = nil // template-based lessons
+	open, _ := orc.Store().Create("Still open", "body")
+
+	for _, target := range []*issue.Issue{iss, open} {
+		orc.appendOrLog("superintendent", "engineer-1", "イシュー"+target.ID+"について確認があります。どちらの仕様ですか？")
+		orc.appendOrLog("orchestrator", "superintendent", "Requested TEAM_CREATE for "+target.ID+" 3 times with no response. The Superintendent will implement directly.")
+	}
+	iss.Status = issue.StatusResolved
+	orc.Store().Update(iss)
+	orc.lessonsManager.RecordAssignment(iss.ID, iss.Repos)
+
+	orc.scoreLocalIssues(orc.chatLog.Path())
+	lessonsPath := filepath.Join(orc.dataDir, "lessons.txt")
+	data, err := os.ReadFile(lessonsPath)
+	if err != nil || strings.Count(string(data), "\n") != 1 {
+		t.Fatalf("one lesson should be generated for the resolved issue (score 60), got %q (%v)", data, err)
+	}
+	if got, _ := orc.Store().Get(iss.ID); !got.LessonScored {
+		t.Error("the issue should be marked as scored")
+	}
+	if got, _ := orc.Store().Get(open.ID); got.LessonScored {
+		t.Error("an open issue should not be scored")
+	}
+
+	// Scored issues are not scored again.
+	orc.scoreLocalIssues(orc.chatLog.Path())
+	if again, _ := os.ReadFile(lessonsPath); string(again) != string(data) {
+		t.Errorf("lessons changed on the second run: %q", again)
+	}
+
+	// An issue assigned before the statistics were kept is only marked.
+	open.Status = issue.StatusClosed
+	orc.Store().Update(open)
+	orc.scoreLocalIssues(orc.chatLog.Path())
+	if again, _ := os.ReadFile(lessonsPath); string(again) != string(data) {
+		t.Errorf("an issue without assignment record should not be scored: %q", again)
+	}
+	if got, _ := orc.Store().Get(open.ID); !got.LessonScored {
+		t.Error("an issue without assignment record should be marked as scored")
+	}
+}
+
+func TestScoreLocalIssuesFromPreviousRun(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	orc.lessonsManager.Completer = nil // template-based lessons
+	iss.Status = issue.StatusResolved
+	orc.Store().Update(iss)
+	orc.lessonsManager.RecordAssignment(iss.ID, iss.Repos)
+	orc.appendOrLog("superintendent", "engineer-1", "イシュー"+iss.ID+"について確認があります。どちらの仕様ですか？")
+	orc.appendOrLog("orchestrator", "superintendent", "Requested TEAM_CREATE for "+iss.ID+" 3 times with no response. The Superintendent will implement directly.")
+
+	done := orc.scoreLocalIssuesFromPreviousRun()
+	if data, _ := os.ReadFile(orc.chatLog.Path()); len(data) != 0 {
+		t.Errorf("chatlog should be empty, got %q", data)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("background scoring did not finish")
+	}
+	if got, _ := orc.Store().Get(iss.ID); !got.LessonScored {
+		t.Error("the issue should be scored in the background")
+	}
+	if _, err := os.Stat(orc.chatLog.Path() + ".previous"); !os.IsNotExist(err) {
+		t.Errorf("the previous chatlog should be removed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(orc.dataDir, "lessons.txt")); strings.Count(string(data), "\n") != 1 {
+		t.Errorf("a lesson should be generated from the previous chatlog, got %q", data)
+	}
+}
+
+func TestEngineerLessons(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	orc.lessonsManager.Completer = nil // template-based lessons
+	iss.Repos = []string{"lib"}
+	iss.Status = issue.StatusResolved
+	orc.Store().Update(iss)
+
+	for range 2 {
+		orc.appendOrLog("superintendent", "orchestrator", "[verify] "+iss.ID+": feature/issue-"+iss.ID+" の検証に失敗しました: lib: test FAILED。")
+	}
+	orc.appendOrLog("engineer-1", "superintendent", "[Changes Requested] "+iss.ID+": エラー処理が抜けています。")
+	orc.lessonsManager.RecordAssignment(iss.ID, iss.Repos)
+
+	orc.scoreLocalIssues(orc.chatLog.Path())
+	all, err := lessons.LoadLessons(filepath.Join(orc.dataDir, "lessons.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var engineer []lessons.Lesson
+	for _, l := range all {
+		if l.Role == agent.RoleEngineer {
+			engineer = append(engineer, l)
+		}
+	}
+	if len(engineer) != 1 || engineer[0].Repo != "lib" {
+		t.Fatalf("one engineer lesson tagged with lib should be generated (score 65), got %+v", all)
+	}
+
+	// The lesson reaches the system prompt of teams working in lib only.
+	agentCfg, err := orc.engineerAgentConfig(orc.Config(), 1, iss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(agentCfg.SystemPrompt, engineer[0].Text) {
+		t.Errorf("engineer system prompt should contain the lesson:\n%s", agentCfg.SystemPrompt)
+	}
+	other, _ := orc.Store().Create("Other", "body")
+	other.Repos = []string{"app"}
+	orc.Store().Update(other)
+	if agentCfg, _ := orc.engineerAgentConfig(orc.Config(), 2, other.ID); strings.Contains(agentCfg.SystemPrompt, engineer[0].Text) {
+		t.Errorf("a team in app should not get the lib lesson:\n%s", agentCfg.SystemPrompt)
+	}
+
+	// The lessons active when a team is created are recorded for the
+	// effectiveness statistics.
+	next, _ := orc.Store().Create("Next", "body")
+	next.Repos = []string{"lib"}
+	orc.Store().Update(next)
+	if _, err := orc.CreateTeamAgents(3, next.ID); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(orc.dataDir, "lessons-stats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats map[string]struct{ Lessons []string }
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+	if active := stats[next.ID].Lessons; len(active) != 1 || !strings.HasSuffix(active[0], engineer[0].Text) {
+		t.Errorf("lessons recorded at assignment = %v, want the lib lesson", active)
+	}
+}
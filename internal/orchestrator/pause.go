@@ -0,0 +1,131 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "PAUSE",
+		usage:   "PAUSE <team|issue-id> | PAUSE --all | PAUSE --new-teams",
+		summary: "pause one team (it keeps its context), every team and team creation, or team creation only",
+		options: []string{"all", "new-teams"},
+		handle:  (*Orchestrator).handlePause,
+	})
+	registerCommand(commandSpec{
+		name:    "RESUME",
+		usage:   "RESUME <team|issue-id> | RESUME --all | RESUME --new-teams",
+		summary: "resume a paused team, everything, or team creation",
+		options: []string{"all", "new-teams"},
+		handle:  (*Orchestrator).handleResume,
+	})
+}
+
+// pauseTarget is what a PAUSE or RESUME command applies to.
+type pauseTarget struct {
+	all      bool
+	newTeams bool
+	team     int
+}
+
+// parsePauseTarget accepts exactly one of a team, --all and --new-teams.
+func (o *Orchestrator) parsePauseTarget(cmd Command) (pauseTarget, error) {
+	var tgt pauseTarget
+	tgt.all = cmd.Option("all") != ""
+	tgt.newTeams = cmd.Option("new-teams") != ""
+	n := len(cmd.Args)
+	if tgt.all {
+		n++
+	}
+	if tgt.newTeams {
+		n++
+	}
+	if n != 1 {
+		return tgt, fmt.Errorf("%s: specify exactly one of a team, --all or --new-teams", cmd.Name)
+	}
+	if len(cmd.Args) == 1 {
+		num, err := o.resolveTeam(cmd.Arg(0))
+		if err != nil {
+			return tgt, fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+		tgt.team = num
+	}
+	return tgt, nil
+}
+
+// resolveTeam accepts a team number ("2", "team-2", "engineer-2") or the ID
+// of the issue a team is working on.
+func (o *Orchestrator) resolveTeam(arg string) (int, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(arg, "team-"), "engineer-")
+	if num, err := strconv.Atoi(s); err == nil {
+		if _, ok := o.teams.Engineer(num); !ok {
+			return 0, fmt.Errorf("team %d not found", num)
+		}
+		return num, nil
+	}
+	issueID := normalizeIssueID(arg)
+	if num, ok := o.teams.FindByIssue(issueID); ok {
+		return num, nil
+	}
+	return 0, fmt.Errorf("no team found for %s", arg)
+}
+
+// pauseChanged returns text as the ACK text of cmd. A pause state change made
+// by someone else (e.g. the operator through `madflow pause`) is also told to
+// the superintendent, so that it does not mistake a paused team for an
+// unresponsive one.
+func (o *Orchestrator) pauseChanged(cmd Command, text string) (string, error) {
+	if cmd.Sender != "superintendent" {
+		o.appendOrLog("superintendent", "orchestrator", fmt.Sprintf("%s (by %s)", text, cmd.Sender))
+	}
+	return text, nil
+}
+
+// handlePause pauses a team, every team, or team creation. Paused engineers
+// keep their process and conversation; messages sent to them are held until
+// they are resumed. The state is kept by the team manager and is not
+// affected by config reloads.
+func (o *Orchestrator) handlePause(_ context.Context, cmd Command) (string, error) {
+	tgt, err := o.parsePauseTarget(cmd)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case tgt.all:
+		o.teams.PauseAll()
+		return o.pauseChanged(cmd, "PAUSE: all teams and new team creation paused")
+	case tgt.newTeams:
+		o.teams.PauseCreation()
+		return o.pauseChanged(cmd, "PAUSE: new team creation paused; existing teams keep working")
+	}
+	if err := o.teams.Pause(tgt.team); err != nil {
+		return "", fmt.Errorf("PAUSE: %w", err)
+	}
+	return o.pauseChanged(cmd, fmt.Sprintf("PAUSE: team %d paused", tgt.team))
+}
+
+// handleResume undoes handlePause.
+func (o *Orchestrator) handleResume(_ context.Context, cmd Command) (string, error) {
+	tgt, err := o.parsePauseTarget(cmd)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case tgt.all:
+		o.teams.ResumeAll()
+		return o.pauseChanged(cmd, "RESUME: all teams and new team creation resumed")
+	case tgt.newTeams:
+		if o.teams.AllPaused() {
+			return "", fmt.Errorf("RESUME: all teams are paused; use RESUME --all")
+		}
+		o.teams.ResumeCreation()
+		return o.pauseChanged(cmd, "RESUME: new team creation resumed")
+	}
+	if err := o.teams.Resume(tgt.team); err != nil {
+		return "", fmt.Errorf("RESUME: %w", err)
+	}
+	return o.pauseChanged(cmd, fmt.Sprintf("RESUME: team %d resumed", tgt.team))
+}
@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/chatlog"
+	"github.com/ytnobody/madflow/internal/team"
+)
+
+func newPauseTestOrchestrator(t *testing.T) (*Orchestrator, string, *team.Team) {
+	t.Helper()
+	orc, dir := newCommandTestOrchestrator(t)
+	orc.teams = team.NewManager(newMockTeamFactory(t), 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tm, err := orc.teams.Create(ctx, "gh-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orc, dir, tm
+}
+
+func teamPaused(orc *Orchestrator, num int) bool {
+	for _, info := range orc.teams.List() {
+		if info.ID == num {
+			return info.Paused
+		}
+	}
+	return false
+}
+
+func TestPauseResumeCommands(t *testing.T) {
+	orc, dir, tm := newPauseTestOrchestrator(t)
+
+	sendCommand(orc, t.Context(), "PAUSE gh-1 --id=p1")
+	if !teamPaused(orc, tm.ID) {
+		t.Error("PAUSE <issue-id> did not pause the team")
+	}
+	sendCommand(orc, t.Context(), "RESUME team-1 --id=r1")
+	if teamPaused(orc, tm.ID) {
+		t.Error("RESUME team-1 did not resume the team")
+	}
+
+	sendCommand(orc, t.Context(), "PAUSE --all --id=p2")
+	if !teamPaused(orc, tm.ID) || !orc.teams.CreationPaused() {
+		t.Error("PAUSE --all did not pause teams and creation")
+	}
+	sendCommand(orc, t.Context(), "RESUME --new-teams --id=r2")
+	sendCommand(orc, t.Context(), "RESUME --all --id=r3")
+	if teamPaused(orc, tm.ID) || orc.teams.CreationPaused() {
+		t.Error("RESUME --all did not clear the pause")
+	}
+
+	log := readChatlog(t, dir)
+	for _, w := range []string{"ACK id=p1", "ACK id=r1", "ACK id=p2", "NACK id=r2", "ACK id=r3"} {
+		if !strings.Contains(log, w) {
+			t.Errorf("chatlog missing %q:\n%s", w, log)
+		}
+	}
+}
+
+func TestPauseCommandErrors(t *testing.T) {
+	orc, dir, _ := newPauseTestOrchestrator(t)
+	sendCommand(orc, t.Context(), "PAUSE --id=e1")
+	sendCommand(orc, t.Context(), "PAUSE 1 --all --id=e2")
+	sendCommand(orc, t.Context(), "PAUSE 7 --id=e3")
+	sendCommand(orc, t.Context(), "PAUSE gh-404 --id=e4")
+
+	log := readChatlog(t, dir)
+	for _, w := range []string{"NACK id=e1", "NACK id=e2", "NACK id=e3 PAUSE: team 7 not found", "NACK id=e4"} {
+		if !strings.Contains(log, w) {
+			t.Errorf("chatlog missing %q:\n%s", w, log)
+		}
+	}
+}
+
+func TestTeamCreateRejectedWhileCreationPaused(t *testing.T) {
+	orc, dir, _ := newPauseTestOrchestrator(t)
+	iss, _ := orc.Store().Create("Paused", "body")
+
+	sendCommand(orc, t.Context(), "PAUSE --new-teams")
+	sendCommand(orc, t.Context(), "TEAM_CREATE "+iss.ID+" --id=c1")
+
+	if log := readChatlog(t, dir); !strings.Contains(log, "NACK id=c1") || !strings.Contains(log, "一時停止中") {
+		t.Errorf("TEAM_CREATE should be rejected while creation is paused:\n%s", log)
+	}
+	if orc.teams.HasIssue(iss.ID) {
+		t.Error("a team was assigned while creation is paused")
+	}
+}
+
+func TestPauseSurvivesConfigReload(t *testing.T) {
+	orc, _, tm := newPauseTestOrchestrator(t)
+	sendCommand(orc, t.Context(), "PAUSE 1")
+	sendCommand(orc, t.Context(), "PAUSE --new-teams")
+
+	updated := *orc.Config()
+	updated.Agent.MaxTeams = 5
+	updated.Agent.Models.Engineer = "claude-haiku-4-5"
+	orc.applyConfig(&updated)
+
+	if !teamPaused(orc, tm.ID) || !tm.Engineer.Paused() {
+		t.Error("team was resumed by a config reload")
+	}
+	if !orc.teams.CreationPaused() {
+		t.Error("team creation was resumed by a config reload")
+	}
+}
+
+func TestOperatorPauseIsAnnounced(t *testing.T) {
+	orc, dir, _ := newPauseTestOrchestrator(t)
+	orc.handleCommand(t.Context(), chatlog.Message{Sender: "operator", Recipient: "orchestrator", Body: "PAUSE 1 --id=o1"})
+
+	log := readChatlog(t, dir)
+	if !strings.Contains(log, "[@operator] orchestrator: ACK id=o1") {
+		t.Errorf("reply should go to the operator:\n%s", log)
+	}
+	if !strings.Contains(log, "[@superintendent] orchestrator: PAUSE: team 1 paused (by operator)") {
+		t.Errorf("superintendent was not told about the pause:\n%s", log)
+	}
+}
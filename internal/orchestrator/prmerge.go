@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/github"
+)
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "PR_MERGE",
+		usage:   "PR_MERGE <issue-id>",
+		summary: "merge the pull requests of an issue in all its repositories together, once every one is mergeable and green",
+		minArgs: 1,
+		handle:  (*Orchestrator).handlePRMerge,
+	})
+}
+
+// linkedPR is the pull request of an issue in one repository.
+type linkedPR struct {
+	repo config.RepoConfig
+	pr   *github.PullRequest
+}
+
+// handlePRMerge merges the pull requests of an issue's feature branch in
+// every repository the issue involves. Nothing is merged unless all of them
+// are open, mergeable and green, and, in repositories with [verify]
+// commands, at a commit that passed VERIFY. A repository whose feature branch has no
+// commits beyond develop needs no pull request and is skipped. The work
+// runs in the background; the outcome is reported with a DONE or NACK reply.
+func (o *Orchestrator) handlePRMerge(ctx context.Context, cmd Command) (string, error) {
+	issueID := normalizeIssueID(cmd.Arg(0))
+	if issueID == "" {
+		return "", fmt.Errorf("PR_MERGE は拒否されました: イシューIDが不正です: %s", cmd.Arg(0))
+	}
+	if _, err := o.store.Get(issueID); err != nil {
+		return "", fmt.Errorf("PR_MERGE %s は拒否されました: イシューが見つかりません", issueID)
+	}
+	cfg := o.Config()
+	repos := o.teamRepos(cfg, issueID)
+
+	go func() {
+		merged, err := o.mergeLinkedPRs(context.WithoutCancel(ctx), cfg, issueID, repos)
+		<-cmd.replied
+		if err != nil {
+			log.Printf("[orchestrator] PR_MERGE %s failed: %v", issueID, err)
+			o.replyCommand(cmd, replyNACK, fmt.Sprintf("PR_MERGE %s に失敗しました: %v", issueID, err))
+			return
+		}
+		o.replyCommand(cmd, replyDONE, fmt.Sprintf("PR_MERGE %s: %s をマージしました", issueID, strings.Join(merged, ", ")))
+	}()
+
+	return fmt.Sprintf("PR_MERGE %s: 受信しました。%s の PR を確認します。", issueID, strings.Join(repoNames(repos), ", ")), nil
+}
+
+// mergeLinkedPRs checks and merges the pull requests of issueID and returns
+// them as "<repo>#<number>".
+func (o *Orchestrator) mergeLinkedPRs(ctx context.Context, cfg *config.Config, issueID string, repos []config.RepoConfig) ([]string, error) {
+	branch := cfg.Branches.FeaturePrefix + issueID
+	var verified map[string]string
+	if iss, err := o.store.Get(issueID); err == nil {
+		verified = iss.VerifiedCommits
+	}
+	var prs []linkedPR
+	var problems []string
+	for _, r := range repos {
+		pr, err := o.pulls.FindOpen(ctx, r.Path, branch)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", r.Name, err))
+			continue
+		}
+		if pr == nil {
+			if branchHasChanges(r.Path, cfg.Branches.Develop, branch) {
+				problems = append(problems, fmt.Sprintf("%s: %s の PR がありません", r.Name, branch))
+			}
+			continue
+		}
+		switch {
+		case pr.IsDraft:
+			problems = append(problems, fmt.Sprintf("%s#%d はドラフトです", r.Name, pr.Number))
+		case pr.Mergeable != "MERGEABLE":
+			problems = append(problems, fmt.Sprintf("%s#%d はマージできません (%s)", r.Name, pr.Number, pr.Mergeable))
+		case !pr.ChecksPassed():
+			problems = append(problems, fmt.Sprintf("%s#%d の CI が成功していません", r.Name, pr.Number))
+		case cfg.Verify.For(r.Name).Enabled() && (pr.HeadRefOid == "" || verified[r.Name] != pr.HeadRefOid):
+			problems = append(problems, fmt.Sprintf("%s#%d の最新コミットは VERIFY に合格していません", r.Name, pr.Number))
+		}
+		prs = append(prs, linkedPR{repo: r, pr: pr})
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("どの PR もマージしていません: %s", strings.Join(problems, "; "))
+	}
+	if len(prs) == 0 {
+		return nil, fmt.Errorf("%s の PR がありません", branch)
+	}
+
+	var merged []string
+	for _, l := range prs {
+		name := fmt.Sprintf("%s#%d", l.repo.Name, l.pr.Number)
+		if err := o.pulls.Merge(ctx, l.repo.Path, l.pr.Number); err != nil {
+			// Merged pull requests cannot be taken back; report where the
+			// merge stopped so that it can be finished by hand.
+			if len(merged) == 0 {
+				return nil, fmt.Errorf("%s: %v (どの PR もマージしていません)", name, err)
+			}
+			return nil, fmt.Errorf("%s: %v (マージ済み: %s)", name, err, strings.Join(merged, ", "))
+		}
+		log.Printf("[orchestrator] PR_MERGE %s: merged %s", issueID, name)
+		merged = append(merged, name)
+	}
+	return merged, nil
+}
+
+// branchHasChanges reports whether branch exists in the repository at path
+// and has commits that are not in develop.
+func branchHasChanges(path, develop, branch string) bool {
+	repo := git.NewRepo(path)
+	if !repo.BranchExists(branch) {
+		return false
+	}
+	n, err := repo.CountCommits(develop, branch)
+	return err != nil || n > 0
+}
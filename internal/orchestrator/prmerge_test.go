@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/github"
+)
+
+// fakePulls serves pull requests by repository path.
+type fakePulls struct {
+	mu       sync.Mutex
+	prs      map[string]*github.PullRequest
+	failDir  string
+	mergedIn []string
+}
+
+func (f *fakePulls) FindOpen(_ context.Context, dir, _ string) (*github.PullRequest, error) {
+	return f.prs[dir], nil
+}
+
+func (f *fakePulls) Merge(_ context.Context, dir string, number int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if dir == f.failDir {
+		return errors.New("merge blocked")
+	}
+	f.mergedIn = append(f.mergedIn, fmt.Sprintf("%s#%d", filepath.Base(dir), number))
+	return nil
+}
+
+func (f *fakePulls) merged() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.mergedIn...)
+}
+
+func greenPR(n int) *github.PullRequest {
+	return &github.PullRequest{Number: n, State: "OPEN", Mergeable: "MERGEABLE"}
+}
+
+func TestPRMergeMergesAllLinkedPRs(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	repos := orc.Config().Project.Repos
+	pulls := &fakePulls{prs: map[string]*github.PullRequest{repos[0].Path: greenPR(12), repos[1].Path: greenPR(7)}}
+	orc.pulls = pulls
+
+	sendCommand(orc, t.Context(), "PR_MERGE "+iss.ID+" --id=m1")
+	log := waitForReply(t, orc.dataDir, "DONE id=m1")
+	if !strings.Contains(log, "app#12, lib#7 をマージしました") {
+		t.Errorf("unexpected reply:\n%s", log)
+	}
+	if got := strings.Join(pulls.merged(), ","); got != "app#12,lib#7" {
+		t.Errorf("merged %s, want app#12,lib#7", got)
+	}
+}
+
+func TestPRMergeRefusesUnlessAllReady(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, libPath string, lib *github.PullRequest) *github.PullRequest
+		want  string
+	}{
+		{"conflicting", func(_ *testing.T, _ string, lib *github.PullRequest) *github.PullRequest {
+			lib.Mergeable = "CONFLICTING"
+			return lib
+		}, "lib#7 はマージできません"},
+		{"draft", func(_ *testing.T, _ string, lib *github.PullRequest) *github.PullRequest {
+			lib.IsDraft = true
+			return lib
+		}, "lib#7 はドラフトです"},
+		{"missing pr with changes", func(t *testing.T, libPath string, _ *github.PullRequest) *github.PullRequest {
+			runGit(t, libPath, "branch", "develop")
+			runGit(t, libPath, "checkout", "-b", "feature/issue-local-001")
+			runGit(t, libPath, "commit", "--allow-empty", "-m", "wip")
+			return nil
+		}, "lib: feature/issue-local-001 の PR がありません"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orc, iss := newRoutingTestOrchestrator(t)
+			repos := orc.Config().Project.Repos
+			lib := tt.setup(t, repos[1].Path, greenPR(7))
+			pulls := &fakePulls{prs: map[string]*github.PullRequest{repos[0].Path: greenPR(12)}}
+			if lib != nil {
+				pulls.prs[repos[1].Path] = lib
+			}
+			orc.pulls = pulls
+
+			sendCommand(orc, t.Context(), "PR_MERGE "+iss.ID+" --id=m2")
+			log := waitForReply(t, orc.dataDir, "NACK id=m2")
+			if !strings.Contains(log, tt.want) || !strings.Contains(log, "どの PR もマージしていません") {
+				t.Errorf("reply missing %q:\n%s", tt.want, log)
+			}
+			if m := pulls.merged(); len(m) != 0 {
+				t.Errorf("merged %v despite a blocked PR", m)
+			}
+		})
+	}
+}
+
+func TestPRMergeSkipsRepoWithoutChanges(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	repos := orc.Config().Project.Repos
+	pulls := &fakePulls{prs: map[string]*github.PullRequest{repos[0].Path: greenPR(12)}}
+	orc.pulls = pulls
+
+	sendCommand(orc, t.Context(), "PR_MERGE "+iss.ID+" --id=m3")
+	waitForReply(t, orc.dataDir, "DONE id=m3")
+	if got := strings.Join(pulls.merged(), ","); got != "app#12" {
+		t.Errorf("merged %s, want app#12", got)
+	}
+}
+
+func TestPRMergeReportsPartialMerge(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	repos := orc.Config().Project.Repos
+	orc.pulls = &fakePulls{
+		prs:     map[string]*github.PullRequest{repos[0].Path: greenPR(12), repos[1].Path: greenPR(7)},
+		failDir: repos[1].Path,
+	}
+
+	sendCommand(orc, t.Context(), "PR_MERGE "+iss.ID+" --id=m4")
+	log := waitForReply(t, orc.dataDir, "NACK id=m4")
+	if !strings.Contains(log, "lib#7: merge blocked (マージ済み: app#12)") {
+		t.Errorf("partial merge not reported:\n%s", log)
+	}
+}
+
+func TestPRMergeUnknownIssue(t *testing.T) {
+	orc, dir := newCommandTestOrchestrator(t)
+	sendCommand(orc, t.Context(), "PR_MERGE gh-404 --id=m5")
+	if data, _ := os.ReadFile(filepath.Join(dir, "chatlog.txt")); !strings.Contains(string(data), "NACK id=m5") {
+		t.Errorf("unknown issue should be NACKed:\n%s", data)
+	}
+}
@@ -0,0 +1,208 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/reset"
+)
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "TEAM_REASSIGN",
+		usage:   "TEAM_REASSIGN <issue-id> [--model=<model>]",
+		summary: "replace the engineer of an issue with a fresh one that continues from a handoff memo and the existing branch",
+		minArgs: 1,
+		options: []string{"model"},
+		handle:  (*Orchestrator).handleTeamReassign,
+	})
+}
+
+// handoffTimeout bounds how long TEAM_REASSIGN waits for the current engineer
+// to finish its turn and write the handoff memo. A stuck engineer is replaced
+// without one (its latest context-reset memo is used instead, if any).
+var handoffTimeout = 10 * time.Minute
+
+// handoffTaskNote is appended to the original task of a reassigned engineer.
+// Arguments: the previous engineer, the feature branch.
+const handoffTaskNote = `
+
+## 引き継ぎ
+このイシューは %s から引き継がれました。前任者の作業はブランチ %s と既存のワークツリーに残っています。
+新しいブランチを作らず、既存のワークツリーで作業を続けてください。ワークツリーが無い場合は -b を付けずに既存ブランチから作り直してください。
+前任者の引き継ぎメモを参考にしてください。`
+
+// handoff is a TEAM_REASSIGN in progress.
+type handoff struct {
+	// from is the engineer being replaced, e.g. "engineer-2", and team its
+	// team number.
+	from string
+	team int
+	// model overrides agent.models.engineer for the new engineer.
+	model string
+	// memo is the handoff memo for the new engineer.
+	memo string
+}
+
+// handleTeamReassign replaces the engineer working on an issue. The current
+// engineer is asked to distill a handoff memo, its team is disbanded while
+// its worktree and branch are kept, and a new team is created whose engineer
+// starts from the memo and the existing branch. The work runs in the
+// background; the outcome is reported with a DONE or NACK reply.
+func (o *Orchestrator) handleTeamReassign(ctx context.Context, cmd Command) (string, error) {
+	issueID := normalizeIssueID(cmd.Arg(0))
+	teamNum, ok := o.teams.FindByIssue(issueID)
+	if !ok {
+		return "", fmt.Errorf("TEAM_REASSIGN %s は拒否されました: このイシューを担当するチームがありません", issueID)
+	}
+	model := cmd.Option("model")
+	if model != "" {
+		if err := config.ValidateModel("--model", model); err != nil {
+			return "", fmt.Errorf("TEAM_REASSIGN %s は拒否されました: %v", issueID, err)
+		}
+	}
+	if o.teams.CreationPaused() {
+		return "", fmt.Errorf("TEAM_REASSIGN %s は保留されました: チーム作成は一時停止中です。RESUME 後に再送してください", issueID)
+	}
+	old, ok := o.teams.Engineer(teamNum)
+	if !ok {
+		return "", fmt.Errorf("TEAM_REASSIGN %s は拒否されました: チーム %d が見つかりません", issueID, teamNum)
+	}
+
+	o.handoffMu.Lock()
+	if _, busy := o.handoffs[issueID]; busy {
+		o.handoffMu.Unlock()
+		return "", fmt.Errorf("TEAM_REASSIGN %s は拒否されました: 引き継ぎは既に進行中です", issueID)
+	}
+	h := &handoff{from: old.ID.String(), team: teamNum, model: model}
+	o.handoffs[issueID] = h
+	o.handoffMu.Unlock()
+
+	go func() {
+		newTeam, err := o.reassign(context.WithoutCancel(ctx), issueID, teamNum, old, h)
+		<-cmd.replied
+		if err != nil {
+			log.Printf("[orchestrator] TEAM_REASSIGN %s failed: %v", issueID, err)
+			o.replyCommand(cmd, replyNACK, fmt.Sprintf("TEAM_REASSIGN %s に失敗しました: %v", issueID, err))
+			return
+		}
+		o.replyCommand(cmd, replyDONE, fmt.Sprintf("TEAM_REASSIGN %s: チーム %d をチーム %d (engineer-%d) に引き継ぎました", issueID, teamNum, newTeam, newTeam))
+	}()
+
+	return fmt.Sprintf("TEAM_REASSIGN %s: 受信しました。%s に引き継ぎメモの作成を依頼します。", issueID, old.ID.String()), nil
+}
+
+// reassign performs TEAM_REASSIGN and returns the number of the new team.
+func (o *Orchestrator) reassign(ctx context.Context, issueID string, teamNum int, old *agent.Agent, h *handoff) (int, error) {
+	defer func() {
+		o.handoffMu.Lock()
+		delete(o.handoffs, issueID)
+		o.handoffMu.Unlock()
+	}()
+
+	memosDir := filepath.Join(o.dataDir, "memos")
+	hctx, cancel := context.WithTimeout(ctx, handoffTimeout)
+	memoPath, err := old.Handoff(hctx, "handoff-"+issueID)
+	cancel()
+	var memo string
+	if err != nil {
+		log.Printf("[orchestrator] TEAM_REASSIGN %s: %s did not write a handoff memo: %v; using its latest memo", issueID, h.from, err)
+		memo, _ = reset.LoadLatestMemo(memosDir, h.from)
+	} else {
+		data, readErr := os.ReadFile(memoPath)
+		if readErr != nil {
+			log.Printf("[orchestrator] TEAM_REASSIGN %s: read handoff memo: %v", issueID, readErr)
+		}
+		memo = string(data)
+	}
+
+	o.handoffMu.Lock()
+	h.memo = memo
+	o.handoffMu.Unlock()
+
+	// Disband without cleaning worktrees: the new engineer continues in the
+	// same worktree and branch.
+	if err := o.teams.Disband(teamNum); err != nil {
+		return 0, fmt.Errorf("disband team %d: %w", teamNum, err)
+	}
+	o.forgetEngineerModel(teamNum)
+	log.Printf("[orchestrator] TEAM_REASSIGN %s: team %d disbanded (worktree kept)", issueID, teamNum)
+
+	var title string
+	if iss, err := o.store.Get(issueID); err == nil {
+		title = iss.Title
+	}
+	t, err := o.teams.Create(ctx, issueID, title)
+	if err != nil {
+		// Let the superintendent start over with TEAM_CREATE.
+		if iss, getErr := o.store.Get(issueID); getErr == nil {
+			iss.AssignedTeam = 0
+			iss.Status = issue.StatusOpen
+			if updErr := o.store.Update(iss); updErr != nil {
+				log.Printf("[orchestrator] TEAM_REASSIGN: failed to reset issue %s: %v", issueID, updErr)
+			}
+		}
+		return 0, fmt.Errorf("create team: %w", err)
+	}
+
+	if iss, err := o.store.Get(issueID); err == nil {
+		iss.AssignedTeam = t.ID
+		iss.Status = issue.StatusInProgress
+		if updErr := o.store.Update(iss); updErr != nil {
+			log.Printf("[orchestrator] TEAM_REASSIGN: failed to update issue %s assignment: %v", issueID, updErr)
+		}
+	}
+	log.Printf("[orchestrator] TEAM_REASSIGN %s: team %d -> team %d", issueID, teamNum, t.ID)
+	return t.ID, nil
+}
+
+// takeHandoff returns the TEAM_REASSIGN in progress for issueID, if any, and
+// records its model override for team teamNum.
+func (o *Orchestrator) takeHandoff(teamNum int, issueID string) *handoff {
+	o.handoffMu.Lock()
+	defer o.handoffMu.Unlock()
+	h, ok := o.handoffs[issueID]
+	if !ok {
+		return nil
+	}
+	if h.model != "" {
+		o.engineerModels[teamNum] = h.model
+	}
+	cp := *h
+	return &cp
+}
+
+// reassigningIssues returns the issues with a TEAM_REASSIGN in progress and
+// the number of the team being replaced for each.
+func (o *Orchestrator) reassigningIssues() map[string]int {
+	o.handoffMu.Lock()
+	defer o.handoffMu.Unlock()
+	teams := make(map[string]int, len(o.handoffs))
+	for id, h := range o.handoffs {
+		teams[id] = h.team
+	}
+	return teams
+}
+
+// engineerModel returns the model chosen for team teamNum by
+// TEAM_REASSIGN --model, or "".
+func (o *Orchestrator) engineerModel(teamNum int) string {
+	o.handoffMu.Lock()
+	defer o.handoffMu.Unlock()
+	return o.engineerModels[teamNum]
+}
+
+// forgetEngineerModel drops the TEAM_REASSIGN --model choice of a disbanded
+// team.
+func (o *Orchestrator) forgetEngineerModel(teamNum int) {
+	o.handoffMu.Lock()
+	defer o.handoffMu.Unlock()
+	delete(o.engineerModels, teamNum)
+}
@@ -0,0 +1,132 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+func TestTeamReassign(t *testing.T) {
+	orc, dir := newCommandTestOrchestrator(t)
+	orc.cfg.Agent.Models.Engineer = "test"
+	iss, _ := orc.Store().Create("Stuck issue", "body")
+
+	old, err := orc.teams.Create(context.Background(), iss.ID, iss.Title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss.AssignedTeam = old.ID
+	iss.Status = issue.StatusInProgress
+	orc.Store().Update(iss)
+
+	sendCommand(orc, t.Context(), "TEAM_REASSIGN "+iss.ID+" --id=r1")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(readChatlog(t, dir), "DONE id=r1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no DONE reply:\n%s", readChatlog(t, dir))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !strings.Contains(readChatlog(t, dir), "ACK id=r1") {
+		t.Errorf("no ACK reply:\n%s", readChatlog(t, dir))
+	}
+
+	num, ok := orc.teams.FindByIssue(iss.ID)
+	if !ok || num == old.ID {
+		t.Fatalf("issue should be handled by a new team, got %d (ok=%v)", num, ok)
+	}
+	if _, ok := orc.teams.Engineer(old.ID); ok {
+		t.Error("the old team should be disbanded")
+	}
+	updated, _ := orc.Store().Get(iss.ID)
+	if updated.AssignedTeam != num {
+		t.Errorf("AssignedTeam = %d, want %d", updated.AssignedTeam, num)
+	}
+	if ids := orc.reassigningIssues(); len(ids) != 0 {
+		t.Errorf("handoff still in progress: %v", ids)
+	}
+}
+
+func TestTeamReassignMovesLegacyWorktree(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	orc.cfg.GhLogin = ""
+	iss.Repos = []string{"app"}
+	orc.Store().Update(iss)
+	repo := orc.Config().Project.Repos[0].Path
+
+	old, err := orc.teams.Create(t.Context(), iss.ID, iss.Title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldWT := teamWorktreePath(repo, "", old.ID, iss.ID)
+	os.WriteFile(filepath.Join(oldWT, "wip.txt"), []byte("work in progress\n"), 0644)
+	runGit(t, oldWT, "add", "wip.txt")
+	runGit(t, oldWT, "commit", "-m", "wip")
+	orc.engineerModels[old.ID] = "claude-haiku-4-5"
+
+	sendCommand(orc, t.Context(), "TEAM_REASSIGN "+iss.ID+" --id=r1")
+	waitForReply(t, orc.dataDir, "DONE id=r1")
+
+	num, _ := orc.teams.FindByIssue(iss.ID)
+	newWT := teamWorktreePath(repo, "", num, iss.ID)
+	if got := runGit(t, newWT, "rev-parse", "--abbrev-ref", "HEAD"); got != "feature/issue-"+iss.ID {
+		t.Errorf("new worktree is on %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(newWT, "wip.txt")); err != nil {
+		t.Errorf("the new team should continue in the previous worktree: %v", err)
+	}
+	if _, err := os.Stat(oldWT); !os.IsNotExist(err) {
+		t.Errorf("the previous worktree should have been moved: %v", err)
+	}
+	if m := orc.engineerModel(old.ID); m != "" {
+		t.Errorf("model of the disbanded team still recorded: %q", m)
+	}
+}
+
+func TestTeamReassignRejected(t *testing.T) {
+	orc, dir := newCommandTestOrchestrator(t)
+	orc.cfg.Agent.Models.Engineer = "test"
+	iss, _ := orc.Store().Create("Issue", "body")
+	if _, err := orc.teams.Create(context.Background(), iss.ID, iss.Title); err != nil {
+		t.Fatal(err)
+	}
+
+	sendCommand(orc, t.Context(), "TEAM_REASSIGN gh-404 --id=n1")
+	sendCommand(orc, t.Context(), "TEAM_REASSIGN "+iss.ID+" --model=gpt-9 --id=n2")
+	sendCommand(orc, t.Context(), "PAUSE --new-teams")
+	sendCommand(orc, t.Context(), "TEAM_REASSIGN "+iss.ID+" --id=n3")
+
+	log := readChatlog(t, dir)
+	for _, w := range []string{"NACK id=n1", "NACK id=n2", "unknown model", "NACK id=n3"} {
+		if !strings.Contains(log, w) {
+			t.Errorf("chatlog missing %q:\n%s", w, log)
+		}
+	}
+}
+
+func TestReassignModelOverride(t *testing.T) {
+	orc, _ := newCommandTestOrchestrator(t)
+	orc.handoffs["gh-1"] = &handoff{from: "engineer-1", model: "claude-haiku-4-5", memo: "memo"}
+
+	h := orc.takeHandoff(2, "gh-1")
+	if h == nil || h.memo != "memo" {
+		t.Fatalf("takeHandoff = %+v", h)
+	}
+	cfg, err := orc.engineerAgentConfig(orc.Config(), 2, "gh-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Model != "claude-haiku-4-5" {
+		t.Errorf("model = %q, want the override", cfg.Model)
+	}
+	// Other teams keep the configured model.
+	if cfg, _ := orc.engineerAgentConfig(orc.Config(), 3, "gh-2"); cfg.Model == "claude-haiku-4-5" {
+		t.Error("override applied to another team")
+	}
+}
@@ -0,0 +1,86 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/release"
+)
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "RELEASE",
+		usage:   "RELEASE [--dry-run] [--bump=major|minor|patch] [--version=X.Y.Z]",
+		summary: "release develop: pre-flight checks, merge into main, changelog, version tag and push, rolled back if any repository fails",
+		options: []string{"dry-run", "bump", "version"},
+		handle:  (*Orchestrator).handleRelease,
+	})
+}
+
+// handleRelease runs the release pipeline (see internal/release) over all
+// repositories in the background. The outcome is reported with a DONE or
+// NACK reply.
+func (o *Orchestrator) handleRelease(ctx context.Context, cmd Command) (string, error) {
+	opts := release.Options{
+		DryRun:  cmd.Option("dry-run") == "true",
+		Version: cmd.Option("version"),
+	}
+	if b := cmd.Option("bump"); b != "" {
+		if opts.Version != "" {
+			return "", fmt.Errorf("RELEASE は拒否されました: --bump と --version は同時に指定できません")
+		}
+		level, err := release.ParseLevel(b)
+		if err != nil {
+			return "", fmt.Errorf("RELEASE は拒否されました: %v", err)
+		}
+		opts.Bump = level
+	}
+	if !o.releasing.CompareAndSwap(false, true) {
+		return "", fmt.Errorf("RELEASE は拒否されました: リリースは既に進行中です")
+	}
+
+	cfg := o.Config()
+	issues, err := o.store.List(issue.StatusFilter{})
+	if err != nil {
+		log.Printf("[orchestrator] RELEASE: list issues: %v", err)
+	}
+	p := &release.Pipeline{
+		Repos:    o.repos,
+		Branches: cfg.Branches,
+		Config:   cfg.Release,
+		Issues:   issues,
+		Redactor: o.redactor,
+	}
+	if cfg.GitHub != nil {
+		p.GitHub = release.GHCLI{}
+	}
+
+	go func() {
+		defer o.releasing.Store(false)
+		res, err := p.Run(context.WithoutCancel(ctx), opts)
+		<-cmd.replied
+		if err != nil {
+			log.Printf("[orchestrator] RELEASE failed: %v", err)
+			o.replyCommand(cmd, replyNACK, fmt.Sprintf("RELEASE に失敗しました: %v", err))
+			return
+		}
+		for _, plan := range res.Plans {
+			log.Printf("[orchestrator] RELEASE %s %s notes:\n%s", plan.Repo, plan.Tag, plan.Notes)
+		}
+		text := "RELEASE 完了: " + res.Summary()
+		if opts.DryRun {
+			text = "RELEASE --dry-run (変更なし): " + res.Summary()
+		} else if cfg.Release.NoPush {
+			text += " (release.no_push のため push していません)"
+		}
+		if len(res.Warnings) > 0 {
+			text += " 警告: " + strings.Join(res.Warnings, "; ")
+		}
+		o.replyCommand(cmd, replyDONE, text)
+	}()
+
+	return "RELEASE: 受信しました。事前チェックを開始します。", nil
+}
@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runGit runs git in dir and fails the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newReleaseTestOrchestrator returns an orchestrator whose only repository
+// has develop one commit ahead of main and no remote.
+func newReleaseTestOrchestrator(t *testing.T) (*Orchestrator, string, string) {
+	t.Helper()
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test User"},
+		{"commit", "--allow-empty", "-m", "initial commit"},
+		{"checkout", "-b", "develop"},
+		{"commit", "--allow-empty", "-m", "feat: first feature"},
+	} {
+		runGit(t, repoDir, args...)
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "issues"), 0755)
+	cfg := testConfig(repoDir)
+	cfg.Release.TagPrefix = "v"
+	cfg.Release.Changelog = "CHANGELOG.md"
+	cfg.Release.NoPush = true
+	return New(cfg, dir, t.TempDir()), dir, repoDir
+}
+
+func waitForReply(t *testing.T, dir, marker string) string {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		log := readChatlog(t, dir)
+		if strings.Contains(log, marker) {
+			return log
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %q reply:\n%s", marker, log)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestReleaseCommand(t *testing.T) {
+	orc, dir, repoDir := newReleaseTestOrchestrator(t)
+
+	sendCommand(orc, t.Context(), "RELEASE --dry-run --id=r1")
+	log := waitForReply(t, dir, "id=r1 RELEASE --dry-run")
+	if !strings.Contains(log, "ACK id=r1") || !strings.Contains(log, "DONE id=r1") || !strings.Contains(log, "main v0.1.0 (minor") {
+		t.Fatalf("unexpected dry-run replies:\n%s", log)
+	}
+	if out, _ := exec.Command("git", "-C", repoDir, "tag").Output(); len(out) != 0 {
+		t.Fatalf("dry run created tags: %s", out)
+	}
+
+	sendCommand(orc, t.Context(), "RELEASE --bump=major --id=r2")
+	log = waitForReply(t, dir, "DONE id=r2")
+	if !strings.Contains(log, "RELEASE 完了: main v1.0.0 (major") || !strings.Contains(log, "push していません") {
+		t.Fatalf("unexpected release reply:\n%s", log)
+	}
+	out, err := exec.Command("git", "-C", repoDir, "tag").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "v1.0.0" {
+		t.Errorf("tags = %q, %v; want v1.0.0", out, err)
+	}
+	if orc.releasing.Load() {
+		t.Error("releasing should be cleared after the release")
+	}
+
+	sendCommand(orc, t.Context(), "RELEASE --id=r3")
+	log = waitForReply(t, dir, "NACK id=r3")
+	if !strings.Contains(log, "develop has no commits") {
+		t.Errorf("second release should fail the pre-flight check:\n%s", log)
+	}
+}
+
+func TestReleaseCommandRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"bad bump", "RELEASE --bump=huge --id=x", "invalid bump"},
+		{"bump and version", "RELEASE --bump=minor --version=2.0.0 --id=x", "同時に指定できません"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orc, dir, _ := newReleaseTestOrchestrator(t)
+			sendCommand(orc, t.Context(), tt.body)
+			log := readChatlog(t, dir)
+			if !strings.Contains(log, "NACK id=x") || !strings.Contains(log, tt.want) {
+				t.Errorf("chatlog missing NACK %q:\n%s", tt.want, log)
+			}
+		})
+	}
+
+	orc, dir, _ := newReleaseTestOrchestrator(t)
+	orc.releasing.Store(true)
+	sendCommand(orc, t.Context(), "RELEASE --id=busy")
+	if log := readChatlog(t, dir); !strings.Contains(log, "NACK id=busy") {
+		t.Errorf("concurrent RELEASE should be rejected:\n%s", log)
+	}
+}
@@ -0,0 +1,337 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/screen"
+)
+
+// periodicLoop is a background goroutine whose schedule depends on the config.
+// On hot-reload, loops whose keys changed are stopped and started again with
+// the new config.
+type periodicLoop struct {
+	name string
+	// keys are config keys (or key prefixes ending in ".") that the loop reads
+	// from the config it is started with.
+	keys    []string
+	enabled func(cfg *config.Config) bool
+	run     func(o *Orchestrator, ctx context.Context, cfg *config.Config)
+}
+
+func always(*config.Config) bool { return true }
+
+func githubEnabled(cfg *config.Config) bool { return cfg.GitHub != nil }
+
+// githubKeys are read by the GitHub sync and the event watcher.
+var githubKeys = []string{"github", "github.", "authorized_users", "screening."}
+
+// periodicLoops lists the loops started by Run after the resident agents are ready.
+var periodicLoops = []periodicLoop{
+	{
+		name:    "github-sync",
+		keys:    githubKeys,
+		enabled: githubEnabled,
+		run:     (*Orchestrator).runGitHubSync,
+	},
+	{
+		name:    "event-watcher",
+		keys:    githubKeys,
+		enabled: githubEnabled,
+		run:     (*Orchestrator).runEventWatcher,
+	},
+	{
+		name:    "chatlog-cleanup",
+		keys:    []string{"agent.chatlog_max_lines", "agent.context_reset_minutes"},
+		enabled: always,
+		run:     (*Orchestrator).runChatlogCleanup,
+	},
+	{
+		name:    "worktree-cleanup",
+		keys:    []string{"agent.worktree_cleanup_interval_minutes"},
+		enabled: func(cfg *config.Config) bool { return cfg.Agent.WorktreeCleanupIntervalMinutes > 0 },
+		run:     (*Orchestrator).runWorktreeCleanup,
+	},
+	{
+		name:    "branch-cleanup",
+		keys:    []string{"branches."},
+		enabled: func(cfg *config.Config) bool { return cfg.Branches.CleanupIntervalMinutes > 0 },
+		run:     (*Orchestrator).runBranchCleanup,
+	},
+	{
+		name:    "branch-update",
+		keys:    []string{"branches."},
+		enabled: func(cfg *config.Config) bool { return cfg.Branches.UpdateIntervalMinutes > 0 },
+		run:     (*Orchestrator).runBranchUpdate,
+	},
+	{
+		name:    "integration-check",
+		keys:    []string{"integration_check.", "branches."},
+		enabled: func(cfg *config.Config) bool { return cfg.IntegrationCheck.IntervalMinutes > 0 },
+		run:     (*Orchestrator).runIntegrationCheck,
+	},
+	{
+		name:    "merged-worktree-cleanup",
+		keys:    []string{"agent.merged_worktree_cleanup_interval_minutes"},
+		enabled: func(cfg *config.Config) bool { return cfg.Agent.MergedWorktreeCleanupIntervalMinutes > 0 },
+		run:     (*Orchestrator).runMergedWorktreeCleanup,
+	},
+	{
+		name:    "main-check",
+		keys:    []string{"agent.main_check_interval_hours"},
+		enabled: func(cfg *config.Config) bool { return cfg.Agent.MainCheckIntervalHours > 0 },
+		run:     (*Orchestrator).runMainCheck,
+	},
+	{
+		name:    "doc-check",
+		keys:    []string{"agent.doc_check_interval_hours"},
+		enabled: func(cfg *config.Config) bool { return cfg.Agent.DocCheckIntervalHours > 0 },
+		run:     (*Orchestrator).runDocCheck,
+	},
+	{
+		name:    "issue-patrol",
+		keys:    []string{"agent.issue_patrol_interval_minutes"},
+		enabled: func(cfg *config.Config) bool { return cfg.Agent.IssuePatrolIntervalMinutes > 0 },
+		run:     (*Orchestrator).runIssuePatrol,
+	},
+}
+
+// agentKeys are baked into an agent's process and system prompt. Changes are
+// applied by reconfiguring the agents at their next context reset.
+var agentKeys = []string{
+	"agent.models.",
+	"agent.extra_prompt",
+	"agent.bash_timeout_minutes",
+	"agent.context_reset_minutes",
+	"agent.language",
+	"branches.main",
+	"branches.develop",
+	"branches.feature_prefix",
+}
+
+// immediateKeys are applied as soon as the new config is swapped in.
+// [release] and [verify] are read by each RELEASE and VERIFY command, and
+// [git_identity] and [guardrails] when a team is created.
+var immediateKeys = []string{"agent.max_teams", "release.", "verify.", "git_identity.", "guardrails."}
+
+// inertKeys are not used by a running MADFLOW ([presets] is only read by
+// `madflow use`), so changes need no action.
+var inertKeys = []string{"presets"}
+
+// matchKey reports whether key is one of keys. Entries ending in "." match
+// every key below that section.
+func matchKey(key string, keys []string) bool {
+	for _, k := range keys {
+		if key == k || (strings.HasSuffix(k, ".") && strings.HasPrefix(key, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// loopGroup runs the periodic loops and restarts them on demand.
+// A nil *loopGroup ignores restarts (Run has not started the loops).
+type loopGroup struct {
+	ctx context.Context
+	wg  *sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]*runningLoop
+}
+
+type runningLoop struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newLoopGroup(ctx context.Context, wg *sync.WaitGroup) *loopGroup {
+	return &loopGroup{ctx: ctx, wg: wg, running: make(map[string]*runningLoop)}
+}
+
+// start runs l if it is enabled in cfg and not already running.
+func (g *loopGroup) start(o *Orchestrator, l periodicLoop, cfg *config.Config) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.running[l.name]; ok || !l.enabled(cfg) || g.ctx.Err() != nil {
+		return false
+	}
+	ctx, cancel := context.WithCancel(g.ctx)
+	r := &runningLoop{cancel: cancel, done: make(chan struct{})}
+	g.running[l.name] = r
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer close(r.done)
+		l.run(o, ctx, cfg)
+	}()
+	return true
+}
+
+// stop cancels l and waits for it to return. It reports whether l was running.
+func (g *loopGroup) stop(name string) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	r, ok := g.running[name]
+	delete(g.running, name)
+	g.mu.Unlock()
+	if !ok {
+		return false
+	}
+	r.cancel()
+	<-r.done
+	return true
+}
+
+// reloadReport summarizes how a config change was applied.
+type reloadReport struct {
+	// Applied lists changes that took effect immediately.
+	Applied []string
+	// Rescheduled lists periodic loops that were restarted, stopped or started.
+	Rescheduled []string
+	// Agents lists agents that pick up the new config at their next context reset.
+	Agents []string
+	// RequiresRestart lists changes that only take effect after restarting MADFLOW.
+	RequiresRestart []string
+}
+
+func (r reloadReport) String() string {
+	var parts []string
+	add := func(label string, items []string) {
+		if len(items) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", label, strings.Join(items, ", ")))
+		}
+	}
+	add("applied", r.Applied)
+	add("rescheduled", r.Rescheduled)
+	add("agents reconfigured at next context reset", r.Agents)
+	add("requires restart", r.RequiresRestart)
+	if len(parts) == 0 {
+		return "no effective changes"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// applyConfig swaps in newCfg and applies every difference from the current
+// config that can be applied at runtime.
+func (o *Orchestrator) applyConfig(newCfg *config.Config) reloadReport {
+	o.cfgMu.Lock()
+	oldCfg := o.cfg
+	o.cfg = newCfg
+	o.cfgMu.Unlock()
+
+	var report reloadReport
+	reconfigureAgents := false
+	restartLoops := make(map[string]bool)
+	for _, c := range config.Diff(oldCfg, newCfg) {
+		if matchKey(c.Key, inertKeys) {
+			continue
+		}
+		handled := false
+		if matchKey(c.Key, immediateKeys) {
+			report.Applied = append(report.Applied, c.String())
+			handled = true
+		}
+		if matchKey(c.Key, agentKeys) {
+			reconfigureAgents = true
+			handled = true
+		}
+		for _, l := range periodicLoops {
+			if matchKey(c.Key, l.keys) {
+				restartLoops[l.name] = true
+				handled = true
+			}
+		}
+		if !handled {
+			report.RequiresRestart = append(report.RequiresRestart, c.Key)
+		}
+	}
+
+	// Propagate max_teams changes to the team manager so that
+	// hot-reload updates take effect without restarting the process.
+	o.teams.SetMaxTeams(newCfg.Agent.MaxTeams)
+
+	o.mu.Lock()
+	loops := o.loops
+	o.mu.Unlock()
+
+	// Stop every affected loop before changing state they read at startup.
+	stopped := make(map[string]bool)
+	for _, l := range periodicLoops {
+		if restartLoops[l.name] {
+			stopped[l.name] = loops.stop(l.name)
+		}
+	}
+	if !reflect.DeepEqual(oldCfg.Screening, newCfg.Screening) {
+		var screener *screen.Screener
+		if !newCfg.Screening.Disabled {
+			screener = newScreener(newCfg.Screening)
+		}
+		o.cfgMu.Lock()
+		o.screener = screener
+		o.cfgMu.Unlock()
+	}
+	for _, l := range periodicLoops {
+		if !restartLoops[l.name] {
+			continue
+		}
+		started := loops.start(o, l, newCfg)
+		switch {
+		case stopped[l.name] && started:
+			report.Rescheduled = append(report.Rescheduled, l.name)
+		case stopped[l.name]:
+			report.Rescheduled = append(report.Rescheduled, l.name+" (stopped)")
+		case started:
+			report.Rescheduled = append(report.Rescheduled, l.name+" (started)")
+		}
+	}
+
+	if reconfigureAgents {
+		report.Agents = o.reconfigureAgents(newCfg)
+	}
+	return report
+}
+
+// reconfigureAgents schedules the new configuration on the resident agents
+// and on every running engineer. It returns the IDs of the agents updated.
+func (o *Orchestrator) reconfigureAgents(cfg *config.Config) []string {
+	var ids []string
+
+	o.mu.Lock()
+	residents := append([]*agent.Agent(nil), o.residentAgents...)
+	o.mu.Unlock()
+	for _, ag := range residents {
+		agentCfg, err := o.residentAgentConfig(cfg, ag.ID.Role)
+		if err != nil {
+			log.Printf("[config-watcher] reconfigure %s: %v", ag.ID.String(), err)
+			continue
+		}
+		ag.Reconfigure(agentCfg)
+		ids = append(ids, ag.ID.String())
+	}
+
+	for _, info := range o.teams.List() {
+		ag, ok := o.teams.Engineer(info.ID)
+		if !ok {
+			continue
+		}
+		agentCfg, err := o.engineerAgentConfig(cfg, info.ID, info.IssueID)
+		if err != nil {
+			log.Printf("[config-watcher] reconfigure %s: %v", ag.ID.String(), err)
+			continue
+		}
+		ag.Reconfigure(agentCfg)
+		ids = append(ids, ag.ID.String())
+	}
+	return ids
+}
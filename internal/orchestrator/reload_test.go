@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/agent"
+)
+
+func TestApplyConfigReport(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	cfg.Agent.MaxTeams = 2
+	cfg.Agent.MainCheckIntervalHours = 6
+	orc := New(cfg, dir, t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	orc.loops = newLoopGroup(ctx, &wg)
+	for _, l := range periodicLoops {
+		orc.loops.start(orc, l, cfg)
+	}
+
+	residentCfg, err := orc.residentAgentConfig(cfg, agent.RoleSuperintendent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	residentCfg.Model = "test"
+	sup := agent.NewAgent(residentCfg)
+	orc.residentAgents = append(orc.residentAgents, sup)
+
+	updated := *cfg
+	updated.Agent.MaxTeams = 5
+	updated.Agent.Models.Superintendent = "claude-sonnet-4-6"
+	updated.Agent.MainCheckIntervalHours = 0
+	updated.Agent.DocCheckIntervalHours = 12
+	updated.Project.Name = "renamed"
+
+	report := orc.applyConfig(&updated)
+
+	if orc.Config() != &updated {
+		t.Error("config not swapped")
+	}
+	if orc.Teams().Cap() != 5 {
+		t.Errorf("Cap() = %d, want 5", orc.Teams().Cap())
+	}
+	if len(report.Applied) != 1 || !strings.HasPrefix(report.Applied[0], "agent.max_teams") {
+		t.Errorf("Applied = %v", report.Applied)
+	}
+	if want := []string{"main-check (stopped)", "doc-check (started)"}; !slices.Equal(report.Rescheduled, want) {
+		t.Errorf("Rescheduled = %v, want %v", report.Rescheduled, want)
+	}
+	if !slices.Equal(report.Agents, []string{"superintendent"}) || !sup.HasPendingConfig() {
+		t.Errorf("Agents = %v, pending = %v", report.Agents, sup.HasPendingConfig())
+	}
+	if !slices.Equal(report.RequiresRestart, []string{"project.name"}) {
+		t.Errorf("RequiresRestart = %v", report.RequiresRestart)
+	}
+	if s := report.String(); !strings.Contains(s, "requires restart: project.name") {
+		t.Errorf("String() = %q", s)
+	}
+
+	orc.loops.mu.Lock()
+	_, mainRunning := orc.loops.running["main-check"]
+	_, docRunning := orc.loops.running["doc-check"]
+	orc.loops.mu.Unlock()
+	if mainRunning || !docRunning {
+		t.Errorf("running loops: main-check=%v doc-check=%v", mainRunning, docRunning)
+	}
+}
+
+func TestApplyConfigWithoutLoops(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	orc := New(cfg, dir, t.TempDir())
+
+	updated := *cfg
+	updated.Agent.IssuePatrolIntervalMinutes = 10
+	report := orc.applyConfig(&updated)
+	if len(report.Rescheduled) != 0 || len(report.RequiresRestart) != 0 {
+		t.Errorf("unexpected report before Run: %+v", report)
+	}
+	if got := (reloadReport{}).String(); got != "no effective changes" {
+		t.Errorf("empty report = %q", got)
+	}
+}
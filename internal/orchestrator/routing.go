@@ -0,0 +1,295 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/teamgit"
+	"github.com/ytnobody/madflow/internal/worktreepool"
+)
+
+// repoLabelPrefix marks an issue label that names a repository, e.g.
+// "repo:api".
+const repoLabelPrefix = "repo:"
+
+// issueRepos returns the configured repositories an issue involves, in
+// config order. They are taken from the issue's repos field, its "repo:"
+// labels and, for GitHub issues, the repository the issue was filed in.
+// Unknown names are logged and ignored. An issue that names no repository
+// involves the first one.
+func issueRepos(cfg *config.Config, iss *issue.Issue) []config.RepoConfig {
+	if len(cfg.Project.Repos) == 0 {
+		return nil
+	}
+	if iss == nil {
+		return cfg.Project.Repos[:1]
+	}
+
+	var names []string
+	names = append(names, iss.Repos...)
+	for _, l := range iss.Labels {
+		if name, ok := strings.CutPrefix(strings.ToLower(l), repoLabelPrefix); ok {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	involved := make([]bool, len(cfg.Project.Repos))
+	found := false
+	for _, name := range names {
+		i := findRepo(cfg, name)
+		if i < 0 {
+			log.Printf("[orchestrator] issue %s names unknown repository %q; ignoring it", iss.ID, name)
+			continue
+		}
+		involved[i], found = true, true
+	}
+	if gh := cfg.GitHub; gh != nil {
+		if name := githubSourceRepo(gh, iss.ID); name != "" {
+			if i := findRepo(cfg, name); i >= 0 {
+				involved[i], found = true, true
+			}
+		}
+	}
+	if !found {
+		return cfg.Project.Repos[:1]
+	}
+
+	var repos []config.RepoConfig
+	for i, r := range cfg.Project.Repos {
+		if involved[i] {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+// findRepo returns the index of the repository called name (compared
+// case-insensitively with its name and the base name of its path), or -1.
+func findRepo(cfg *config.Config, name string) int {
+	for i, r := range cfg.Project.Repos {
+		if strings.EqualFold(r.Name, name) || strings.EqualFold(filepath.Base(r.Path), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// githubSourceRepo returns the [github] repository a GitHub issue ID
+// ("<owner>-<repo>-<number>") belongs to, or "" for other issues.
+func githubSourceRepo(gh *config.GitHubConfig, issueID string) string {
+	best := ""
+	for _, r := range gh.Repos {
+		prefix := strings.ToLower(gh.Owner + "-" + r + "-")
+		if strings.HasPrefix(strings.ToLower(issueID), prefix) && len(r) > len(best) {
+			best = r
+		}
+	}
+	return best
+}
+
+// teamRepos returns the repositories of cfg the team working on issueID
+// uses.
+func (o *Orchestrator) teamRepos(cfg *config.Config, issueID string) []config.RepoConfig {
+	iss, err := o.store.Get(issueID)
+	if err != nil {
+		iss = nil
+	}
+	return issueRepos(cfg, iss)
+}
+
+// teamWorktreePath returns the worktree of team teamNum for issueID in the
+// repository at repoPath: .worktrees/<gh_login>/issue-<id>, or the legacy
+// .worktrees/team-<n> when the GitHub login is unknown.
+func teamWorktreePath(repoPath, ghLogin string, teamNum int, issueID string) string {
+	if ghLogin == "" {
+		return filepath.Join(repoPath, ".worktrees", fmt.Sprintf("team-%d", teamNum))
+	}
+	return filepath.Join(repoPath, ".worktrees", ghLogin, "issue-"+issueID)
+}
+
+// prepareTeamWorktrees creates the worktree of a team in each repository on
+// the issue's feature branch. An existing worktree is kept, and an existing
+// branch (e.g. after TEAM_REASSIGN) is checked out instead of created from
+// develop; when another worktree under .worktrees still has it checked out,
+// such as the team-<n> worktree of the previous team, that worktree is moved
+// to the team's path. A new branch starts at the latest remote develop when
+// there is one, in a worktree taken from the repository's pool if it has a
+// ready one. Failures are logged: the engineer can still create the worktree
+// as its prompt describes.
+func (o *Orchestrator) prepareTeamWorktrees(teamNum int, issueID string, repos []config.RepoConfig) {
+	cfg := o.Config()
+	branch := cfg.Branches.FeaturePrefix + issueID
+	for _, r := range repos {
+		path := teamWorktreePath(r.Path, cfg.GhLogin, teamNum, issueID)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		repo, ok := o.repos[r.Name]
+		if !ok {
+			repo = git.NewRepo(r.Path)
+		}
+		var err error
+		if kept := keptWorktree(repo, r.Path, branch); kept != "" {
+			err = repo.MoveWorktree(kept, path)
+		} else if repo.BranchExists(branch) {
+			err = repo.AddWorktreeForBranch(path, branch)
+		} else {
+			err = o.newTeamWorktree(r.Name, repo, path, branch)
+		}
+		if err != nil {
+			log.Printf("[orchestrator] team %d: worktree for %s in %s not created: %v", teamNum, issueID, r.Name, err)
+			continue
+		}
+		log.Printf("[orchestrator] team %d: created worktree %s (%s)", teamNum, path, branch)
+	}
+}
+
+// keptWorktree returns the worktree under the .worktrees directory of the
+// repository at repoPath that has branch checked out, or "".
+func keptWorktree(repo *git.Repo, repoPath, branch string) string {
+	wt, err := repo.BranchWorktree(branch)
+	if err != nil || wt == "" {
+		return ""
+	}
+	root, err := filepath.EvalSymlinks(filepath.Join(repoPath, ".worktrees"))
+	if err != nil {
+		return ""
+	}
+	real, err := filepath.EvalSymlinks(wt)
+	if err != nil {
+		return ""
+	}
+	if rel, err := filepath.Rel(root, real); err != nil || !filepath.IsLocal(rel) {
+		return ""
+	}
+	return wt
+}
+
+// setupTeamGit gives the team's worktrees the team's git identity, the
+// commit-msg hook that adds the Madflow-* trailers (see [git_identity]) and
+// the guard hooks (see [guardrails]). Failures are logged; commits are then
+// made with the host's identity and without the guard.
+func (o *Orchestrator) setupTeamGit(cfg *config.Config, teamNum int, issueID, model string, repos []config.RepoConfig) {
+	agentID := agent.AgentID{Role: agent.RoleEngineer, TeamNum: teamNum}.String()
+	var opts teamgit.Options
+	if !cfg.GitIdentity.Disabled {
+		expand := strings.NewReplacer(
+			"{agent}", agentID,
+			"{team}", strconv.Itoa(teamNum),
+			"{issue}", issueID,
+			"{model}", model,
+			"{login}", cfg.GhLogin,
+		).Replace
+		opts.Name = expand(cfg.GitIdentity.Name)
+		opts.Email = expand(cfg.GitIdentity.Email)
+		opts.Trailers = []teamgit.Trailer{
+			{Key: "Madflow-Issue", Value: issueID},
+			{Key: "Madflow-Team", Value: strconv.Itoa(teamNum)},
+			{Key: "Madflow-Model", Value: model},
+		}
+	}
+	if g := cfg.Guardrails; !g.Disabled {
+		opts.Guard = &teamgit.Guard{
+			Branch:         cfg.Branches.FeaturePrefix + issueID,
+			AllowForcePush: g.AllowForcePush,
+			ProtectedPaths: g.ProtectedPaths,
+			MaxDiffLines:   g.MaxDiffLines,
+			Base:           cfg.Branches.Develop,
+			ApprovalDir:    o.guardApprovalDir,
+			ChatlogPath:    o.ChatLogPath(),
+			AgentID:        agentID,
+		}
+	}
+	if opts.Trailers == nil && opts.Guard == nil {
+		return
+	}
+	for _, r := range repos {
+		path := teamWorktreePath(r.Path, cfg.GhLogin, teamNum, issueID)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := teamgit.Setup(git.NewRepo(path), opts); err != nil {
+			log.Printf("[orchestrator] team %d: git identity and guardrails for %s not set: %v", teamNum, r.Name, err)
+		}
+	}
+}
+
+// newTeamWorktree creates a worktree at path on the new branch, starting at
+// the latest develop.
+func (o *Orchestrator) newTeamWorktree(name string, repo *git.Repo, path, branch string) error {
+	cfg := o.Config()
+	base, err := latestDevelop(repo, cfg.Branches)
+	if err != nil {
+		return err
+	}
+
+	if pool := o.pools[name]; pool != nil {
+		err := pool.Take(path, branch, base)
+		if err == nil {
+			log.Printf("[orchestrator] %s: took %s from the worktree pool", name, path)
+			return nil
+		}
+		if !errors.Is(err, worktreepool.ErrEmpty) {
+			log.Printf("[orchestrator] %s: pooled worktree not usable: %v", name, err)
+		}
+	}
+
+	if err := repo.PrepareWorktree(path, branch, cfg.Branches.Develop, cfg.Branches.Main); err != nil {
+		return err
+	}
+	if base != cfg.Branches.Develop {
+		return git.NewRepo(path).ResetBranch(branch, base)
+	}
+	return nil
+}
+
+// latestDevelop fetches origin and returns the latest develop: origin's
+// develop branch when the remote has one (engineers start from it too),
+// otherwise the local develop branch, created from main if needed.
+func latestDevelop(repo *git.Repo, branches config.BranchConfig) (string, error) {
+	if repo.HasRemote("origin") && repo.Fetch("origin") == nil && repo.BranchExists("origin/"+branches.Develop) {
+		return "origin/" + branches.Develop, nil
+	}
+	if err := repo.EnsureBranch(branches.Develop, branches.Main); err != nil {
+		return "", fmt.Errorf("ensure develop branch: %w", err)
+	}
+	return branches.Develop, nil
+}
+
+// repoTaskNote is appended to the task of an engineer whose issue involves
+// several repositories. Arguments: the repository list, the feature branch.
+const repoTaskNote = `
+
+## 対象リポジトリ
+このイシューは複数のリポジトリにまたがります。各リポジトリのワークツリーで作業してください。
+%s
+すべてのリポジトリで同じブランチ %s を使い、変更のある各リポジトリで PR を作成してください。
+各 PR の本文には他のリポジトリの PR の URL を "Linked PRs:" として記載してください。これらの PR は PR_MERGE によってまとめてマージされます。`
+
+// repoList renders the repositories of a team for its task: name, path and
+// worktree.
+func repoList(repos []config.RepoConfig, ghLogin string, teamNum int, issueID string) string {
+	var b strings.Builder
+	for _, r := range repos {
+		fmt.Fprintf(&b, "- %s: %s (ワークツリー: %s)\n", r.Name, r.Path, teamWorktreePath(r.Path, ghLogin, teamNum, issueID))
+	}
+	return b.String()
+}
+
+// repoNames returns the names of repos.
+func repoNames(repos []config.RepoConfig) []string {
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		names[i] = r.Name
+	}
+	return names
+}
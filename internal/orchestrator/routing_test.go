@@ -0,0 +1,234 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/team"
+	"github.com/ytnobody/madflow/internal/worktreepool"
+)
+
+func TestIssueRepos(t *testing.T) {
+	cfg := &config.Config{
+		Project: config.ProjectConfig{Repos: []config.RepoConfig{
+			{Name: "web", Path: "/src/frontend"},
+			{Name: "api", Path: "/src/api"},
+			{Name: "infra", Path: "/src/infra"},
+		}},
+		GitHub: &config.GitHubConfig{Owner: "acme", Repos: []string{"frontend", "api"}},
+	}
+	tests := []struct {
+		name string
+		iss  *issue.Issue
+		want []string
+	}{
+		{"unknown issue", nil, []string{"web"}},
+		{"no repos", &issue.Issue{ID: "local-001"}, []string{"web"}},
+		{"repos field", &issue.Issue{ID: "local-002", Repos: []string{"infra", "API"}}, []string{"api", "infra"}},
+		{"labels", &issue.Issue{ID: "local-003", Labels: []string{"bug", "repo:infra"}}, []string{"infra"}},
+		{"github source by path", &issue.Issue{ID: "acme-frontend-007"}, []string{"web"}},
+		{"github source and label", &issue.Issue{ID: "acme-api-012", Labels: []string{"Repo:web"}}, []string{"web", "api"}},
+		{"unknown name", &issue.Issue{ID: "local-004", Repos: []string{"mobile"}}, []string{"web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repoNames(issueRepos(cfg, tt.iss)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issueRepos = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newRoutingTestOrchestrator returns an orchestrator with two git
+// repositories, "app" and "lib", and an issue that involves both.
+func newRoutingTestOrchestrator(t *testing.T) (*Orchestrator, *issue.Issue) {
+	t.Helper()
+	var repos []config.RepoConfig
+	for _, name := range []string{"app", "lib"} {
+		dir := filepath.Join(t.TempDir(), name)
+		os.MkdirAll(dir, 0755)
+		runGit(t, dir, "init", "-b", "main")
+		runGit(t, dir, "config", "user.email", "test@test.com")
+		runGit(t, dir, "config", "user.name", "Test User")
+		runGit(t, dir, "commit", "--allow-empty", "-m", "initial commit")
+		repos = append(repos, config.RepoConfig{Name: name, Path: dir})
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "issues"), 0755)
+	cfg := testConfig(repos[0].Path)
+	cfg.Project.Repos = repos
+	cfg.Agent.Models.Engineer = "test"
+	cfg.GhLogin = "alice"
+	orc := New(cfg, dir, t.TempDir())
+
+	iss, err := orc.Store().Create("Cross-repo change", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss.Repos = []string{"app", "lib"}
+	orc.Store().Update(iss)
+	return orc, iss
+}
+
+func TestCreateTeamAgentsForMultiRepoIssue(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	cfg := orc.Config()
+
+	eng, err := orc.CreateTeamAgents(1, iss.ID)
+	if err != nil {
+		t.Fatalf("CreateTeamAgents: %v", err)
+	}
+	branch := "feature/issue-" + iss.ID
+	for _, r := range cfg.Project.Repos {
+		wt := teamWorktreePath(r.Path, "alice", 1, iss.ID)
+		if got := runGit(t, wt, "rev-parse", "--abbrev-ref", "HEAD"); got != branch {
+			t.Errorf("%s worktree is on %q, want %q", r.Name, got, branch)
+		}
+		if !strings.Contains(eng.OriginalTask, wt) {
+			t.Errorf("task does not mention the %s worktree %s:\n%s", r.Name, wt, eng.OriginalTask)
+		}
+	}
+	if !strings.Contains(eng.OriginalTask, "PR_MERGE") {
+		t.Errorf("task should explain linked PRs:\n%s", eng.OriginalTask)
+	}
+
+	// A second team for the same issue (e.g. after TEAM_REASSIGN) reuses
+	// the existing worktrees and branch.
+	if _, err := orc.CreateTeamAgents(2, iss.ID); err != nil {
+		t.Fatalf("CreateTeamAgents again: %v", err)
+	}
+	list := runGit(t, cfg.Project.Repos[1].Path, "worktree", "list")
+	if n := strings.Count(list, "\n") + 1; n != 2 {
+		t.Errorf("lib has %d worktrees, want 2:\n%s", n, list)
+	}
+}
+
+func TestCreateTeamAgentsSetsGitIdentity(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	orc.cfg.GitIdentity = config.GitIdentityConfig{Name: "madflow-{agent}", Email: "{login}+team{team}@example.com"}
+
+	if _, err := orc.CreateTeamAgents(3, iss.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range orc.Config().Project.Repos {
+		wt := teamWorktreePath(r.Path, "alice", 3, iss.ID)
+		runGit(t, wt, "commit", "--allow-empty", "-m", "feat: change")
+		if got := runGit(t, wt, "log", "-1", "--format=%an <%ae>"); got != "madflow-engineer-3 <alice+team3@example.com>" {
+			t.Errorf("%s: author = %q", r.Name, got)
+		}
+		msg := runGit(t, wt, "log", "-1", "--format=%B")
+		for _, want := range []string{"Madflow-Issue: " + iss.ID, "Madflow-Team: 3", "Madflow-Model: test"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("%s: message lacks %q:\n%s", r.Name, want, msg)
+			}
+		}
+	}
+
+	// Disabled: the next team commits with the host identity.
+	orc.cfg.GitIdentity.Disabled = true
+	other, _ := orc.Store().Create("Other", "body")
+	other.Repos = []string{"app"}
+	orc.Store().Update(other)
+	if _, err := orc.CreateTeamAgents(4, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	wt := teamWorktreePath(orc.Config().Project.Repos[0].Path, "alice", 4, other.ID)
+	runGit(t, wt, "commit", "--allow-empty", "-m", "chore: change")
+	if got := runGit(t, wt, "log", "-1", "--format=%an%n%B"); got != "Test User\nchore: change" {
+		t.Errorf("with git_identity disabled, commit = %q", got)
+	}
+}
+
+func TestIdleTeamAssignmentSetsUpTeam(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	orc.cfg.GitIdentity = config.GitIdentityConfig{Name: "madflow-{agent}", Email: "{login}+team{team}@example.com"}
+	orc.teams = team.NewManager(newMockTeamFactory(t), 1)
+	standby, err := orc.Teams().Create(t.Context(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendCommand(orc, t.Context(), "TEAM_CREATE "+iss.ID)
+	if got, _ := orc.Store().Get(iss.ID); got.AssignedTeam != standby.ID {
+		t.Fatalf("issue assigned to team %d, want idle team %d", got.AssignedTeam, standby.ID)
+	}
+
+	// The idle team gets worktrees with its own identity and trailers.
+	for _, r := range orc.Config().Project.Repos {
+		wt := teamWorktreePath(r.Path, "alice", standby.ID, iss.ID)
+		runGit(t, wt, "commit", "--allow-empty", "-m", "feat: change")
+		if got := runGit(t, wt, "log", "-1", "--format=%an"); got != fmt.Sprintf("madflow-engineer-%d", standby.ID) {
+			t.Errorf("%s: author = %q", r.Name, got)
+		}
+		if msg := runGit(t, wt, "log", "-1", "--format=%B"); !strings.Contains(msg, "Madflow-Issue: "+iss.ID) {
+			t.Errorf("%s: message lacks the issue trailer:\n%s", r.Name, msg)
+		}
+	}
+
+	// The assignment is recorded for the lessons statistics.
+	data, err := os.ReadFile(filepath.Join(orc.dataDir, "lessons-stats.json"))
+	if err != nil || !strings.Contains(string(data), iss.ID) {
+		t.Errorf("assignment of %s not recorded: %v\n%s", iss.ID, err, data)
+	}
+}
+
+func TestEngineerStartsInIssueRepo(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	iss.Repos = []string{"lib"}
+	orc.Store().Update(iss)
+
+	cfg := orc.Config()
+	agentCfg, err := orc.engineerAgentConfig(cfg, 1, iss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := cfg.Project.Repos[1].Path; agentCfg.WorkDir != want {
+		t.Errorf("WorkDir = %s, want %s", agentCfg.WorkDir, want)
+	}
+
+	eng, err := orc.CreateTeamAgents(1, iss.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(eng.OriginalTask, "対象リポジトリ") {
+		t.Errorf("single-repo task should not list repositories:\n%s", eng.OriginalTask)
+	}
+	if _, err := os.Stat(teamWorktreePath(cfg.Project.Repos[0].Path, "alice", 1, iss.ID)); !os.IsNotExist(err) {
+		t.Error("no worktree should be created in a repository the issue does not involve")
+	}
+}
+
+func TestCreateTeamAgentsTakesPooledWorktree(t *testing.T) {
+	orc, iss := newRoutingTestOrchestrator(t)
+	iss.Repos = []string{"app"}
+	orc.Store().Update(iss)
+	app := orc.Config().Project.Repos[0]
+	runGit(t, app.Path, "branch", "develop")
+
+	pool := worktreepool.New(orc.repos["app"], worktreepool.Options{Size: 1, Bootstrap: "echo ok > bootstrapped", Develop: "develop", Main: "main"})
+	orc.pools = map[string]*worktreepool.Pool{"app": pool}
+	pool.Start(t.Context())
+	pool.Wait()
+	t.Cleanup(pool.Wait)
+	if pool.Ready() != 1 {
+		t.Fatal("pool was not filled")
+	}
+
+	if _, err := orc.CreateTeamAgents(1, iss.ID); err != nil {
+		t.Fatal(err)
+	}
+	wt := teamWorktreePath(app.Path, "alice", 1, iss.ID)
+	if got := runGit(t, wt, "rev-parse", "--abbrev-ref", "HEAD"); got != "feature/issue-"+iss.ID {
+		t.Errorf("worktree is on %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(wt, "bootstrapped")); err != nil {
+		t.Errorf("team did not get the pooled worktree: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/agent"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/verify"
+)
+
+// VerifyDir is the directory inside the data directory that holds the
+// latest VERIFY report of each issue and repository.
+const VerifyDir = "verify"
+
+func init() {
+	registerCommand(commandSpec{
+		name:    "VERIFY",
+		usage:   "VERIFY <issue-id>",
+		summary: "run the [verify] build, lint and test commands in the team's worktrees; the branch is ready for review once they pass",
+		minArgs: 1,
+		handle:  (*Orchestrator).handleVerify,
+	})
+}
+
+// handleVerify runs the configured verification commands in the team's
+// worktree of every repository of the issue that has [verify] commands.
+// The commands run in the background; the outcome is reported with a DONE
+// or NACK reply and sent to the superintendent and the team's engineer.
+func (o *Orchestrator) handleVerify(ctx context.Context, cmd Command) (string, error) {
+	issueID := normalizeIssueID(cmd.Arg(0))
+	if issueID == "" {
+		return "", fmt.Errorf("VERIFY は拒否されました: イシューIDが不正です: %s", cmd.Arg(0))
+	}
+	if _, err := o.store.Get(issueID); err != nil {
+		return "", fmt.Errorf("VERIFY %s は拒否されました: イシューが見つかりません", issueID)
+	}
+	teamNum, ok := o.teams.FindByIssue(issueID)
+	if !ok {
+		return "", fmt.Errorf("VERIFY %s は拒否されました: このイシューを担当するチームがありません", issueID)
+	}
+	cfg := o.Config()
+	var repos []config.RepoConfig
+	for _, r := range o.teamRepos(cfg, issueID) {
+		if cfg.Verify.For(r.Name).Enabled() {
+			repos = append(repos, r)
+		}
+	}
+	if len(repos) == 0 {
+		return "", fmt.Errorf("VERIFY %s は拒否されました: [verify] にコマンドが設定されていません", issueID)
+	}
+	if _, running := o.verifying.LoadOrStore(issueID, true); running {
+		return "", fmt.Errorf("VERIFY %s は拒否されました: 検証を実行中です", issueID)
+	}
+
+	go func() {
+		defer o.verifying.Delete(issueID)
+		o.verifyIssue(ctx, cfg, cmd, issueID, teamNum, repos)
+	}()
+
+	return fmt.Sprintf("VERIFY %s: 受信しました。%s で検証を実行します。", issueID, strings.Join(repoNames(repos), ", ")), nil
+}
+
+// verifyIssue runs the checks of each repository, in the agents' sandbox
+// when one is configured, records the verified commits on the issue when all
+// pass (and clears them otherwise) and reports the outcome. A repository
+// whose worktree changed while its checks ran fails: what passed may not be
+// what is committed.
+func (o *Orchestrator) verifyIssue(ctx context.Context, cfg *config.Config, cmd Command, issueID string, teamNum int, repos []config.RepoConfig) {
+	branch := cfg.Branches.FeaturePrefix + issueID
+	commits := make(map[string]string)
+	var passed, failed []string
+	sandbox, err := o.agentSandbox()
+	if err != nil {
+		failed = append(failed, err.Error())
+		repos = nil
+	}
+	for _, r := range repos {
+		v := cfg.Verify.For(r.Name)
+		path := teamWorktreePath(r.Path, cfg.GhLogin, teamNum, issueID)
+		commit, err := verifiableCommit(path, branch)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", r.Name, err))
+			continue
+		}
+		res := verify.Run(ctx, path, verify.Options{
+			Build:       v.Build,
+			Lint:        v.Lint,
+			Test:        v.Test,
+			Timeout:     time.Duration(v.TimeoutMinutes) * time.Minute,
+			MinCoverage: v.MinCoverage,
+			Command:     sandbox.ShellCommand,
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if after, err := verifiableCommit(path, branch); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: changed during verification: %v", r.Name, err))
+			continue
+		} else if after != commit {
+			failed = append(failed, fmt.Sprintf("%s: HEAD moved from %.12s to %.12s during verification", r.Name, commit, after))
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", r.Name, res.Summary())
+		reportPath, err := o.writeVerifyReport(issueID, r.Name, res.Markdown(fmt.Sprintf("%s in %s at %.12s", branch, r.Name, commit)))
+		if err != nil {
+			log.Printf("[verify] %s: %v", issueID, err)
+		} else {
+			line += " 詳細: " + reportPath
+		}
+		if !res.OK() {
+			failed = append(failed, line)
+			continue
+		}
+		commits[r.Name] = commit
+		passed = append(passed, line)
+	}
+
+	ok := len(failed) == 0
+	if iss, err := o.store.Get(issueID); err == nil {
+		iss.VerifiedCommits = nil
+		if ok {
+			iss.VerifiedCommits = commits
+		}
+		if err := o.store.Update(iss); err != nil {
+			log.Printf("[verify] %s: record verified commits: %v", issueID, err)
+		}
+	}
+
+	engineerID := agent.AgentID{Role: agent.RoleEngineer, TeamNum: teamNum}.String()
+	notify := func(recipient, text string) {
+		if recipient != cmd.Sender {
+			o.appendOrLog(recipient, "orchestrator", "[verify] "+text)
+		}
+	}
+	<-cmd.replied
+	if ok {
+		log.Printf("[verify] %s: passed: %s", issueID, strings.Join(passed, "; "))
+		o.replyCommand(cmd, replyDONE, fmt.Sprintf("VERIFY %s: 検証に合格しました (%s)。レビューを依頼できます。", issueID, strings.Join(passed, "; ")))
+		notify(engineerID, fmt.Sprintf("%s: 検証に合格しました (%s)。レビューを依頼してください。", issueID, strings.Join(passed, "; ")))
+		notify("superintendent", fmt.Sprintf("%s: %s の検証に合格しました (%s)。レビュー可能です。", issueID, branch, strings.Join(passed, "; ")))
+		return
+	}
+	log.Printf("[verify] %s: failed: %s", issueID, strings.Join(failed, "; "))
+	o.replyCommand(cmd, replyNACK, fmt.Sprintf("VERIFY %s: 検証に失敗しました: %s。修正をコミットしてから、もう一度 VERIFY を送ってください。", issueID, strings.Join(failed, "; ")))
+	notify(engineerID, fmt.Sprintf("%s: 検証に失敗しました: %s。修正をコミットしてから、もう一度 VERIFY を送ってください。", issueID, strings.Join(failed, "; ")))
+	notify("superintendent", fmt.Sprintf("%s: %s の検証に失敗しました: %s。合格するまでレビューと PR_MERGE は行わないでください。", issueID, branch, strings.Join(failed, "; ")))
+}
+
+// verifiableCommit returns the commit checked out in the worktree at path.
+// The worktree must be on branch and have no uncommitted changes, so that
+// what is verified is exactly that commit.
+func verifiableCommit(path, branch string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("worktree %s does not exist", path)
+	}
+	wt := git.NewRepo(path)
+	if cur, err := wt.CurrentBranch(); err != nil || cur != branch {
+		return "", fmt.Errorf("worktree %s is not on %s", path, branch)
+	}
+	if clean, err := wt.IsClean(); err != nil || !clean {
+		return "", fmt.Errorf("worktree %s has uncommitted changes; commit them first", path)
+	}
+	return wt.RevParse("HEAD")
+}
+
+// writeVerifyReport saves a VERIFY report in the data directory and returns
+// its path.
+func (o *Orchestrator) writeVerifyReport(issueID, repoName, report string) (string, error) {
+	dir := filepath.Join(o.dataDir, VerifyDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("write verify report: %w", err)
+	}
+	path := filepath.Join(dir, issueID+"-"+repoName+".md")
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		return "", fmt.Errorf("write verify report: %w", err)
+	}
+	return path, nil
+}
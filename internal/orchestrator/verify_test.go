@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/chatlog"
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/github"
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+// sendEngineerCommand sends body to the orchestrator as engineer-1.
+func sendEngineerCommand(orc *Orchestrator, t *testing.T, body string) {
+	orc.handleCommand(t.Context(), chatlog.Message{Sender: "engineer-1", Recipient: "orchestrator", Body: body})
+}
+
+func TestVerifyPassUnblocksPRMerge(t *testing.T) {
+	orc, app, wt := newBranchUpdateTestOrchestrator(t)
+	orc.cfg.Verify = config.VerifyConfig{
+		Build:          "test -f a.txt",
+		Test:           "echo 'ok  app  0.1s  coverage: 70.0% of statements'",
+		MinCoverage:    55,
+		TimeoutMinutes: 1,
+	}
+	iss, _ := orc.Store().List(issue.StatusFilter{})
+	issueID := iss[0].ID
+
+	sendEngineerCommand(orc, t, "VERIFY "+issueID+" --id=v1")
+	log := waitForReply(t, orc.dataDir, "DONE id=v1")
+	if !strings.Contains(log, "app: build ok, test ok, coverage 70.0% (min 55.0%)") {
+		t.Errorf("reply lacks the summary:\n%s", log)
+	}
+	if !strings.Contains(log, "[@superintendent] orchestrator: [verify] "+issueID) {
+		t.Errorf("the superintendent was not told:\n%s", log)
+	}
+	if _, err := os.Stat(filepath.Join(orc.dataDir, VerifyDir, issueID+"-app.md")); err != nil {
+		t.Errorf("no report: %v", err)
+	}
+	head := runGit(t, wt, "rev-parse", "HEAD")
+	got, _ := orc.Store().Get(issueID)
+	if got.VerifiedCommits["app"] != head {
+		t.Fatalf("VerifiedCommits = %v, want app=%s", got.VerifiedCommits, head)
+	}
+
+	// PR_MERGE accepts the verified commit only.
+	pr := greenPR(12)
+	pr.HeadRefOid = "0123abcd"
+	orc.pulls = &fakePulls{prs: map[string]*github.PullRequest{app: pr}}
+	sendCommand(orc, t.Context(), "PR_MERGE "+issueID+" --id=m1")
+	if log := waitForReply(t, orc.dataDir, "NACK id=m1"); !strings.Contains(log, "app#12 の最新コミットは VERIFY に合格していません") {
+		t.Errorf("unverified head not refused:\n%s", log)
+	}
+	pr.HeadRefOid = head
+	sendCommand(orc, t.Context(), "PR_MERGE "+issueID+" --id=m2")
+	waitForReply(t, orc.dataDir, "DONE id=m2")
+}
+
+func TestVerifyFailure(t *testing.T) {
+	orc, _, wt := newBranchUpdateTestOrchestrator(t)
+	orc.cfg.Verify = config.VerifyConfig{Build: "true", Lint: "echo 'a.txt:1: bad'; exit 1", Test: "true", TimeoutMinutes: 1}
+	iss, _ := orc.Store().List(issue.StatusFilter{})
+	issueID := iss[0].ID
+	iss[0].VerifiedCommits = map[string]string{"app": "stale"}
+	orc.Store().Update(iss[0])
+
+	sendEngineerCommand(orc, t, "VERIFY "+issueID+" --id=v2")
+	log := waitForReply(t, orc.dataDir, "NACK id=v2")
+	if !strings.Contains(log, "lint FAILED") || !strings.Contains(log, "test skipped") {
+		t.Errorf("reply lacks the failure:\n%s", log)
+	}
+	if !strings.Contains(log, "合格するまでレビューと PR_MERGE は行わないでください") {
+		t.Errorf("the superintendent was not told:\n%s", log)
+	}
+	if got, _ := orc.Store().Get(issueID); got.VerifiedCommits != nil {
+		t.Errorf("a failed VERIFY should clear VerifiedCommits, got %v", got.VerifiedCommits)
+	}
+	report, _ := os.ReadFile(filepath.Join(orc.dataDir, VerifyDir, issueID+"-app.md"))
+	if !strings.Contains(string(report), "a.txt:1: bad") {
+		t.Errorf("report lacks the lint output:\n%s", report)
+	}
+
+	// Uncommitted changes are not verified.
+	os.WriteFile(filepath.Join(wt, "b.txt"), []byte("wip\n"), 0644)
+	sendEngineerCommand(orc, t, "VERIFY "+issueID+" --id=v3")
+	if log := waitForReply(t, orc.dataDir, "NACK id=v3"); !strings.Contains(log, "uncommitted changes") {
+		t.Errorf("dirty worktree not refused:\n%s", log)
+	}
+
+	// A commit made while the checks run was not verified.
+	os.Remove(filepath.Join(wt, "b.txt"))
+	orc.cfg.Verify = config.VerifyConfig{Test: "git commit --allow-empty -q -m during", TimeoutMinutes: 1}
+	sendEngineerCommand(orc, t, "VERIFY "+issueID+" --id=v6")
+	if log := waitForReply(t, orc.dataDir, "NACK id=v6"); !strings.Contains(log, "during verification") {
+		t.Errorf("a commit during the checks not refused:\n%s", log)
+	}
+	if got, _ := orc.Store().Get(issueID); got.VerifiedCommits != nil {
+		t.Errorf("VerifiedCommits = %v, want none", got.VerifiedCommits)
+	}
+}
+
+func TestVerifyRejected(t *testing.T) {
+	orc, _, _ := newBranchUpdateTestOrchestrator(t)
+	iss, _ := orc.Store().List(issue.StatusFilter{})
+
+	sendEngineerCommand(orc, t, "VERIFY "+iss[0].ID+" --id=v4")
+	if log := readChatlog(t, orc.dataDir); !strings.Contains(log, "NACK id=v4") || !strings.Contains(log, "[verify] にコマンドが設定されていません") {
+		t.Errorf("VERIFY without [verify] should be refused:\n%s", log)
+	}
+
+	orc.cfg.Verify = config.VerifyConfig{Build: "true"}
+	other, _ := orc.Store().Create("Unassigned", "body")
+	sendEngineerCommand(orc, t, "VERIFY "+other.ID+" --id=v5")
+	if log := readChatlog(t, orc.dataDir); !strings.Contains(log, "NACK id=v5") || !strings.Contains(log, "担当するチームがありません") {
+		t.Errorf("VERIFY of an issue without a team should be refused:\n%s", log)
+	}
+}
@@ -0,0 +1,348 @@
+// Package redact masks credentials in text that MADFLOW writes to the chatlog
+// or sends to GitHub.
+//
+// A Redactor combines the built-in credential patterns with user-supplied
+// regular expressions and the literal values of selected environment
+// variables. It counts every masked value per destination so that prevented
+// leaks are visible in the logs.
+package redact
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask is the replacement text for a redacted value.
+const Mask = "***"
+
+// minLiteralLen is the shortest environment variable value that is redacted
+// literally. Shorter values ("1", "true", "dev") would mask ordinary words.
+const minLiteralLen = 8
+
+// builtinPatterns mask well-known credential formats. Each pattern captures
+// the non-secret prefix in group 1 so that it is kept for context.
+var builtinPatterns = []*regexp.Regexp{
+	// OpenAI / Anthropic-style secret keys: sk-<value>, sk-ant-<value>.
+	// Anchored at a word boundary so that words such as "task-scheduler" or
+	// "disk-usage" are left alone.
+	regexp.MustCompile(`\b(sk-)[A-Za-z0-9_-]{16,}`),
+	// Google / Gemini API keys: AIza<value>
+	regexp.MustCompile(`\b(AIza)[A-Za-z0-9_-]{16,}`),
+	// Bearer tokens in Authorization headers
+	regexp.MustCompile(`(?i)\b(Bearer\s+)[^\s"'&]{4,}`),
+	// URL query parameters: key=, api_key=, token=, access_token=, secret=
+	regexp.MustCompile(`(?i)(\b(?:key|api[_-]key|token|access[_-]token|secret)=)[^\s"'&]{4,}`),
+	// GitHub tokens: classic (ghp_, gho_, ghu_, ghs_, ghr_) and fine-grained (github_pat_)
+	regexp.MustCompile(`\b(gh[pousr]_|github_pat_)[A-Za-z0-9_]{16,}`),
+	// AWS access key IDs
+	regexp.MustCompile(`\b(AKIA)[A-Z0-9]{16}\b`),
+	// Slack tokens
+	regexp.MustCompile(`\b(xox[abposr]-)[A-Za-z0-9-]{10,}`),
+	// PEM private key bodies
+	regexp.MustCompile(`(-----BEGIN [A-Z ]*PRIVATE KEY-----)[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// DefaultEnvVars are environment variables whose values are always redacted
+// literally when set.
+var DefaultEnvVars = []string{
+	"ANTHROPIC_API_KEY",
+	"GEMINI_API_KEY",
+	"GOOGLE_API_KEY",
+	"OPENAI_API_KEY",
+	"GH_TOKEN",
+	"GITHUB_TOKEN",
+}
+
+// MaskBuiltin masks the built-in credential patterns in s without counting.
+// It is the implementation behind agent.SanitizeLog.
+func MaskBuiltin(s string) string {
+	out, _ := maskPatterns(s, builtinPatterns)
+	return out
+}
+
+// maskPatterns replaces every match of patterns in s, keeping capture group 1
+// when the pattern has one. It returns the masked string and the match count.
+func maskPatterns(s string, patterns []*regexp.Regexp) (string, int) {
+	n := 0
+	for _, re := range patterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			n++
+			sub := re.FindStringSubmatchIndex(match)
+			if len(sub) < 4 || sub[2] < 0 {
+				return Mask
+			}
+			return match[sub[2]:sub[3]] + Mask
+		})
+	}
+	return s, n
+}
+
+// Options configures a Redactor.
+type Options struct {
+	// Patterns are additional regular expressions to mask. If a pattern has a
+	// capture group, group 1 is kept and the rest of the match is masked;
+	// otherwise the whole match is masked.
+	Patterns []string
+	// EnvVars names environment variables whose current values are masked
+	// literally, in addition to DefaultEnvVars.
+	EnvVars []string
+}
+
+// Redactor masks secrets and counts what it masked per destination.
+// A nil *Redactor is a no-op (redaction disabled).
+type Redactor struct {
+	patterns []*regexp.Regexp
+	literals []string
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// New builds a Redactor from opts. Environment variables are read once, here.
+func New(opts Options) (*Redactor, error) {
+	r := &Redactor{
+		patterns: append([]*regexp.Regexp{}, builtinPatterns...),
+		counts:   make(map[string]int),
+	}
+	for _, p := range opts.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, DefaultEnvVars...), opts.EnvVars...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if v := os.Getenv(name); len(v) >= minLiteralLen {
+			r.literals = append(r.literals, v)
+		}
+	}
+	// Replace longer values first so that a value containing another is
+	// masked as a whole.
+	sort.SliceStable(r.literals, func(i, j int) bool {
+		return len(r.literals[i]) > len(r.literals[j])
+	})
+	return r, nil
+}
+
+// Redact masks secrets in s. dest names where the text is going
+// (e.g. "chatlog", "github") and is used for counting and logging.
+func (r *Redactor) Redact(dest, s string) string {
+	if r == nil {
+		return s
+	}
+	n := 0
+	for _, l := range r.literals {
+		if c := strings.Count(s, l); c > 0 {
+			s = strings.ReplaceAll(s, l, Mask)
+			n += c
+		}
+	}
+	s, m := maskPatterns(s, r.patterns)
+	n += m
+	if n > 0 {
+		r.mu.Lock()
+		r.counts[dest] += n
+		r.mu.Unlock()
+		log.Printf("[redact] masked %d secret(s) in text bound for %s", n, dest)
+	}
+	return s
+}
+
+// Counts returns the number of masked values per destination so far.
+func (r *Redactor) Counts() map[string]int {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.counts)
+}
+
+// Summary formats Counts as "dest=N, dest=N" in sorted order, or "" if
+// nothing has been masked.
+func (r *Redactor) Summary() string {
+	counts := r.Counts()
+	if len(counts) == 0 {
+		return ""
+	}
+	dests := make([]string, 0, len(counts))
+	for d := range counts {
+		dests = append(dests, d)
+	}
+	sort.Strings(dests)
+	parts := make([]string, len(dests))
+	for i, d := range dests {
+		parts[i] = fmt.Sprintf("%s=%d", d, counts[d])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DestGitHub is the Redact destination for text published to GitHub.
+const DestGitHub = "github"
+
+// githubPublishPattern matches gh CLI invocations that publish text to GitHub.
+var githubPublishPattern = regexp.MustCompile(`\bgh\s+(?:(?:issue|pr|release)\s+(?:create|comment|edit|review)|api)\b`)
+
+// textArgPattern captures the flags of gh commands that carry free-form text
+// (group 1) and their value (group 2): inline titles, bodies and notes,
+// gh api fields, and files passed via --body-file / --notes-file / -F.
+var textArgPattern = regexp.MustCompile(`(?:^|\s)(--title|--body-file|--body|--notes-file|--notes|--raw-field|--field|-[tbnfF])(?:=|\s+)("(?:[^"\\]|\\.)*"|'[^']*'|[^\s;&|]+)`)
+
+// heredocPattern matches the start of a here-document and captures its
+// delimiter.
+var heredocPattern = regexp.MustCompile(`<<-?\s*['"]?(\w+)['"]?`)
+
+// IsGitHubPublish reports whether command runs a gh subcommand that sends
+// free-form text (issue/PR bodies, comments, release notes) to GitHub.
+func IsGitHubPublish(command string) bool {
+	return githubPublishPattern.MatchString(command)
+}
+
+// RedactGitHubCommand masks secrets in the text that a shell command publishes
+// to GitHub: the values of --title/--body/--notes and gh api fields,
+// here-document bodies, and the contents of files passed via
+// --body-file/--notes-file/-F (resolved relative to workDir). The rest of the
+// command line (branch names, paths, other commands) is left untouched.
+//
+// A body file that contains secrets is not modified; a redacted copy is
+// written next to it and the command is pointed at the copy. The returned
+// cleanup function removes such copies and must be called once the command
+// has run. Commands that do not publish to GitHub are returned unchanged.
+func (r *Redactor) RedactGitHubCommand(command, workDir string) (string, func()) {
+	loc := githubPublishPattern.FindStringIndex(command)
+	if r == nil || loc == nil {
+		return command, func() {}
+	}
+	var copies []string
+	cleanup := func() {
+		for _, c := range copies {
+			os.Remove(c)
+		}
+	}
+
+	head, tail := command[:loc[0]], command[loc[0]:]
+	var b strings.Builder
+	last := 0
+	for _, m := range textArgPattern.FindAllStringSubmatchIndex(tail, -1) {
+		flag, value := tail[m[2]:m[3]], tail[m[4]:m[5]]
+		b.WriteString(tail[last:m[4]])
+		last = m[5]
+		if isFileFlag(flag) {
+			if c, ok := r.redactFile(value, workDir); ok {
+				copies = append(copies, c)
+				b.WriteString(shellQuote(c))
+				continue
+			}
+			if flag != "-F" {
+				b.WriteString(value)
+				continue
+			}
+			// gh api -F key=value is a field, not a file.
+		}
+		b.WriteString(r.redactQuoted(value))
+	}
+	b.WriteString(tail[last:])
+	return r.redactHeredocs(head + b.String()), cleanup
+}
+
+// isFileFlag reports whether flag takes the path of a file to publish.
+func isFileFlag(flag string) bool {
+	return flag == "--body-file" || flag == "--notes-file" || flag == "-F"
+}
+
+// redactFile writes a redacted copy of the body file named by value next to
+// the original and returns its path. It reports false when the file does not
+// exist (or is stdin) or contains nothing to redact.
+func (r *Redactor) redactFile(value, workDir string) (string, bool) {
+	path := strings.Trim(value, `"'`)
+	if path == "-" {
+		return "", false
+	}
+	if !filepath.IsAbs(path) && workDir != "" {
+		path = filepath.Join(workDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false // gh will report the missing file itself
+	}
+	red := r.Redact(DestGitHub, string(data))
+	if red == string(data) {
+		return "", false
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".madflow-redacted-*")
+	if err != nil {
+		log.Printf("[redact] failed to write redacted copy of %s: %v", path, err)
+		return "", false
+	}
+	defer f.Close()
+	if _, err := f.WriteString(red); err != nil {
+		log.Printf("[redact] failed to write redacted copy of %s: %v", path, err)
+		os.Remove(f.Name())
+		return "", false
+	}
+	return f.Name(), true
+}
+
+// redactQuoted masks secrets inside a (possibly quoted) shell word, keeping
+// its quotes.
+func (r *Redactor) redactQuoted(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		q := value[:1]
+		return q + r.Redact(DestGitHub, value[1:len(value)-1]) + q
+	}
+	return r.Redact(DestGitHub, value)
+}
+
+// redactHeredocs masks secrets in the bodies of here-documents in command,
+// which are typically piped to gh via --body-file -.
+func (r *Redactor) redactHeredocs(command string) string {
+	var b strings.Builder
+	rest := command
+	for {
+		m := heredocPattern.FindStringSubmatchIndex(rest)
+		if m == nil {
+			break
+		}
+		nl := strings.IndexByte(rest[m[1]:], '\n')
+		if nl < 0 {
+			break
+		}
+		start := m[1] + nl + 1
+		delim := rest[m[2]:m[3]]
+		end := len(rest)
+		for off := start; off < len(rest); {
+			lineEnd := strings.IndexByte(rest[off:], '\n')
+			if lineEnd < 0 {
+				lineEnd = len(rest) - off
+			}
+			if strings.TrimSpace(rest[off:off+lineEnd]) == delim {
+				end = off
+				break
+			}
+			off += lineEnd + 1
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(r.Redact(DestGitHub, rest[start:end]))
+		rest = rest[end:]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// shellQuote quotes path for use as a single shell word.
+func shellQuote(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
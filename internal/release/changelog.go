@@ -0,0 +1,154 @@
+package release
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+// changelogTitle is written at the top of a new changelog file. Release
+// sections are inserted below the title of an existing file.
+const changelogTitle = "# Changelog"
+
+// changelogSection is a group of entries in the release notes.
+type changelogSection struct {
+	title  string
+	labels []string
+}
+
+// changelogSections lists the sections in order. Issues are placed in the
+// first section one of their labels matches, or in "Other changes".
+var changelogSections = []changelogSection{
+	{"Breaking changes", []string{"breaking", "breaking-change", "major"}},
+	{"Features", []string{"feature", "enhancement"}},
+	{"Bug fixes", []string{"bug", "fix"}},
+}
+
+// ReleasedIssues returns the closed or resolved issues mentioned in commits,
+// by ID or through their feature branch name in merge subjects.
+func ReleasedIssues(issues []*issue.Issue, commits []git.Commit) []*issue.Issue {
+	var text strings.Builder
+	for _, c := range commits {
+		text.WriteString(c.Subject)
+		text.WriteByte('\n')
+		text.WriteString(c.Body)
+		text.WriteByte('\n')
+	}
+	log := text.String()
+
+	var released []*issue.Issue
+	for _, iss := range issues {
+		if iss.Status != issue.StatusClosed && iss.Status != issue.StatusResolved {
+			continue
+		}
+		if mentions(log, iss.ID) {
+			released = append(released, iss)
+		}
+	}
+	return released
+}
+
+// mentions reports whether text contains id not surrounded by letters or
+// digits, so that "local-001" does not match "local-0012" but does match
+// the branch "feature/issue-local-001".
+func mentions(text, id string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], id)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(id)
+		if (start == 0 || !isAlnum(text[start-1])) && (end == len(text) || !isAlnum(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Notes renders the release notes for tag. Issues are grouped by label;
+// when no issue is referenced, the commit subjects are listed instead.
+func Notes(tag string, date time.Time, issues []*issue.Issue, commits []git.Commit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s - %s\n", tag, date.Format("2006-01-02"))
+
+	if len(issues) == 0 {
+		b.WriteString("\n### Changes\n\n")
+		n := 0
+		for _, c := range commits {
+			if strings.HasPrefix(c.Subject, "Merge ") {
+				continue
+			}
+			fmt.Fprintf(&b, "- %s\n", c.Subject)
+			n++
+		}
+		if n == 0 {
+			b.WriteString("- Maintenance release.\n")
+		}
+		return b.String()
+	}
+
+	groups := make([][]*issue.Issue, len(changelogSections)+1)
+	for _, iss := range issues {
+		groups[sectionOf(iss)] = append(groups[sectionOf(iss)], iss)
+	}
+	for i, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		title := "Other changes"
+		if i < len(changelogSections) {
+			title = changelogSections[i].title
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, iss := range group {
+			ref := iss.ID
+			if iss.URL != "" {
+				ref = fmt.Sprintf("[%s](%s)", iss.ID, iss.URL)
+			}
+			fmt.Fprintf(&b, "- %s (%s)\n", iss.Title, ref)
+		}
+	}
+	return b.String()
+}
+
+func sectionOf(iss *issue.Issue) int {
+	for i, s := range changelogSections {
+		for _, l := range iss.Labels {
+			if slices.Contains(s.labels, strings.ToLower(l)) {
+				return i
+			}
+		}
+	}
+	return len(changelogSections)
+}
+
+// PrependChangelog inserts notes at the top of the changelog at path, below
+// its "# " title line if it has one. The file is created when it does not
+// exist.
+func PrependChangelog(path, notes string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read changelog: %w", err)
+	}
+	title, body := changelogTitle, strings.TrimLeft(string(data), "\n")
+	if strings.HasPrefix(body, "# ") {
+		title, body, _ = strings.Cut(body, "\n")
+	}
+	out := title + "\n\n" + strings.TrimRight(notes, "\n") + "\n"
+	if rest := strings.TrimLeft(body, "\n"); rest != "" {
+		out += "\n" + rest
+	}
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		return fmt.Errorf("write changelog: %w", err)
+	}
+	return nil
+}
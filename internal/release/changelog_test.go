@@ -0,0 +1,95 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/issue"
+)
+
+func TestReleasedIssues(t *testing.T) {
+	issues := []*issue.Issue{
+		{ID: "local-001", Status: issue.StatusClosed},
+		{ID: "local-0012", Status: issue.StatusResolved},
+		{ID: "gh-7", Status: issue.StatusResolved},
+		{ID: "gh-8", Status: issue.StatusInProgress},
+		{ID: "gh-9", Status: issue.StatusClosed},
+	}
+	commits := []git.Commit{
+		{Subject: "Merge branch 'feature/issue-local-001' into develop"},
+		{Subject: "fix: handle empty input", Body: "Refs gh-7, gh-8"},
+	}
+	got := ReleasedIssues(issues, commits)
+	var ids []string
+	for _, iss := range got {
+		ids = append(ids, iss.ID)
+	}
+	if strings.Join(ids, ",") != "local-001,gh-7" {
+		t.Errorf("ReleasedIssues = %v, want [local-001 gh-7]", ids)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	date := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	issues := []*issue.Issue{
+		{ID: "gh-1", Title: "Add export", Labels: []string{"enhancement"}, URL: "https://github.com/o/r/issues/1"},
+		{ID: "gh-2", Title: "Fix crash", Labels: []string{"bug"}},
+		{ID: "gh-3", Title: "Tidy docs"},
+		{ID: "gh-4", Title: "Drop v1 API", Labels: []string{"Breaking", "enhancement"}},
+	}
+	want := `## v1.0.0 - 2026-10-18
+
+### Breaking changes
+
+- Drop v1 API (gh-4)
+
+### Features
+
+- Add export ([gh-1](https://github.com/o/r/issues/1))
+
+### Bug fixes
+
+- Fix crash (gh-2)
+
+### Other changes
+
+- Tidy docs (gh-3)
+`
+	if got := Notes("v1.0.0", date, issues, nil); got != want {
+		t.Errorf("Notes =\n%s\nwant\n%s", got, want)
+	}
+
+	commits := []git.Commit{{Subject: "Merge branch 'x'"}, {Subject: "fix: typo"}}
+	if got := Notes("v1.0.1", date, nil, commits); !strings.Contains(got, "### Changes\n\n- fix: typo\n") || strings.Contains(got, "Merge") {
+		t.Errorf("Notes without issues =\n%s", got)
+	}
+}
+
+func TestPrependChangelog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+
+	if err := PrependChangelog(path, "## v0.1.0 - 2026-10-01\n\n- first\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PrependChangelog(path, "## v0.2.0 - 2026-10-18\n\n- second\n"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "# Changelog\n\n## v0.2.0 - 2026-10-18\n\n- second\n\n## v0.1.0 - 2026-10-01\n\n- first\n"
+	if string(data) != want {
+		t.Errorf("changelog =\n%q\nwant\n%q", data, want)
+	}
+
+	os.WriteFile(path, []byte("# History\n\nOlder entries.\n"), 0644)
+	if err := PrependChangelog(path, "## v1.0.0 - 2026-10-18\n"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if want := "# History\n\n## v1.0.0 - 2026-10-18\n\nOlder entries.\n"; string(data) != want {
+		t.Errorf("changelog =\n%q\nwant\n%q", data, want)
+	}
+}
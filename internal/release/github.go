@@ -0,0 +1,103 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// CIState summarises the check runs of a commit.
+type CIState string
+
+const (
+	CISuccess CIState = "success"
+	CIPending CIState = "pending"
+	CIFailure CIState = "failure"
+	// CINone means the commit has no check runs.
+	CINone CIState = "none"
+)
+
+// GitHub is the subset of the GitHub API the pipeline uses. Each call is made
+// for the local repository at dir; the GitHub repository is the one its
+// origin remote points to.
+type GitHub interface {
+	// CIStatus returns the combined state of the check runs on sha.
+	CIStatus(ctx context.Context, dir, sha string) (CIState, error)
+	// PRLabels returns the labels of pull request number.
+	PRLabels(ctx context.Context, dir string, number int) ([]string, error)
+	// CreateRelease publishes a GitHub release for an already pushed tag.
+	CreateRelease(ctx context.Context, dir, tag, notes string) error
+}
+
+// GHCLI implements GitHub with the gh command-line tool.
+type GHCLI struct{}
+
+// CIStatus implements GitHub.
+func (GHCLI) CIStatus(ctx context.Context, dir, sha string) (CIState, error) {
+	out, err := runGH(ctx, dir, "", "api", "repos/{owner}/{repo}/commits/"+sha+"/check-runs", "--paginate",
+		"--jq", `.check_runs[] | .status + " " + (.conclusion // "")`)
+	if err != nil {
+		return "", err
+	}
+	return combineCheckRuns(out), nil
+}
+
+// combineCheckRuns folds "status conclusion" lines into a CIState.
+func combineCheckRuns(out string) CIState {
+	state := CINone
+	for line := range strings.Lines(out) {
+		status, conclusion, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch {
+		case status == "":
+			continue
+		case status != "completed":
+			if state != CIFailure {
+				state = CIPending
+			}
+		case conclusion == "success" || conclusion == "neutral" || conclusion == "skipped":
+			if state == CINone {
+				state = CISuccess
+			}
+		default:
+			state = CIFailure
+		}
+	}
+	return state
+}
+
+// PRLabels implements GitHub.
+func (GHCLI) PRLabels(ctx context.Context, dir string, number int) ([]string, error) {
+	out, err := runGH(ctx, dir, "", "pr", "view", strconv.Itoa(number), "--json", "labels", "--jq", ".labels[].name")
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for line := range strings.Lines(out) {
+		if l := strings.TrimSpace(line); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return labels, nil
+}
+
+// CreateRelease implements GitHub.
+func (GHCLI) CreateRelease(ctx context.Context, dir, tag, notes string) error {
+	_, err := runGH(ctx, dir, notes, "release", "create", tag, "--verify-tag", "--title", tag, "--notes-file", "-")
+	return err
+}
+
+func runGH(ctx context.Context, dir, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("gh %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
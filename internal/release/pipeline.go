@@ -0,0 +1,457 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/redact"
+)
+
+// remote is the remote releases are pushed to.
+const remote = "origin"
+
+// Options are the per-run options of a release.
+type Options struct {
+	// DryRun runs the pre-flight checks and computes the plan without
+	// changing any repository.
+	DryRun bool
+	// Bump overrides the computed bump level when it is not LevelNone.
+	Bump Level
+	// Version sets the released version explicitly (e.g. "2.0.0"). It
+	// overrides Bump and must be higher than the previous release.
+	Version string
+}
+
+// Pipeline releases the develop branch of each repository: develop is merged
+// into main, the changelog is updated, main is tagged, develop is fast-
+// forwarded to main and everything is pushed. A failure in any repository
+// rolls all of them back.
+type Pipeline struct {
+	Repos    map[string]*git.Repo
+	Branches config.BranchConfig
+	Config   config.ReleaseConfig
+	// GitHub is used for the CI check, pull request labels and GitHub
+	// releases. nil skips all three.
+	GitHub GitHub
+	// Issues are the issues of the project; the closed and resolved ones
+	// referenced by the released commits make up the changelog.
+	Issues []*issue.Issue
+	// Now returns the release date. Defaults to time.Now.
+	Now func() time.Time
+	// Redactor masks secrets in the release notes, which are published in
+	// the changelog, the tag and the GitHub release. nil disables redaction.
+	Redactor *redact.Redactor
+}
+
+// Plan is the release of one repository.
+type Plan struct {
+	Repo string
+	// Previous is the tag of the previous release, empty for the first one.
+	Previous string
+	Version  Version
+	Tag      string
+	Level    Level
+	// Commits is the number of commits on develop not yet in main.
+	Commits int
+	Notes   string
+}
+
+// Result is the outcome of a release.
+type Result struct {
+	Plans []Plan
+	// Warnings are problems that did not stop the release, such as a failed
+	// GitHub release or unreadable pull request labels.
+	Warnings []string
+}
+
+// Summary describes the planned or released versions in one line.
+func (r *Result) Summary() string {
+	parts := make([]string, len(r.Plans))
+	for i, p := range r.Plans {
+		prev := p.Previous
+		if prev == "" {
+			prev = "なし"
+		}
+		parts[i] = fmt.Sprintf("%s %s (%s, 前回 %s, %d コミット)", p.Repo, p.Tag, p.Level, prev, p.Commits)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// repoState records a repository's refs before the release so that it can
+// be rolled back.
+type repoState struct {
+	name string
+	repo *git.Repo
+	plan *Plan
+	// branch is the branch checked out before the release.
+	branch string
+	// main and develop are the local branch heads before the release.
+	main, develop string
+	// remoteMain and remoteDevelop are the heads on origin before the push,
+	// empty when the branch did not exist there.
+	remoteMain, remoteDevelop string
+	released                  bool
+	tagged                    bool
+	pushed                    bool
+}
+
+// Run executes the release. Nothing is changed when a pre-flight check
+// fails or opts.DryRun is set.
+func (p *Pipeline) Run(ctx context.Context, opts Options) (*Result, error) {
+	names := make([]string, 0, len(p.Repos))
+	for name := range p.Repos {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if len(names) == 0 {
+		return nil, errors.New("no repositories configured")
+	}
+
+	var errs []string
+	for _, name := range names {
+		if err := p.preflight(ctx, p.Repos[name]); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("pre-flight check failed: %s", strings.Join(errs, "; "))
+	}
+
+	res := &Result{}
+	for _, name := range names {
+		plan, err := p.plan(ctx, name, p.Repos[name], opts, res)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		res.Plans = append(res.Plans, *plan)
+	}
+	if opts.DryRun {
+		return res, nil
+	}
+
+	states := make([]*repoState, len(names))
+	for i, name := range names {
+		states[i] = &repoState{name: name, repo: p.Repos[name], plan: &res.Plans[i]}
+	}
+	for _, s := range states {
+		if err := p.releaseLocal(s); err != nil {
+			return nil, p.fail(fmt.Errorf("%s: %w", s.name, err), states)
+		}
+		log.Printf("[release] %s: tagged %s", s.name, s.plan.Tag)
+	}
+	if p.Config.NoPush {
+		return res, nil
+	}
+	for _, s := range states {
+		if err := p.push(s); err != nil {
+			return nil, p.fail(fmt.Errorf("%s: push: %w", s.name, err), states)
+		}
+		log.Printf("[release] %s: pushed %s", s.name, s.plan.Tag)
+	}
+
+	if p.Config.GitHubRelease && p.GitHub != nil {
+		for _, s := range states {
+			if err := p.GitHub.CreateRelease(ctx, s.repo.Path(), s.plan.Tag, s.plan.Notes); err != nil {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("%s: GitHub release %s: %v", s.name, s.plan.Tag, err))
+			}
+		}
+	}
+	return res, nil
+}
+
+// preflight checks that repo can be released: a clean working tree, develop
+// ahead of main, local branches not behind origin, and green CI on develop.
+func (p *Pipeline) preflight(ctx context.Context, repo *git.Repo) error {
+	main, develop := p.Branches.Main, p.Branches.Develop
+	clean, err := repo.IsClean()
+	if err != nil {
+		return err
+	}
+	if !clean {
+		return errors.New("working tree has uncommitted changes")
+	}
+	for _, b := range []string{main, develop} {
+		if !repo.BranchExists(b) {
+			return fmt.Errorf("branch %s does not exist", b)
+		}
+	}
+
+	if !p.Config.NoPush {
+		if !repo.HasRemote(remote) {
+			return fmt.Errorf("no %s remote (set release.no_push to release locally)", remote)
+		}
+		if err := repo.Fetch(remote); err != nil {
+			return err
+		}
+		for _, b := range []string{main, develop} {
+			if !repo.BranchExists(remote + "/" + b) {
+				continue
+			}
+			behind, err := repo.CountCommits(b, remote+"/"+b)
+			if err != nil {
+				return err
+			}
+			if behind > 0 {
+				return fmt.Errorf("%s is %d commit(s) behind %s/%s", b, behind, remote, b)
+			}
+		}
+	}
+
+	ahead, err := repo.CountCommits(main, develop)
+	if err != nil {
+		return err
+	}
+	if ahead == 0 {
+		return fmt.Errorf("%s has no commits that are not in %s", develop, main)
+	}
+
+	if p.GitHub != nil && !p.Config.SkipCI {
+		sha, err := repo.RevParse(develop)
+		if err != nil {
+			return err
+		}
+		state, err := p.GitHub.CIStatus(ctx, repo.Path(), sha)
+		if err != nil {
+			return fmt.Errorf("CI status of %s: %w", develop, err)
+		}
+		if state != CISuccess && state != CINone {
+			return fmt.Errorf("CI on %s (%s) is %s", develop, sha[:min(len(sha), 7)], state)
+		}
+	}
+	return nil
+}
+
+// plan computes the version and release notes of one repository.
+func (p *Pipeline) plan(ctx context.Context, name string, repo *git.Repo, opts Options, res *Result) (*Plan, error) {
+	prefix := p.Config.TagPrefix
+	plan := &Plan{Repo: name}
+
+	tags, err := repo.Tags(prefix+"*", p.Branches.Main)
+	if err != nil {
+		return nil, err
+	}
+	var current Version
+	for _, t := range tags {
+		if v, err := ParseVersion(t, prefix); err == nil {
+			plan.Previous, current = t, v
+			break
+		}
+	}
+
+	commits, err := repo.Log(p.Branches.Main + ".." + p.Branches.Develop)
+	if err != nil {
+		return nil, err
+	}
+	plan.Commits = len(commits)
+	if plan.Previous != "" {
+		// Hotfixes may have been released from main since the last tag;
+		// the notes cover everything after it.
+		if commits, err = repo.Log(plan.Previous + ".." + p.Branches.Develop); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case opts.Version != "":
+		v, err := ParseVersion(opts.Version, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if !current.Less(v) {
+			return nil, fmt.Errorf("version %s is not higher than %s", v, current)
+		}
+		plan.Version = v
+	case opts.Bump != LevelNone:
+		plan.Level = opts.Bump
+	default:
+		plan.Level = p.computeLevel(ctx, name, repo, commits, res)
+	}
+	if opts.Version == "" {
+		plan.Version = current.Bump(plan.Level)
+	} else {
+		plan.Level = levelBetween(current, plan.Version)
+	}
+
+	plan.Tag = prefix + plan.Version.String()
+	if _, err := repo.RevParse(plan.Tag); err == nil {
+		return nil, fmt.Errorf("tag %s already exists", plan.Tag)
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	plan.Notes = p.Redactor.Redact(redact.DestGitHub, Notes(plan.Tag, now(), ReleasedIssues(p.Issues, commits), commits))
+	return plan, nil
+}
+
+// computeLevel derives the bump level from Conventional Commits subjects and,
+// when GitHub is available, from the labels of the merged pull requests. It
+// is at least LevelPatch.
+func (p *Pipeline) computeLevel(ctx context.Context, name string, repo *git.Repo, commits []git.Commit, res *Result) Level {
+	level := LevelPatch
+	for _, c := range commits {
+		level = max(level, CommitLevel(c.Subject, c.Body))
+	}
+	if p.GitHub == nil {
+		return level
+	}
+	seen := make(map[int]bool)
+	for _, c := range commits {
+		n := PRNumber(c.Subject)
+		if n == 0 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		labels, err := p.GitHub.PRLabels(ctx, repo.Path(), n)
+		if err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("%s: labels of PR #%d: %v", name, n, err))
+			continue
+		}
+		level = max(level, LabelLevel(labels))
+	}
+	return level
+}
+
+// levelBetween returns the level of the change from v to w.
+func levelBetween(v, w Version) Level {
+	switch {
+	case w.Major != v.Major:
+		return LevelMajor
+	case w.Minor != v.Minor:
+		return LevelMinor
+	default:
+		return LevelPatch
+	}
+}
+
+// releaseLocal merges develop into main, updates the changelog, tags main
+// and fast-forwards develop, recording what is needed for a rollback.
+func (p *Pipeline) releaseLocal(s *repoState) error {
+	main, develop := p.Branches.Main, p.Branches.Develop
+	var err error
+	if s.branch, err = s.repo.CurrentBranch(); err != nil {
+		return err
+	}
+	if s.main, err = s.repo.RevParse(main); err != nil {
+		return err
+	}
+	if s.develop, err = s.repo.RevParse(develop); err != nil {
+		return err
+	}
+	s.released = true
+
+	if err := s.repo.Checkout(main); err != nil {
+		return err
+	}
+	ok, err := s.repo.Merge(develop)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("merging %s into %s conflicts", develop, main)
+	}
+	if err := PrependChangelog(filepath.Join(s.repo.Path(), p.Config.Changelog), s.plan.Notes); err != nil {
+		return err
+	}
+	if err := s.repo.CommitFiles("chore(release): "+s.plan.Tag, p.Config.Changelog); err != nil {
+		return err
+	}
+	if err := s.repo.CreateAnnotatedTag(s.plan.Tag, "Release "+s.plan.Tag+"\n\n"+s.plan.Notes, "HEAD"); err != nil {
+		return err
+	}
+	s.tagged = true
+	if err := s.repo.ResetBranch(develop, main); err != nil {
+		return err
+	}
+	if s.branch != main {
+		return s.repo.Checkout(s.branch)
+	}
+	return nil
+}
+
+// push pushes main, develop and the tag atomically, recording the previous
+// remote heads for a rollback.
+func (p *Pipeline) push(s *repoState) error {
+	main, develop := p.Branches.Main, p.Branches.Develop
+	s.remoteMain, _ = s.repo.RevParse(remote + "/" + main)
+	s.remoteDevelop, _ = s.repo.RevParse(remote + "/" + develop)
+	if err := s.repo.Push(remote, "--atomic", main, develop, "refs/tags/"+s.plan.Tag); err != nil {
+		return err
+	}
+	s.pushed = true
+	return nil
+}
+
+// fail rolls back every repository touched so far and returns cause with
+// the outcome of the rollback.
+func (p *Pipeline) fail(cause error, states []*repoState) error {
+	var errs []string
+	for _, s := range states {
+		if s.pushed {
+			if err := p.rollbackRemote(s); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", s.name, err))
+			}
+		}
+		if s.released {
+			if err := p.rollbackLocal(s); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", s.name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		log.Printf("[release] rollback incomplete: %s", strings.Join(errs, "; "))
+		return fmt.Errorf("%w (rollback incomplete: %s)", cause, strings.Join(errs, "; "))
+	}
+	log.Printf("[release] %v; rolled back", cause)
+	return fmt.Errorf("%w (rolled back)", cause)
+}
+
+// rollbackRemote restores main and develop on origin and deletes the tag.
+// --force-with-lease refuses to overwrite pushes made since the release.
+func (p *Pipeline) rollbackRemote(s *repoState) error {
+	args := []string{"--atomic"}
+	for _, b := range []struct{ name, before string }{
+		{p.Branches.Main, s.remoteMain},
+		{p.Branches.Develop, s.remoteDevelop},
+	} {
+		released, err := s.repo.RevParse(b.name)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--force-with-lease="+b.name+":"+released, b.before+":refs/heads/"+b.name)
+	}
+	args = append(args, ":refs/tags/"+s.plan.Tag)
+	return s.repo.Push(remote, args...)
+}
+
+// rollbackLocal restores the branches, deletes the tag and checks out the
+// original branch.
+func (p *Pipeline) rollbackLocal(s *repoState) error {
+	if err := s.repo.Checkout(p.Branches.Main); err != nil {
+		return err
+	}
+	if err := s.repo.ResetBranch(p.Branches.Main, s.main); err != nil {
+		return err
+	}
+	if err := s.repo.ResetBranch(p.Branches.Develop, s.develop); err != nil {
+		return err
+	}
+	if s.tagged {
+		if err := s.repo.DeleteTag(s.plan.Tag); err != nil {
+			return err
+		}
+	}
+	if s.branch != p.Branches.Main {
+		return s.repo.Checkout(s.branch)
+	}
+	return nil
+}
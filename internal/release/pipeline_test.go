@@ -0,0 +1,267 @@
+package release
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/config"
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/issue"
+	"github.com/ytnobody/madflow/internal/redact"
+)
+
+type fakeGitHub struct {
+	ci       CIState
+	labels   map[int][]string
+	releases []string
+	notes    []string
+}
+
+func (f *fakeGitHub) CIStatus(context.Context, string, string) (CIState, error) {
+	return f.ci, nil
+}
+
+func (f *fakeGitHub) PRLabels(_ context.Context, _ string, n int) ([]string, error) {
+	return f.labels[n], nil
+}
+
+func (f *fakeGitHub) CreateRelease(_ context.Context, _, tag, notes string) error {
+	f.releases = append(f.releases, tag)
+	f.notes = append(f.notes, notes)
+	return nil
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newReleaseRepo creates a repository with main and develop pushed to a bare
+// origin, and develop one commit (subject) ahead of main.
+func newReleaseRepo(t *testing.T, subject string) (*git.Repo, string) {
+	t.Helper()
+	origin := t.TempDir()
+	gitRun(t, origin, "init", "--bare", "-b", "main")
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-b", "main")
+	gitRun(t, dir, "config", "user.email", "test@test.com")
+	gitRun(t, dir, "config", "user.name", "Test User")
+	gitRun(t, dir, "remote", "add", "origin", origin)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test\n"), 0644)
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-m", "initial commit")
+	gitRun(t, dir, "checkout", "-b", "develop")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-m", subject)
+	gitRun(t, dir, "push", "origin", "main", "develop")
+	return git.NewRepo(dir), origin
+}
+
+func newTestPipeline(repos map[string]*git.Repo) *Pipeline {
+	return &Pipeline{
+		Repos:    repos,
+		Branches: config.BranchConfig{Main: "main", Develop: "develop"},
+		Config:   config.ReleaseConfig{TagPrefix: "v", Changelog: "CHANGELOG.md", GitHubRelease: true},
+		Now:      func() time.Time { return time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC) },
+	}
+}
+
+func TestRunReleasesAndPushes(t *testing.T) {
+	repo, origin := newReleaseRepo(t, "feat: add a (gh-1)")
+	gh := &fakeGitHub{ci: CISuccess}
+	p := newTestPipeline(map[string]*git.Repo{"app": repo})
+	p.GitHub = gh
+	p.Issues = []*issue.Issue{{ID: "gh-1", Title: "Add a", Status: issue.StatusResolved, Labels: []string{"enhancement"}}}
+
+	res, err := p.Run(t.Context(), Options{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(res.Plans) != 1 || res.Plans[0].Tag != "v0.1.0" || res.Plans[0].Level != LevelMinor {
+		t.Fatalf("plans = %+v", res.Plans)
+	}
+
+	main := gitRun(t, repo.Path(), "rev-parse", "main")
+	for _, ref := range []string{"develop", "v0.1.0^{commit}"} {
+		if got := gitRun(t, repo.Path(), "rev-parse", ref); got != main {
+			t.Errorf("%s = %s, want main %s", ref, got, main)
+		}
+	}
+	for _, ref := range []string{"main", "develop", "v0.1.0^{commit}"} {
+		if got := gitRun(t, origin, "rev-parse", ref); got != main {
+			t.Errorf("origin %s = %s, want %s", ref, got, main)
+		}
+	}
+	if got := gitRun(t, repo.Path(), "cat-file", "-t", "v0.1.0"); got != "tag" {
+		t.Errorf("v0.1.0 is a %s, want an annotated tag", got)
+	}
+	if b, _ := repo.CurrentBranch(); b != "develop" {
+		t.Errorf("current branch = %s, want the original branch develop", b)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(repo.Path(), "CHANGELOG.md"))
+	if !strings.Contains(string(changelog), "## v0.1.0 - 2026-10-18\n\n### Features\n\n- Add a (gh-1)") {
+		t.Errorf("CHANGELOG.md =\n%s", changelog)
+	}
+	if len(gh.releases) != 1 || gh.releases[0] != "v0.1.0" {
+		t.Errorf("GitHub releases = %v", gh.releases)
+	}
+}
+
+func TestRunRedactsNotes(t *testing.T) {
+	repo, _ := newReleaseRepo(t, "fix: stop logging the key (gh-1)")
+	gh := &fakeGitHub{ci: CISuccess}
+	p := newTestPipeline(map[string]*git.Repo{"app": repo})
+	p.GitHub = gh
+	p.Issues = []*issue.Issue{{ID: "gh-1", Title: "Stop logging token=abcdefghijkl", Status: issue.StatusResolved}}
+	r, err := redact.New(redact.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Redactor = r
+
+	if _, err := p.Run(t.Context(), Options{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	changelog, _ := os.ReadFile(filepath.Join(repo.Path(), "CHANGELOG.md"))
+	tag := gitRun(t, repo.Path(), "tag", "-l", "--format=%(contents)", "v0.0.1")
+	for name, text := range map[string]string{"CHANGELOG.md": string(changelog), "tag": tag, "GitHub release": strings.Join(gh.notes, "")} {
+		if strings.Contains(text, "abcdefghijkl") || !strings.Contains(text, "Stop logging token=***") {
+			t.Errorf("%s is not redacted:\n%s", name, text)
+		}
+	}
+}
+
+func TestRunPreflightFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, repo *git.Repo, p *Pipeline)
+		want  string
+	}{
+		{"dirty tree", func(t *testing.T, repo *git.Repo, _ *Pipeline) {
+			os.WriteFile(filepath.Join(repo.Path(), "wip.txt"), []byte("x"), 0644)
+		}, "uncommitted changes"},
+		{"nothing to release", func(t *testing.T, repo *git.Repo, _ *Pipeline) {
+			gitRun(t, repo.Path(), "branch", "-f", "main", "develop")
+		}, "develop has no commits"},
+		{"ci failing", func(_ *testing.T, _ *git.Repo, p *Pipeline) {
+			p.GitHub = &fakeGitHub{ci: CIFailure}
+		}, "CI on develop"},
+		{"behind origin", func(t *testing.T, repo *git.Repo, _ *Pipeline) {
+			gitRun(t, repo.Path(), "reset", "--hard", "HEAD~1")
+		}, "develop is 1 commit(s) behind origin/develop"},
+		{"no remote", func(t *testing.T, repo *git.Repo, _ *Pipeline) {
+			gitRun(t, repo.Path(), "remote", "remove", "origin")
+		}, "no origin remote"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newReleaseRepo(t, "fix: a")
+			p := newTestPipeline(map[string]*git.Repo{"app": repo})
+			tt.setup(t, repo, p)
+			_, err := p.Run(t.Context(), Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Run error = %v, want %q", err, tt.want)
+			}
+			if tags := gitRun(t, repo.Path(), "tag"); tags != "" {
+				t.Errorf("tags created despite failed pre-flight: %s", tags)
+			}
+		})
+	}
+}
+
+func TestRunVersionSelection(t *testing.T) {
+	repo, _ := newReleaseRepo(t, "Merge pull request #5 from alice/x")
+	gitRun(t, repo.Path(), "tag", "-a", "v1.4.2", "-m", "v1.4.2", "main")
+	gh := &fakeGitHub{ci: CINone, labels: map[int][]string{5: {"breaking"}}}
+
+	tests := []struct {
+		name    string
+		gh      GitHub
+		opts    Options
+		want    string
+		wantErr string
+	}{
+		{"default patch", nil, Options{}, "v1.4.3", ""},
+		{"pr labels", gh, Options{}, "v2.0.0", ""},
+		{"bump override", gh, Options{Bump: LevelMinor}, "v1.5.0", ""},
+		{"explicit version", nil, Options{Version: "v1.6.0"}, "v1.6.0", ""},
+		{"version not higher", nil, Options{Version: "1.4.2"}, "", "not higher than 1.4.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPipeline(map[string]*git.Repo{"app": repo})
+			p.GitHub = tt.gh
+			tt.opts.DryRun = true
+			res, err := p.Run(t.Context(), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := res.Plans[0].Tag; got != tt.want {
+				t.Errorf("tag = %s, want %s", got, tt.want)
+			}
+			if res.Plans[0].Previous != "v1.4.2" {
+				t.Errorf("previous = %q", res.Plans[0].Previous)
+			}
+		})
+	}
+	if tags := gitRun(t, repo.Path(), "tag"); tags != "v1.4.2" {
+		t.Errorf("dry run created tags: %s", tags)
+	}
+}
+
+func TestRunRollsBackWhenAnyRepoFails(t *testing.T) {
+	a, originA := newReleaseRepo(t, "fix: a")
+	b, originB := newReleaseRepo(t, "fix: b")
+	hook := filepath.Join(originB, "hooks", "pre-receive")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho rejected >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	before := map[string]string{}
+	for name, dir := range map[string]string{"a": a.Path(), "b": b.Path(), "originA": originA} {
+		before[name+" main"] = gitRun(t, dir, "rev-parse", "main")
+		before[name+" develop"] = gitRun(t, dir, "rev-parse", "develop")
+	}
+
+	p := newTestPipeline(map[string]*git.Repo{"a": a, "b": b})
+	_, err := p.Run(t.Context(), Options{})
+	if err == nil || !strings.Contains(err.Error(), "b: push") || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("Run error = %v, want a rolled back push failure on b", err)
+	}
+
+	for name, dir := range map[string]string{"a": a.Path(), "b": b.Path(), "originA": originA} {
+		for _, branch := range []string{"main", "develop"} {
+			if got := gitRun(t, dir, "rev-parse", branch); got != before[name+" "+branch] {
+				t.Errorf("%s %s = %s, want %s", name, branch, got, before[name+" "+branch])
+			}
+		}
+		if tags := gitRun(t, dir, "tag"); tags != "" {
+			t.Errorf("%s still has tags: %s", name, tags)
+		}
+	}
+	for _, repo := range []*git.Repo{a, b} {
+		if br, _ := repo.CurrentBranch(); br != "develop" {
+			t.Errorf("%s: current branch = %s, want develop", repo.Path(), br)
+		}
+		if _, err := os.Stat(filepath.Join(repo.Path(), "CHANGELOG.md")); !os.IsNotExist(err) {
+			t.Errorf("%s: CHANGELOG.md left behind", repo.Path())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package release implements the RELEASE pipeline: pre-flight checks,
+// semantic version computation, changelog generation, tagging, pushing and
+// rollback across the project's repositories.
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version without pre-release or build metadata.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses "1.2.3", optionally preceded by prefix (e.g. "v").
+func ParseVersion(s, prefix string) (Version, error) {
+	var v Version
+	parts := strings.Split(strings.TrimPrefix(s, prefix), ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q: want MAJOR.MINOR.PATCH", s)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (len(p) > 1 && p[0] == '0') {
+			return v, fmt.Errorf("invalid version %q: %q is not a version number", s, p)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// String returns "MAJOR.MINOR.PATCH".
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v sorts before w.
+func (v Version) Less(w Version) bool {
+	if v.Major != w.Major {
+		return v.Major < w.Major
+	}
+	if v.Minor != w.Minor {
+		return v.Minor < w.Minor
+	}
+	return v.Patch < w.Patch
+}
+
+// Bump returns v incremented at level. LevelNone returns v unchanged.
+func (v Version) Bump(level Level) Version {
+	switch level {
+	case LevelMajor:
+		return Version{Major: v.Major + 1}
+	case LevelMinor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	case LevelPatch:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	default:
+		return v
+	}
+}
+
+// Level is the part of the version a release increments.
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelPatch
+	LevelMinor
+	LevelMajor
+)
+
+// String returns the lower-case level name.
+func (l Level) String() string {
+	switch l {
+	case LevelPatch:
+		return "patch"
+	case LevelMinor:
+		return "minor"
+	case LevelMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ParseLevel parses "major", "minor" or "patch".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "major":
+		return LevelMajor, nil
+	case "minor":
+		return LevelMinor, nil
+	case "patch":
+		return LevelPatch, nil
+	}
+	return LevelNone, fmt.Errorf("invalid bump %q: want major, minor or patch", s)
+}
+
+// conventionalRe matches a Conventional Commits subject: type(scope)!: text.
+var conventionalRe = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?:\s`)
+
+// CommitLevel returns the bump a commit calls for under Conventional
+// Commits: "!" or a BREAKING CHANGE footer is major, feat is minor, fix and
+// perf are patch. Other commits return LevelNone.
+func CommitLevel(subject, body string) Level {
+	if strings.Contains(body, "BREAKING CHANGE:") || strings.Contains(body, "BREAKING-CHANGE:") {
+		return LevelMajor
+	}
+	m := conventionalRe.FindStringSubmatch(subject)
+	if m == nil {
+		return LevelNone
+	}
+	if m[3] == "!" {
+		return LevelMajor
+	}
+	switch strings.ToLower(m[1]) {
+	case "feat":
+		return LevelMinor
+	case "fix", "perf":
+		return LevelPatch
+	}
+	return LevelNone
+}
+
+// LabelLevel returns the bump a pull request's labels call for.
+func LabelLevel(labels []string) Level {
+	level := LevelNone
+	for _, l := range labels {
+		switch strings.ToLower(l) {
+		case "major", "breaking", "breaking-change", "semver:major":
+			return LevelMajor
+		case "minor", "feature", "enhancement", "semver:minor":
+			level = max(level, LevelMinor)
+		case "patch", "bug", "fix", "semver:patch":
+			level = max(level, LevelPatch)
+		}
+	}
+	return level
+}
+
+// prRe matches the pull request number in GitHub merge and squash subjects:
+// "Merge pull request #12 from ..." and "Add feature (#12)".
+var prRe = regexp.MustCompile(`^Merge pull request #(\d+)|\(#(\d+)\)$`)
+
+// PRNumber returns the pull request a commit subject refers to, or 0.
+func PRNumber(subject string) int {
+	m := prRe.FindStringSubmatch(subject)
+	if m == nil {
+		return 0
+	}
+	s := m[1]
+	if s == "" {
+		s = m[2]
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,112 @@
+package release
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{"v1.2.3", Version{1, 2, 3}, false},
+		{"0.10.0", Version{0, 10, 0}, false},
+		{"v1.2", Version{}, true},
+		{"v1.02.3", Version{}, true},
+		{"v1.2.3-rc1", Version{}, true},
+		{"release-1.2.3", Version{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in, "v")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	v := Version{1, 2, 3}
+	for level, want := range map[Level]string{
+		LevelNone:  "1.2.3",
+		LevelPatch: "1.2.4",
+		LevelMinor: "1.3.0",
+		LevelMajor: "2.0.0",
+	} {
+		if got := v.Bump(level).String(); got != want {
+			t.Errorf("Bump(%s) = %s, want %s", level, got, want)
+		}
+	}
+	if !v.Less(Version{1, 3, 0}) || (Version{2, 0, 0}).Less(v) || v.Less(v) {
+		t.Error("Less is wrong")
+	}
+}
+
+func TestCommitLevel(t *testing.T) {
+	tests := []struct {
+		subject, body string
+		want          Level
+	}{
+		{"feat: add RELEASE --dry-run", "", LevelMinor},
+		{"feat(release): add changelog", "", LevelMinor},
+		{"fix: handle empty tags", "", LevelPatch},
+		{"refactor!: drop the legacy config", "", LevelMajor},
+		{"chore: bump deps", "BREAKING CHANGE: Go 1.25 is required", LevelMajor},
+		{"docs: update README", "", LevelNone},
+		{"Merge branch 'feature/issue-gh-1' into develop", "", LevelNone},
+		{"feature: not conventional", "", LevelNone},
+	}
+	for _, tt := range tests {
+		if got := CommitLevel(tt.subject, tt.body); got != tt.want {
+			t.Errorf("CommitLevel(%q) = %s, want %s", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestLabelLevel(t *testing.T) {
+	tests := []struct {
+		labels []string
+		want   Level
+	}{
+		{nil, LevelNone},
+		{[]string{"documentation"}, LevelNone},
+		{[]string{"bug"}, LevelPatch},
+		{[]string{"bug", "Enhancement"}, LevelMinor},
+		{[]string{"feature", "breaking"}, LevelMajor},
+	}
+	for _, tt := range tests {
+		if got := LabelLevel(tt.labels); got != tt.want {
+			t.Errorf("LabelLevel(%v) = %s, want %s", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestPRNumber(t *testing.T) {
+	tests := map[string]int{
+		"Merge pull request #42 from alice/feature": 42,
+		"Add changelog generation (#17)":            17,
+		"Fix #3 in the parser":                      0,
+		"Merge branch 'develop'":                    0,
+	}
+	for subject, want := range tests {
+		if got := PRNumber(subject); got != want {
+			t.Errorf("PRNumber(%q) = %d, want %d", subject, got, want)
+		}
+	}
+}
+
+func TestCombineCheckRuns(t *testing.T) {
+	tests := []struct {
+		out  string
+		want CIState
+	}{
+		{"", CINone},
+		{"completed success\ncompleted skipped\n", CISuccess},
+		{"completed success\nin_progress \n", CIPending},
+		{"in_progress \ncompleted failure\n", CIFailure},
+		{"completed timed_out\ncompleted success\n", CIFailure},
+	}
+	for _, tt := range tests {
+		if got := combineCheckRuns(tt.out); got != tt.want {
+			t.Errorf("combineCheckRuns(%q) = %s, want %s", tt.out, got, tt.want)
+		}
+	}
+}
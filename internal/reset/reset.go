@@ -156,6 +156,12 @@ func (t *Timer) Reset() {
 	t.started = time.Now()
 }
 
+// SetInterval changes the reset interval. The elapsed time is kept, so the
+// timer may be expired immediately if the new interval is shorter.
+func (t *Timer) SetInterval(interval time.Duration) {
+	t.interval = interval
+}
+
 // DistillPrompt is the default (Japanese) prompt for backward compatibility.
 const DistillPrompt = distillPromptJA
 
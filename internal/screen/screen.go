@@ -0,0 +1,179 @@
+// Package screen flags prompt-injection attempts in issue and comment text
+// before it reaches agent prompts.
+//
+// Text is checked against regular-expression rules (built-in plus
+// user-defined) and, optionally, an LLM classifier. Flagged content is
+// quarantined by the GitHub sync pipeline as pending approval.
+package screen
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Finding describes why a piece of text was flagged.
+type Finding struct {
+	// Rule is the name of the matching rule, or "classifier".
+	Rule string
+	// Reason is a human-readable explanation for the superintendent.
+	Reason string
+	// Excerpt is the matching fragment (truncated), empty for classifier findings.
+	Excerpt string
+}
+
+func (f *Finding) String() string {
+	if f.Excerpt == "" {
+		return fmt.Sprintf("%s (%s)", f.Reason, f.Rule)
+	}
+	return fmt.Sprintf("%s (%s: %q)", f.Reason, f.Rule, f.Excerpt)
+}
+
+// Rule is a single screening rule.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Reason  string
+}
+
+// RuleSpec is the uncompiled form of a Rule, as read from the config file.
+type RuleSpec struct {
+	Name    string
+	Pattern string
+	Reason  string
+}
+
+// builtinRules cover common prompt-injection goals against coding agents.
+var builtinRules = []RuleSpec{
+	{
+		Name:    "override-instructions",
+		Pattern: `(?i)\b(ignore|disregard|forget)\b.{0,30}\b(previous|prior|above|all|system)\b.{0,20}\b(instructions?|prompts?|rules)\b`,
+		Reason:  "attempts to override the agent's instructions",
+	},
+	{
+		Name:    "exfiltrate-secrets",
+		Pattern: `(?i)(\b(print|echo|cat|dump|send|post|upload|exfiltrate|leak|reveal)\b.{0,40}(\benv\b|environment variables?|api[_ -]?keys?|secrets?|tokens?|credentials?|\.ssh|id_rsa|\.netrc|\.env\b))|(\$\{?(ANTHROPIC_API_KEY|GEMINI_API_KEY|GOOGLE_API_KEY|GH_TOKEN|GITHUB_TOKEN)\b)`,
+		Reason:  "asks to reveal or send secrets",
+	},
+	{
+		Name:    "remote-script",
+		Pattern: `(?i)\b(curl|wget)\b[^|\n]{0,200}\|\s*(ba|z)?sh\b`,
+		Reason:  "pipes a downloaded script into a shell",
+	},
+	{
+		Name:    "alter-ci",
+		Pattern: `(?i)(\.github/workflows/|\b(disable|remove|delete|skip|bypass)\b.{0,30}\b(ci|checks?|workflows?|branch protection)\b)`,
+		Reason:  "asks to change or bypass CI",
+	},
+	{
+		Name:    "push-protected",
+		Pattern: `(?i)(\bgit\s+push\b[^\n]*(--force\b|-f\b|\bmain\b|\bmaster\b))|(\b(push|commit|merge)\b.{0,20}\bdirectly\b.{0,20}\b(to\s+)?(main|master)\b)`,
+		Reason:  "asks to push to a protected branch or force-push",
+	},
+	{
+		Name:    "disable-safety",
+		Pattern: `(?i)\b(disable|turn off|bypass|escape)\b.{0,30}\b(sandbox|redaction|audit|safety|guardrails?)\b`,
+		Reason:  "asks to disable MADFLOW safety features",
+	},
+}
+
+// Classifier is an optional second stage that judges text the rules did not flag.
+type Classifier interface {
+	// Classify reports whether text is a prompt-injection attempt and why.
+	Classify(ctx context.Context, text string) (suspicious bool, reason string, err error)
+}
+
+// Options configures a Screener.
+type Options struct {
+	// Rules are user-defined rules applied in addition to the built-ins.
+	Rules []RuleSpec
+	// DisableBuiltinRules drops the built-in rules (user rules still apply).
+	DisableBuiltinRules bool
+	// Classifier is consulted when no rule matches. nil disables it.
+	Classifier Classifier
+}
+
+// Screener checks untrusted text for prompt-injection attempts.
+// A nil *Screener flags nothing.
+type Screener struct {
+	rules      []Rule
+	classifier Classifier
+}
+
+// New compiles the configured rules.
+func New(opts Options) (*Screener, error) {
+	var specs []RuleSpec
+	if !opts.DisableBuiltinRules {
+		specs = append(specs, builtinRules...)
+	}
+	specs = append(specs, opts.Rules...)
+
+	s := &Screener{classifier: opts.Classifier}
+	for i, spec := range specs {
+		re, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("screening rule %q: invalid pattern: %w", spec.Name, err)
+		}
+		name := spec.Name
+		if name == "" {
+			name = fmt.Sprintf("rule-%d", i+1)
+		}
+		reason := spec.Reason
+		if reason == "" {
+			reason = "matches screening rule " + name
+		}
+		s.rules = append(s.rules, Rule{Name: name, Pattern: re, Reason: reason})
+	}
+	return s, nil
+}
+
+// maxExcerpt limits the excerpt reported in a Finding.
+const maxExcerpt = 80
+
+// Screen checks text and returns a Finding when it looks like a
+// prompt-injection attempt, or nil when it is clean. Classifier errors are
+// logged and treated as clean so that an unavailable API does not block work;
+// rule matches are always reported.
+func (s *Screener) Screen(ctx context.Context, text string) *Finding {
+	if s == nil || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	for _, r := range s.rules {
+		if m := r.Pattern.FindString(text); m != "" {
+			if len(m) > maxExcerpt {
+				m = m[:maxExcerpt] + "..."
+			}
+			return &Finding{Rule: r.Name, Reason: r.Reason, Excerpt: m}
+		}
+	}
+	if s.classifier == nil {
+		return nil
+	}
+	suspicious, reason, err := s.classifier.Classify(ctx, text)
+	if err != nil {
+		log.Printf("[screen] classifier failed, treating text as clean: %v", err)
+		return nil
+	}
+	if !suspicious {
+		return nil
+	}
+	if reason == "" {
+		reason = "classified as a prompt-injection attempt"
+	}
+	return &Finding{Rule: "classifier", Reason: reason}
+}
+
+// QuotedText returns the quoted lines ("> ...") of a Markdown comment.
+// Comments from authorized users are trusted, but text they quote from
+// others is not.
+func QuotedText(body string) string {
+	var quoted []string
+	for _, line := range strings.Split(body, "\n") {
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, ">") {
+			quoted = append(quoted, strings.TrimSpace(strings.TrimLeft(t, ">")))
+		}
+	}
+	return strings.Join(quoted, "\n")
+}
@@ -0,0 +1,34 @@
+// Package shell runs user-configured command lines, such as bootstrap and
+// test commands from madflow.toml, with the platform shell.
+package shell
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// waitDelay is how long a cancelled command's output is still read. Without
+// it, a child process that outlives the killed shell keeps the output open
+// and Wait blocks until the child exits.
+const waitDelay = time.Second
+
+// CommandFunc builds the command that runs a command line in dir, such as
+// Command or a sandboxed variant of it.
+type CommandFunc func(ctx context.Context, dir, line string) *exec.Cmd
+
+// Command returns a command that runs line with sh -c, or cmd /C on
+// Windows, in dir. When ctx is done the shell is killed and the output
+// read so far is returned shortly after.
+func Command(ctx context.Context, dir, line string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", line)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", line)
+	}
+	cmd.Dir = dir
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 	"sync"
 	"time"
 
 	"github.com/ytnobody/madflow/internal/agent"
 	"github.com/ytnobody/madflow/internal/chatlog"
+	"github.com/ytnobody/madflow/internal/filelock"
 )
 
 // announceStart は各エージェントの作業開始をチャットログに報告する。
@@ -43,15 +43,11 @@ func announceStart(team *Team) {
 	}
 }
 
-// appendLine はチャットログファイルに1行追記する。
+// appendLine はチャットログファイルにロックを取得して1行追記する。
 func appendLine(path, line string) {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		log.Printf("[team] announce: open %s: %v", path, err)
-		return
+	if err := filelock.AppendLines(path, line); err != nil {
+		log.Printf("[team] announce: append %s: %v", path, err)
 	}
-	defer f.Close()
-	fmt.Fprintln(f, line)
 }
 
 // Team represents a task force team (engineer).
@@ -61,6 +57,8 @@ type Team struct {
 	IssueTitle string
 	Engineer   *agent.Agent
 	cancel     context.CancelFunc
+	// paused is set by Manager.Pause. It is guarded by Manager.mu.
+	paused bool
 }
 
 // DefaultMaxTeams is the default maximum number of concurrent teams.
@@ -71,10 +69,15 @@ type Manager struct {
 	mu            sync.Mutex
 	teams         map[int]*Team
 	pendingIssues map[string]bool // issues currently being created (not yet in teams)
-	pendingCount  int             // number of teams being created (not yet in teams)
+	pendingTeams  map[int]string  // teams being created (not yet in teams): number -> issue ID
 	nextID        int
 	maxTeams      int
 	factory       TeamFactory
+
+	// allPaused pauses every team and new team creation (PauseAll).
+	// creationPaused pauses new team creation only (PauseCreation).
+	allPaused      bool
+	creationPaused bool
 }
 
 // TeamFactory creates agents for a team. Provided by the orchestrator.
@@ -89,6 +92,7 @@ func NewManager(factory TeamFactory, maxTeams int) *Manager {
 	return &Manager{
 		teams:         make(map[int]*Team),
 		pendingIssues: make(map[string]bool),
+		pendingTeams:  make(map[int]string),
 		nextID:        1,
 		maxTeams:      maxTeams,
 		factory:       factory,
@@ -99,8 +103,12 @@ func NewManager(factory TeamFactory, maxTeams int) *Manager {
 // issueTitle is included in the chatlog announcement so it's clear what work will be done.
 func (m *Manager) Create(ctx context.Context, issueID, issueTitle string) (*Team, error) {
 	m.mu.Lock()
+	if m.allPaused || m.creationPaused {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("team creation is paused")
+	}
 	// Count both active teams and teams being created to prevent maxTeams bypass.
-	totalSlots := len(m.teams) + m.pendingCount
+	totalSlots := len(m.teams) + len(m.pendingTeams)
 	if totalSlots >= m.maxTeams {
 		m.mu.Unlock()
 		return nil, fmt.Errorf("maximum number of concurrent teams reached (%d)", m.maxTeams)
@@ -113,7 +121,7 @@ func (m *Manager) Create(ctx context.Context, issueID, issueTitle string) (*Team
 	teamNum := m.nextID
 	m.nextID++
 	// Mark this slot and issue as pending before releasing the lock.
-	m.pendingCount++
+	m.pendingTeams[teamNum] = issueID
 	if issueID != "" {
 		m.pendingIssues[issueID] = true
 	}
@@ -123,7 +131,7 @@ func (m *Manager) Create(ctx context.Context, issueID, issueTitle string) (*Team
 	engineer, err := m.factory.CreateTeamAgents(teamNum, issueID)
 	if err != nil {
 		m.mu.Lock()
-		m.pendingCount--
+		delete(m.pendingTeams, teamNum)
 		delete(m.pendingIssues, issueID)
 		m.mu.Unlock()
 		return nil, fmt.Errorf("create team agents: %w", err)
@@ -139,11 +147,15 @@ func (m *Manager) Create(ctx context.Context, issueID, issueTitle string) (*Team
 		cancel:     cancel,
 	}
 
-	// Move from pending to active.
+	// Move from pending to active. A team that finishes creation after
+	// PauseAll starts paused.
 	m.mu.Lock()
 	m.teams[teamNum] = team
-	m.pendingCount--
+	delete(m.pendingTeams, teamNum)
 	delete(m.pendingIssues, issueID)
+	if m.allPaused {
+		engineer.Pause()
+	}
 	m.mu.Unlock()
 
 	// Start the engineer agent with restart on unexpected exit.
@@ -157,6 +169,11 @@ func (m *Manager) Create(ctx context.Context, issueID, issueTitle string) (*Team
 				return
 			}
 			log.Printf("[team-%d] engineer exited: %v, restarting in 5s", teamNum, err)
+			if issueID != "" {
+				// Recorded for the superintendent and for lesson scoring.
+				appendLine(engineer.ChatLog.Path(), chatlog.FormatMessage("superintendent", "orchestrator",
+					fmt.Sprintf("%s が異常終了したため再起動します。イシュー: %s", engineer.ID, issueID)))
+			}
 			select {
 			case <-teamCtx.Done():
 				return
@@ -231,11 +248,24 @@ func (m *Manager) List() []TeamInfo {
 		infos = append(infos, TeamInfo{
 			ID:      t.ID,
 			IssueID: t.IssueID,
+			Paused:  t.paused || m.allPaused,
 		})
 	}
 	return infos
 }
 
+// Pending returns the teams being created, whose agents are not started yet.
+func (m *Manager) Pending() []TeamInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]TeamInfo, 0, len(m.pendingTeams))
+	for num, issueID := range m.pendingTeams {
+		infos = append(infos, TeamInfo{ID: num, IssueID: issueID})
+	}
+	return infos
+}
+
 // AssignIdle looks for a standby team (a team with no issue currently assigned)
 // and assigns the given issue to it. Returns the team and true if an idle team
 // was found and assigned; returns nil and false if all teams are busy.
@@ -243,11 +273,12 @@ func (m *Manager) List() []TeamInfo {
 // This is called by the orchestrator when TEAM_CREATE is received and all team
 // slots are already occupied by standby teams, preventing the "maximum teams
 // reached" error by reusing an existing idle team instead of spawning a new one.
+// Paused teams are not assigned.
 func (m *Manager) AssignIdle(issueID, issueTitle string) (*Team, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for _, t := range m.teams {
-		if t.IssueID == "" {
+		if t.IssueID == "" && !t.paused && !m.allPaused {
 			t.IssueID = issueID
 			t.IssueTitle = issueTitle
 			return t, true
@@ -256,6 +287,29 @@ func (m *Manager) AssignIdle(issueID, issueTitle string) (*Team, bool) {
 	return nil, false
 }
 
+// Engineer returns the engineer agent of team teamNum.
+func (m *Manager) Engineer(teamNum int) (*agent.Agent, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.teams[teamNum]
+	if !ok {
+		return nil, false
+	}
+	return t.Engineer, true
+}
+
+// FindByIssue returns the number of the active team assigned to issueID.
+func (m *Manager) FindByIssue(issueID string) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for num, t := range m.teams {
+		if t.IssueID == issueID {
+			return num, true
+		}
+	}
+	return 0, false
+}
+
 // HasIssue returns true if any active or pending team is assigned to the given issue.
 func (m *Manager) HasIssue(issueID string) bool {
 	m.mu.Lock()
@@ -276,7 +330,7 @@ func (m *Manager) HasIssue(issueID string) bool {
 func (m *Manager) Count() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return len(m.teams) + m.pendingCount
+	return len(m.teams) + len(m.pendingTeams)
 }
 
 // Full returns true if the manager has reached the maximum number of teams
@@ -285,7 +339,7 @@ func (m *Manager) Count() int {
 func (m *Manager) Full() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return len(m.teams)+m.pendingCount >= m.maxTeams
+	return len(m.teams)+len(m.pendingTeams) >= m.maxTeams
 }
 
 // Cap returns the configured maximum number of concurrent teams.
@@ -308,8 +362,98 @@ func (m *Manager) SetMaxTeams(n int) {
 	m.mu.Unlock()
 }
 
+// Pause stops team teamNum's engineer from consuming chatlog messages. Its
+// process and context are kept; held messages are delivered on Resume.
+func (m *Manager) Pause(teamNum int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.teams[teamNum]
+	if !ok {
+		return fmt.Errorf("team %d not found", teamNum)
+	}
+	t.paused = true
+	t.Engineer.Pause()
+	log.Printf("[team-%d] paused (issue %s)", teamNum, t.IssueID)
+	return nil
+}
+
+// Resume resumes a team paused by Pause. A team stays paused while PauseAll
+// is in effect.
+func (m *Manager) Resume(teamNum int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.teams[teamNum]
+	if !ok {
+		return fmt.Errorf("team %d not found", teamNum)
+	}
+	if m.allPaused {
+		return fmt.Errorf("all teams are paused; resume them all instead")
+	}
+	t.paused = false
+	t.Engineer.Resume()
+	log.Printf("[team-%d] resumed (issue %s)", teamNum, t.IssueID)
+	return nil
+}
+
+// PauseAll pauses every team and new team creation.
+func (m *Manager) PauseAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allPaused = true
+	for _, t := range m.teams {
+		t.Engineer.Pause()
+	}
+	log.Printf("[team] all teams paused")
+}
+
+// ResumeAll clears every pause: all teams, including teams paused
+// individually, and new team creation.
+func (m *Manager) ResumeAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allPaused = false
+	m.creationPaused = false
+	for _, t := range m.teams {
+		t.paused = false
+		t.Engineer.Resume()
+	}
+	log.Printf("[team] all teams resumed")
+}
+
+// PauseCreation rejects new teams while existing teams keep working.
+func (m *Manager) PauseCreation() {
+	m.mu.Lock()
+	m.creationPaused = true
+	m.mu.Unlock()
+}
+
+// ResumeCreation allows new teams again. Creation stays paused while
+// PauseAll is in effect.
+func (m *Manager) ResumeCreation() {
+	m.mu.Lock()
+	m.creationPaused = false
+	m.mu.Unlock()
+}
+
+// CreationPaused reports whether new teams are rejected, by PauseCreation
+// or PauseAll.
+func (m *Manager) CreationPaused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.creationPaused || m.allPaused
+}
+
+// AllPaused reports whether PauseAll is in effect.
+func (m *Manager) AllPaused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.allPaused
+}
+
 // TeamInfo is a read-only snapshot of a team's state.
 type TeamInfo struct {
 	ID      int
 	IssueID string
+	// Paused is true when the team is paused individually or by PauseAll.
+	Paused bool
 }
@@ -20,7 +20,8 @@ import (
 // context cancellation).
 type mockFactory struct {
 	shouldFail bool
-	tmpDir     string // temp dir for chatlog files
+	tmpDir     string        // temp dir for chatlog files
+	gate       chan struct{} // when set, CreateTeamAgents waits for it to close
 }
 
 func newMockFactory(t *testing.T) *mockFactory {
@@ -29,6 +30,9 @@ func newMockFactory(t *testing.T) *mockFactory {
 }
 
 func (m *mockFactory) CreateTeamAgents(teamNum int, issueID string) (engineer *agent.Agent, err error) {
+	if m.gate != nil {
+		<-m.gate
+	}
 	if m.shouldFail {
 		return nil, fmt.Errorf("factory error")
 	}
@@ -179,6 +183,40 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestPending(t *testing.T) {
+	factory := newMockFactory(t)
+	factory.gate = make(chan struct{})
+	mgr := NewManager(factory, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	created := make(chan error)
+	go func() {
+		_, err := mgr.Create(ctx, "issue-001", "")
+		created <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(mgr.Pending()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	pending := mgr.Pending()
+	if len(pending) != 1 || pending[0].ID != 1 || pending[0].IssueID != "issue-001" {
+		t.Fatalf("Pending() = %+v, want team 1 for issue-001", pending)
+	}
+	if len(mgr.List()) != 0 {
+		t.Errorf("a team being created should not be listed as active")
+	}
+
+	close(factory.gate)
+	if err := <-created; err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if pending := mgr.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() after creation = %+v, want none", pending)
+	}
+}
+
 func TestDisband(t *testing.T) {
 	factory := newMockFactory(t)
 	m := NewManager(factory, 0)
@@ -581,3 +619,89 @@ func TestSetMaxTeams(t *testing.T) {
 		t.Errorf("expected Cap()=%d after SetMaxTeams(0), got %d", DefaultMaxTeams, m.Cap())
 	}
 }
+
+func pausedTeams(m *Manager) map[int]bool {
+	paused := make(map[int]bool)
+	for _, info := range m.List() {
+		paused[info.ID] = info.Paused
+	}
+	return paused
+}
+
+func TestPauseResumeTeam(t *testing.T) {
+	m := NewManager(newMockFactory(t), 4)
+	t1 := createAndCancel(t, m, "issue-1")
+	t2 := createAndCancel(t, m, "issue-2")
+
+	if err := m.Pause(t1.ID); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if got := pausedTeams(m); !got[t1.ID] || got[t2.ID] {
+		t.Errorf("after Pause(%d): paused = %v", t1.ID, got)
+	}
+	if !t1.Engineer.Paused() || t2.Engineer.Paused() {
+		t.Error("Pause should pause only the team's engineer")
+	}
+
+	if err := m.Resume(t1.ID); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if got := pausedTeams(m); got[t1.ID] {
+		t.Errorf("after Resume: paused = %v", got)
+	}
+	if t1.Engineer.Paused() {
+		t.Error("Resume should resume the engineer")
+	}
+
+	if err := m.Pause(99); err == nil {
+		t.Error("Pause of an unknown team should fail")
+	}
+}
+
+func TestPauseAll(t *testing.T) {
+	m := NewManager(newMockFactory(t), 4)
+	t1 := createAndCancel(t, m, "")
+	m.PauseAll()
+
+	if got := pausedTeams(m); !got[t1.ID] {
+		t.Errorf("after PauseAll: paused = %v", got)
+	}
+	if !m.CreationPaused() {
+		t.Error("PauseAll should pause team creation")
+	}
+	if _, err := m.Create(context.Background(), "issue-2", ""); err == nil {
+		t.Error("Create should fail while all teams are paused")
+	}
+	if _, ok := m.AssignIdle("issue-3", ""); ok {
+		t.Error("AssignIdle should not assign a paused team")
+	}
+	if err := m.Resume(t1.ID); err == nil {
+		t.Error("Resume of one team should fail while all teams are paused")
+	}
+
+	m.ResumeAll()
+	if got := pausedTeams(m); got[t1.ID] {
+		t.Errorf("after ResumeAll: paused = %v", got)
+	}
+	if m.CreationPaused() || t1.Engineer.Paused() {
+		t.Error("ResumeAll should clear every pause")
+	}
+}
+
+func TestPauseCreation(t *testing.T) {
+	m := NewManager(newMockFactory(t), 4)
+	t1 := createAndCancel(t, m, "issue-1")
+	m.PauseCreation()
+
+	if _, err := m.Create(context.Background(), "issue-2", ""); err == nil || !strings.Contains(err.Error(), "paused") {
+		t.Errorf("Create while creation is paused: err = %v", err)
+	}
+	if got := pausedTeams(m); got[t1.ID] {
+		t.Error("PauseCreation should not pause existing teams")
+	}
+
+	m.ResumeCreation()
+	if m.CreationPaused() {
+		t.Error("CreationPaused() = true after ResumeCreation")
+	}
+}
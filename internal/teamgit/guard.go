@@ -0,0 +1,159 @@
+package teamgit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/filelock"
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+// Guard are the rules the pre-commit and pre-push hooks enforce.
+type Guard struct {
+	// Branch is the only branch that may be pushed, e.g. feature/issue-gh-12.
+	Branch string
+	// AllowForcePush permits pushes that are not fast-forwards.
+	AllowForcePush bool
+	// ProtectedPaths are shell case patterns (where * also matches /) of
+	// files that may not be changed without approval (see ApproveProtectedPaths).
+	ProtectedPaths []string
+	// MaxDiffLines limits the lines added plus deleted by a pushed branch
+	// relative to Base. 0 means no limit.
+	MaxDiffLines int
+	// Base is the branch the protected paths and diff size of a push are
+	// compared with; origin/<Base> is used when it exists.
+	Base string
+	// ApprovalDir holds the approval markers of ApproveProtectedPaths. It
+	// must lie outside the paths the agent can write (the repositories and
+	// the data directory), or the agent could approve itself. Empty means
+	// protected paths cannot be approved.
+	ApprovalDir string
+	// ChatlogPath and AgentID: violations are also written to the chatlog,
+	// addressed to AgentID, under the chatlog's lock. Empty ChatlogPath only
+	// prints them.
+	ChatlogPath string
+	AgentID     string
+}
+
+// approvalPath returns the approval marker, in approvalDir, of the worktree
+// whose git directory is gitDir.
+func approvalPath(approvalDir, gitDir string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(gitDir)))
+	return filepath.Join(approvalDir, hex.EncodeToString(sum[:8]))
+}
+
+// ApproveProtectedPaths allows (or, with approved unset, disallows again)
+// changes to the protected paths in the worktree wt, by writing a marker to
+// approvalDir (see Guard.ApprovalDir).
+func ApproveProtectedPaths(wt *git.Repo, approvalDir string, approved bool) error {
+	gitDir, err := wt.GitDir()
+	if err != nil {
+		return err
+	}
+	if approvalDir == "" {
+		return fmt.Errorf("approve protected paths: no approval directory")
+	}
+	path := approvalPath(approvalDir, gitDir)
+	if !approved {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("revoke approval: %w", err)
+		}
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, HooksDir)); err != nil {
+		return fmt.Errorf("approve protected paths: %s has no managed hooks", wt.Path())
+	}
+	if err := os.MkdirAll(approvalDir, 0700); err != nil {
+		return fmt.Errorf("approve protected paths: %w", err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return fmt.Errorf("approve protected paths: %w", err)
+	}
+	return nil
+}
+
+// prelude defines the shell functions shared by the guard hooks.
+func (g *Guard) prelude(dir string) string {
+	var b strings.Builder
+	b.WriteString("report() {\n\techo \"madflow guardrail: $1\" >&2\n")
+	if g.ChatlogPath != "" {
+		// Appended under the chatlog lock (filelock), so that a concurrent
+		// rotation cannot drop the line; without flock(1) the violation is
+		// only printed.
+		chatlog := filepath.ToSlash(g.ChatlogPath)
+		fmt.Fprintf(&b, "\tif command -v flock >/dev/null 2>&1; then\n\t\t{ flock 9 && printf '[%%s] [@%%s] orchestrator: [guardrail] %%s\\n' \"$(date +%%Y-%%m-%%dT%%H:%%M:%%S)\" %s \"$1\" >> %s; } 9>> %s\n\tfi\n",
+			shellQuote(g.AgentID), shellQuote(chatlog), shellQuote(chatlog+filelock.LockSuffix))
+	}
+	b.WriteString("\texit 1\n}\n")
+	if len(g.ProtectedPaths) > 0 {
+		// The patterns are validated by the config and are used unquoted
+		// so that their wildcards apply.
+		b.WriteString("protected() {\n")
+		if g.ApprovalDir != "" {
+			fmt.Fprintf(&b, "\t[ -f %s ] && return\n", shellQuote(filepath.ToSlash(approvalPath(g.ApprovalDir, filepath.Dir(dir)))))
+		}
+		fmt.Fprintf(&b, "\twhile IFS= read -r f; do\n\t\tcase \"$f\" in\n\t\t%s) printf '%%s ' \"$f\" ;;\n\t\tesac\n\tdone\n}\n",
+			strings.Join(g.ProtectedPaths, "|"))
+	}
+	return b.String()
+}
+
+// preCommit is the body of the pre-commit hook: staged changes to protected
+// paths are refused.
+func (g *Guard) preCommit(dir string) string {
+	if len(g.ProtectedPaths) == 0 {
+		return ""
+	}
+	return g.prelude(dir) + `files=$(git diff --cached --name-only | protected)
+if [ -n "$files" ]; then
+	report "changes to protected paths need the superintendent's approval (GUARD_APPROVE): $files"
+fi
+`
+}
+
+// prePush is the body of the pre-push hook. It reads the refs being pushed
+// from standard input into $input, which is passed on to the repository's
+// own pre-push hook.
+func (g *Guard) prePush(dir string) string {
+	var b strings.Builder
+	b.WriteString(g.prelude(dir))
+	fmt.Fprintf(&b, "branch=%s\nbase=%s\n", shellQuote("refs/heads/"+g.Branch), shellQuote("origin/"+g.Base))
+	fmt.Fprintf(&b, "git rev-parse -q --verify \"$base\" >/dev/null || base=%s\n", shellQuote(g.Base))
+	b.WriteString(`zero() { case "$1" in *[!0]*) return 1 ;; esac; }
+input=$(cat)
+while read -r lref lsha rref rsha; do
+	[ -n "$rref" ] || continue
+	if [ "$rref" != "$branch" ]; then
+		report "push to $rref is not allowed; only ${branch#refs/heads/} may be pushed"
+	fi
+	if zero "$lsha"; then
+		report "deleting $rref is not allowed"
+	fi
+`)
+	if !g.AllowForcePush {
+		b.WriteString(`	if ! zero "$rsha" && ! git merge-base --is-ancestor "$rsha" "$lsha" 2>/dev/null; then
+		report "force push to $rref is not allowed"
+	fi
+`)
+	}
+	if len(g.ProtectedPaths) > 0 {
+		b.WriteString(`	files=$(git diff --name-only "$base...$lsha" | protected)
+	if [ -n "$files" ]; then
+		report "changes to protected paths need the superintendent's approval (GUARD_APPROVE): $files"
+	fi
+`)
+	}
+	if g.MaxDiffLines > 0 {
+		fmt.Fprintf(&b, `	lines=$(git diff --numstat "$base...$lsha" | awk '{n += $1 + $2} END {print n + 0}')
+	if [ "${lines:-0}" -gt %d ]; then
+		report "the branch changes $lines lines against $base, more than the limit of %d; split the work"
+	fi
+`, g.MaxDiffLines, g.MaxDiffLines)
+	}
+	b.WriteString("done <<EOF\n$input\nEOF\n")
+	return b.String()
+}
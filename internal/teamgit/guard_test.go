@@ -0,0 +1,172 @@
+package teamgit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/filelock"
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+// initGuardedWorktree returns a worktree on branch feature with a bare
+// origin, set up with guard g.
+func initGuardedWorktree(t *testing.T, g Guard) string {
+	t.Helper()
+	repo, wt := initWorktree(t)
+	origin := t.TempDir()
+	runGit(t, origin, "init", "--bare")
+	runGit(t, repo, "remote", "add", "origin", origin)
+	runGit(t, repo, "push", "origin", "main")
+	runGit(t, repo, "fetch", "origin")
+	os.WriteFile(filepath.Join(repo, ".git", "hooks", "pre-push"), []byte("#!/bin/sh\ncat >> \"$(git rev-parse --absolute-git-dir)/pre-push-input\"\n"), 0755)
+
+	g.Branch, g.Base = "feature", "main"
+	if err := Setup(git.NewRepo(wt), Options{Guard: &g}); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	return wt
+}
+
+// tryGit runs git in dir and returns its combined output and whether it
+// succeeded.
+func tryGit(dir string, args ...string) (string, bool) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err == nil
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+	os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-m", "change "+name)
+}
+
+func TestGuardPush(t *testing.T) {
+	chatlog := filepath.Join(t.TempDir(), "chatlog.txt")
+	wt := initGuardedWorktree(t, Guard{ChatlogPath: chatlog, AgentID: "engineer-1"})
+
+	commitFile(t, wt, "a.txt", "a\n")
+	if out, ok := tryGit(wt, "push", "origin", "feature"); !ok {
+		t.Fatalf("push to the team's branch should succeed: %s", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(runGit(t, wt, "rev-parse", "--absolute-git-dir"), "pre-push-input")); !strings.Contains(string(data), "refs/heads/feature") {
+		t.Errorf("the repository's pre-push hook should get the pushed refs, got %q", data)
+	}
+
+	if out, ok := tryGit(wt, "push", "origin", "feature:main"); ok || !strings.Contains(out, "push to refs/heads/main is not allowed") {
+		t.Errorf("push to main should be refused: %s", out)
+	}
+	if out, ok := tryGit(wt, "push", "origin", ":feature"); ok || !strings.Contains(out, "deleting refs/heads/feature is not allowed") {
+		t.Errorf("deleting the branch should be refused: %s", out)
+	}
+	runGit(t, wt, "commit", "--amend", "-m", "rewritten")
+	if out, ok := tryGit(wt, "push", "--force", "origin", "feature"); ok || !strings.Contains(out, "force push") {
+		t.Errorf("force push should be refused: %s", out)
+	}
+
+	if _, err := exec.LookPath("flock"); err != nil {
+		return
+	}
+	data, _ := os.ReadFile(chatlog)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "[@engineer-1] orchestrator: [guardrail] push to refs/heads/main is not allowed") {
+		t.Errorf("violations should be reported to the chatlog, got:\n%s", data)
+	}
+}
+
+func TestGuardReportTakesChatlogLock(t *testing.T) {
+	if _, err := exec.LookPath("flock"); err != nil {
+		t.Skip("flock not installed")
+	}
+	chatlog := filepath.Join(t.TempDir(), "chatlog.txt")
+	wt := initGuardedWorktree(t, Guard{ChatlogPath: chatlog, AgentID: "engineer-1"})
+	commitFile(t, wt, "a.txt", "a\n")
+
+	lock, err := filelock.Acquire(chatlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan string)
+	go func() {
+		out, _ := tryGit(wt, "push", "origin", "feature:main")
+		done <- out
+	}()
+	select {
+	case out := <-done:
+		t.Fatalf("the hook should wait for the chatlog lock: %s", out)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if data, _ := os.ReadFile(chatlog); len(data) != 0 {
+		t.Errorf("the chatlog should not be written while locked, got %q", data)
+	}
+	lock.Release()
+	<-done
+	if data, _ := os.ReadFile(chatlog); !strings.Contains(string(data), "[guardrail] push to refs/heads/main is not allowed") {
+		t.Errorf("the violation should be written after the lock is released, got %q", data)
+	}
+}
+
+func TestGuardAllowForcePush(t *testing.T) {
+	wt := initGuardedWorktree(t, Guard{AllowForcePush: true})
+	commitFile(t, wt, "a.txt", "a\n")
+	runGit(t, wt, "push", "origin", "feature")
+	runGit(t, wt, "commit", "--amend", "-m", "rewritten")
+	if out, ok := tryGit(wt, "push", "--force", "origin", "feature"); !ok {
+		t.Errorf("force push should be allowed: %s", out)
+	}
+}
+
+func TestGuardProtectedPaths(t *testing.T) {
+	approvals := t.TempDir()
+	wt := initGuardedWorktree(t, Guard{ProtectedPaths: []string{".github/workflows/*", "go.mod"}, ApprovalDir: approvals})
+
+	commitFile(t, wt, "docs/a.md", "a\n")
+	os.MkdirAll(filepath.Join(wt, ".github", "workflows"), 0755)
+	os.WriteFile(filepath.Join(wt, ".github", "workflows", "ci.yml"), []byte("on: push\n"), 0644)
+	runGit(t, wt, "add", ".")
+	if out, ok := tryGit(wt, "commit", "-m", "ci"); ok || !strings.Contains(out, ".github/workflows/ci.yml") {
+		t.Fatalf("commit to a protected path should be refused: %s", out)
+	}
+	// Committing with --no-verify does not get it pushed either.
+	runGit(t, wt, "commit", "--no-verify", "-m", "ci")
+	if out, ok := tryGit(wt, "push", "origin", "feature"); ok || !strings.Contains(out, "protected paths") {
+		t.Errorf("push with a protected path should be refused: %s", out)
+	}
+
+	if err := ApproveProtectedPaths(git.NewRepo(wt), approvals, true); err != nil {
+		t.Fatalf("ApproveProtectedPaths: %v", err)
+	}
+	if entries, _ := os.ReadDir(approvals); len(entries) != 1 {
+		t.Errorf("the approval should be kept in the approval directory, got %v", entries)
+	}
+	if out, ok := tryGit(wt, "push", "origin", "feature"); !ok {
+		t.Errorf("push should succeed after approval: %s", out)
+	}
+	if err := ApproveProtectedPaths(git.NewRepo(wt), approvals, false); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	os.WriteFile(filepath.Join(wt, "go.mod"), []byte("module x\n"), 0644)
+	runGit(t, wt, "add", "go.mod")
+	if out, ok := tryGit(wt, "commit", "-m", "mod"); ok || !strings.Contains(out, "go.mod") {
+		t.Errorf("commit should be refused again after revoking: %s", out)
+	}
+}
+
+func TestGuardMaxDiffLines(t *testing.T) {
+	wt := initGuardedWorktree(t, Guard{MaxDiffLines: 3})
+	commitFile(t, wt, "a.txt", "1\n2\n3\n")
+	if out, ok := tryGit(wt, "push", "origin", "feature"); !ok {
+		t.Fatalf("push within the limit should succeed: %s", out)
+	}
+	commitFile(t, wt, "b.txt", "4\n")
+	if out, ok := tryGit(wt, "push", "origin", "feature"); ok || !strings.Contains(out, "changes 4 lines") {
+		t.Errorf("push over the limit should be refused: %s", out)
+	}
+}
@@ -0,0 +1,176 @@
+// Package teamgit sets up a team's worktree so that the commits made in it
+// identify the team: a git identity of its own and a commit-msg hook that
+// adds MADFLOW trailers (issue, team, model) to every commit message.
+// Optionally pre-commit and pre-push hooks enforce guardrails (see Guard).
+//
+// Both live in the worktree's own configuration and git directory, so the
+// main worktree and the other teams are not affected. Hooks the repository
+// already has keep running: the managed hooks directory contains a wrapper
+// for each of them.
+package teamgit
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+// HooksDir is the managed hooks directory inside the worktree's git
+// directory (.git/worktrees/<name>/madflow-hooks).
+const HooksDir = "madflow-hooks"
+
+// Trailer is a "Key: Value" line added to commit messages.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Options describe the team working in a worktree.
+type Options struct {
+	// Name and Email become user.name and user.email of the worktree.
+	// Empty values keep the identity configured for the repository.
+	Name  string
+	Email string
+	// Trailers are added to every commit message, replacing trailers with
+	// the same key (so amending a commit does not duplicate them).
+	Trailers []Trailer
+	// Guard, when set, installs pre-commit and pre-push hooks enforcing it.
+	Guard *Guard
+}
+
+// Setup configures the worktree wt for a team. It can be called again on
+// the same worktree, e.g. when another team takes it over; the previous
+// settings are replaced.
+func Setup(wt *git.Repo, opts Options) error {
+	shared, err := wt.SharedHooksDir()
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(shared) {
+		// A relative core.hooksPath is relative to the worktree.
+		shared = filepath.Join(wt.Path(), shared)
+	}
+	if err := wt.EnableWorktreeConfig(); err != nil {
+		return err
+	}
+	if opts.Name != "" {
+		if err := wt.SetWorktreeConfig("user.name", opts.Name); err != nil {
+			return err
+		}
+	}
+	if opts.Email != "" {
+		if err := wt.SetWorktreeConfig("user.email", opts.Email); err != nil {
+			return err
+		}
+	}
+
+	gitDir, err := wt.GitDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(gitDir, HooksDir)
+	if err := writeHooks(dir, shared, opts); err != nil {
+		return err
+	}
+	if g := opts.Guard; g != nil && g.ApprovalDir != "" {
+		// An approval is for the issue the worktree was set up for.
+		if err := ApproveProtectedPaths(wt, g.ApprovalDir, false); err != nil {
+			return err
+		}
+	}
+	return wt.SetWorktreeConfig("core.hooksPath", dir)
+}
+
+// writeHooks replaces dir with the managed hooks: the commit-msg hook adding
+// the trailers, the guard hooks, and a wrapper for every hook in the shared
+// hooks directory.
+func writeHooks(dir, shared string, opts Options) error {
+	bodies := make(map[string]string)
+	if len(opts.Trailers) > 0 {
+		bodies["commit-msg"] = trailerHook(opts.Trailers)
+	}
+	if g := opts.Guard; g != nil {
+		if body := g.preCommit(dir); body != "" {
+			bodies["pre-commit"] = body
+		}
+		bodies["pre-push"] = g.prePush(dir)
+	}
+	existing := sharedHooks(shared)
+	names := slices.Collect(maps.Keys(bodies))
+	for _, name := range existing {
+		if _, ok := bodies[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("write hooks: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("write hooks: %w", err)
+	}
+	for _, name := range names {
+		var b strings.Builder
+		b.WriteString("#!/bin/sh\n# Managed by MADFLOW; rewritten whenever the team's worktree is set up.\n")
+		b.WriteString(bodies[name])
+		if slices.Contains(existing, name) {
+			hook := shellQuote(filepath.ToSlash(filepath.Join(shared, name)))
+			if name == "pre-push" && opts.Guard != nil {
+				// The guard has read the pushed refs from standard input.
+				fmt.Fprintf(&b, "printf '%%s\\n' \"$input\" | %s \"$@\"\n", hook)
+			} else {
+				fmt.Fprintf(&b, "exec %s \"$@\"\n", hook)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0755); err != nil {
+			return fmt.Errorf("write hooks: %w", err)
+		}
+	}
+	return nil
+}
+
+// trailerHook is the body of the commit-msg hook: it adds the trailers to
+// the message file ($1).
+func trailerHook(trailers []Trailer) string {
+	var b strings.Builder
+	b.WriteString("git interpret-trailers --in-place --if-exists replace")
+	for _, t := range trailers {
+		fmt.Fprintf(&b, " \\\n\t--trailer %s", shellQuote(t.Key+": "+t.Value))
+	}
+	b.WriteString(" \\\n\t\"$1\" || exit 1\n")
+	return b.String()
+}
+
+// sharedHooks returns the names of the hooks installed in dir. Sample hooks
+// and, except on Windows where git ignores the mode, files that are not
+// executable are skipped.
+func sharedHooks(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".sample") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// shellQuote quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,103 @@
+package teamgit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// initWorktree creates a repository with a pre-commit hook that records its
+// runs, and a linked worktree on branch feature.
+func initWorktree(t *testing.T) (repo, wt string) {
+	t.Helper()
+	repo = t.TempDir()
+	runGit(t, repo, "init", "-b", "main")
+	runGit(t, repo, "config", "user.email", "host@test.com")
+	runGit(t, repo, "config", "user.name", "Host User")
+	runGit(t, repo, "commit", "--allow-empty", "-m", "initial commit")
+	hook := "#!/bin/sh\necho ran >> \"$(git rev-parse --absolute-git-dir)/pre-commit-ran\"\n"
+	os.WriteFile(filepath.Join(repo, ".git", "hooks", "pre-commit"), []byte(hook), 0755)
+
+	wt = filepath.Join(repo, ".worktrees", "team-1")
+	runGit(t, repo, "worktree", "add", "-b", "feature", wt)
+	return repo, wt
+}
+
+func TestSetup(t *testing.T) {
+	repo, wt := initWorktree(t)
+	opts := Options{
+		Name:  "madflow-engineer-1",
+		Email: "madflow-engineer-1@madflow.invalid",
+		Trailers: []Trailer{
+			{"Madflow-Issue", "gh-12"},
+			{"Madflow-Team", "1"},
+			{"Madflow-Model", "claude-sonnet-4-6"},
+		},
+	}
+	if err := Setup(git.NewRepo(wt), opts); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	// Setting up again (e.g. for another team) replaces the settings.
+	if err := Setup(git.NewRepo(wt), opts); err != nil {
+		t.Fatalf("second Setup: %v", err)
+	}
+
+	runGit(t, wt, "commit", "--allow-empty", "-m", "feat: change")
+	runGit(t, wt, "commit", "--amend", "--allow-empty", "--no-edit")
+	if got := runGit(t, wt, "log", "-1", "--format=%an <%ae>"); got != "madflow-engineer-1 <madflow-engineer-1@madflow.invalid>" {
+		t.Errorf("author = %q", got)
+	}
+	msg := runGit(t, wt, "log", "-1", "--format=%B")
+	for _, want := range []string{"Madflow-Issue: gh-12", "Madflow-Team: 1", "Madflow-Model: claude-sonnet-4-6"} {
+		if strings.Count(msg, want) != 1 {
+			t.Errorf("message should contain %q once:\n%s", want, msg)
+		}
+	}
+	gitDir := runGit(t, wt, "rev-parse", "--absolute-git-dir")
+	if data, _ := os.ReadFile(filepath.Join(gitDir, "pre-commit-ran")); strings.Count(string(data), "ran") != 2 {
+		t.Errorf("the repository's pre-commit hook should still run for each commit, ran: %q", data)
+	}
+
+	// The main worktree keeps the host identity and gets no trailers.
+	runGit(t, repo, "commit", "--allow-empty", "-m", "host change")
+	if got := runGit(t, repo, "log", "-1", "--format=%an%n%B"); got != "Host User\nhost change" {
+		t.Errorf("main worktree commit = %q", got)
+	}
+}
+
+func TestSetupRelativeHooksPath(t *testing.T) {
+	repo, wt := initWorktree(t)
+	// A hooks directory checked into the repository, as husky does.
+	for _, dir := range []string{repo, wt} {
+		os.MkdirAll(filepath.Join(dir, ".hooks"), 0755)
+		os.WriteFile(filepath.Join(dir, ".hooks", "commit-msg"), []byte("#!/bin/sh\necho checked >> \"$1\"\n"), 0755)
+	}
+	runGit(t, repo, "config", "core.hooksPath", ".hooks")
+
+	if err := Setup(git.NewRepo(wt), Options{Trailers: []Trailer{{"Madflow-Team", "2"}}}); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	runGit(t, wt, "commit", "--allow-empty", "-m", "change")
+	msg := runGit(t, wt, "log", "-1", "--format=%B")
+	if !strings.Contains(msg, "Madflow-Team: 2") || !strings.Contains(msg, "checked") {
+		t.Errorf("both commit-msg hooks should have run:\n%s", msg)
+	}
+	if got := runGit(t, wt, "log", "-1", "--format=%an"); got != "Host User" {
+		t.Errorf("an empty Name should keep the host identity, got %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+// Package verify runs a project's build, lint and test commands in a
+// worktree and checks the test coverage, so that a branch is known to pass
+// the project's quality checks before it is sent for review.
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/shell"
+)
+
+// maxOutputLines is how much of the end of a command's output a Step keeps.
+const maxOutputLines = 60
+
+// Options are the checks to run. Empty commands are skipped.
+type Options struct {
+	Build string
+	Lint  string
+	Test  string
+	// Timeout bounds all commands together. 0 means no limit.
+	Timeout time.Duration
+	// MinCoverage is the required coverage in percent, read from the output
+	// of Test (see ParseCoverage). 0 does not check coverage.
+	MinCoverage float64
+	// Command builds the commands, e.g. in a sandbox. nil uses
+	// shell.Command.
+	Command shell.CommandFunc
+}
+
+// Step is one command that was run, or skipped after an earlier failure.
+type Step struct {
+	Name    string
+	Command string
+	Skipped bool
+	Err     error
+	// Output is the end of the combined output.
+	Output   string
+	Duration time.Duration
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Steps []Step
+	// Coverage is the coverage found in the test output, when HasCoverage
+	// is set.
+	Coverage    float64
+	HasCoverage bool
+	MinCoverage float64
+}
+
+// OK reports whether every command passed and the coverage, if required,
+// was reached.
+func (r *Result) OK() bool {
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			return false
+		}
+	}
+	return r.coverageErr() == nil
+}
+
+// coverageErr describes why the coverage requirement was not met.
+func (r *Result) coverageErr() error {
+	if r.MinCoverage <= 0 {
+		return nil
+	}
+	for _, s := range r.Steps {
+		if s.Name == "test" && (s.Skipped || s.Err != nil) {
+			return nil // reported as the test failure
+		}
+	}
+	switch {
+	case !r.HasCoverage:
+		return errors.New("no coverage found in the test output")
+	case r.Coverage < r.MinCoverage:
+		return fmt.Errorf("coverage %.1f%% is below %.1f%%", r.Coverage, r.MinCoverage)
+	}
+	return nil
+}
+
+// Run runs the build, lint and test commands in dir, in that order. After
+// the first failure the remaining commands are skipped.
+func Run(ctx context.Context, dir string, opts Options) *Result {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	command := opts.Command
+	if command == nil {
+		command = shell.Command
+	}
+	res := &Result{MinCoverage: opts.MinCoverage}
+	failed := false
+	for _, c := range []struct{ name, command string }{
+		{"build", opts.Build},
+		{"lint", opts.Lint},
+		{"test", opts.Test},
+	} {
+		if c.command == "" {
+			continue
+		}
+		step := Step{Name: c.name, Command: c.command}
+		if failed {
+			step.Skipped = true
+			res.Steps = append(res.Steps, step)
+			continue
+		}
+		start := time.Now()
+		out, err := command(ctx, dir, c.command).CombinedOutput()
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", opts.Timeout)
+		}
+		step.Err, step.Output, step.Duration = err, tail(string(out), maxOutputLines), time.Since(start).Round(time.Second)
+		if c.name == "test" {
+			res.Coverage, res.HasCoverage = ParseCoverage(string(out))
+		}
+		failed = err != nil
+		res.Steps = append(res.Steps, step)
+	}
+	return res
+}
+
+// coverageRe matches a line reporting coverage, e.g. "coverage: 63.2% of
+// statements" from go test -cover, or "total: (statements) 63.2%" from
+// go tool cover -func.
+var coverageRe = regexp.MustCompile(`(?i)(?:coverage|total)\b.*?([0-9]+(?:\.[0-9]+)?)%`)
+
+// ParseCoverage returns the coverage percentage of the last line of out
+// that reports one. The test command should therefore print the overall
+// coverage last.
+func ParseCoverage(out string) (float64, bool) {
+	lines := strings.Split(out, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		m := coverageRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = append([]string{fmt.Sprintf("... (%d lines omitted)", len(lines)-n)}, lines[len(lines)-n:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary is a one-line description of the outcome, e.g.
+// "build ok, lint FAILED (exit status 1), test skipped".
+func (r *Result) Summary() string {
+	parts := make([]string, 0, len(r.Steps)+1)
+	for _, s := range r.Steps {
+		switch {
+		case s.Skipped:
+			parts = append(parts, s.Name+" skipped")
+		case s.Err != nil:
+			parts = append(parts, fmt.Sprintf("%s FAILED (%v)", s.Name, s.Err))
+		default:
+			parts = append(parts, s.Name+" ok")
+		}
+	}
+	if err := r.coverageErr(); err != nil {
+		parts = append(parts, "coverage FAILED ("+err.Error()+")")
+	} else if r.HasCoverage {
+		cov := fmt.Sprintf("coverage %.1f%%", r.Coverage)
+		if r.MinCoverage > 0 {
+			cov += fmt.Sprintf(" (min %.1f%%)", r.MinCoverage)
+		}
+		parts = append(parts, cov)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Markdown renders the result as a report.
+func (r *Result) Markdown(title string) string {
+	var b strings.Builder
+	status := "passed"
+	if !r.OK() {
+		status = "FAILED"
+	}
+	fmt.Fprintf(&b, "# Verification: %s\n\n%s: %s\n", title, status, r.Summary())
+	for _, s := range r.Steps {
+		fmt.Fprintf(&b, "\n## %s\n\n`%s`\n\n", s.Name, s.Command)
+		switch {
+		case s.Skipped:
+			b.WriteString("skipped after an earlier failure\n")
+			continue
+		case s.Err != nil:
+			fmt.Fprintf(&b, "FAILED after %v: %v\n\n", s.Duration, s.Err)
+		default:
+			fmt.Fprintf(&b, "passed in %v\n\n", s.Duration)
+		}
+		fmt.Fprintf(&b, "```\n%s\n```\n", s.Output)
+	}
+	return b.String()
+}
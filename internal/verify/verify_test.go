@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	res := Run(t.Context(), dir, Options{
+		Build:       "echo built > out",
+		Test:        "echo 'ok  pkg/a  0.1s  coverage: 40.0% of statements'; echo 'total: (statements) 72.5%'",
+		MinCoverage: 60,
+	})
+	if !res.OK() {
+		t.Fatalf("Run failed: %s", res.Summary())
+	}
+	if len(res.Steps) != 2 || res.Steps[0].Name != "build" || res.Steps[1].Name != "test" {
+		t.Errorf("steps = %+v, want build and test (lint is not configured)", res.Steps)
+	}
+	if !res.HasCoverage || res.Coverage != 72.5 {
+		t.Errorf("coverage = %v (%v), want the last one reported, 72.5", res.Coverage, res.HasCoverage)
+	}
+	if got, want := res.Summary(), "build ok, test ok, coverage 72.5% (min 60.0%)"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	dir := t.TempDir()
+
+	res := Run(t.Context(), dir, Options{Build: "true", Lint: "echo 'main.go:3: unused'; exit 1", Test: "true"})
+	if res.OK() {
+		t.Fatal("a failing lint should fail the run")
+	}
+	if !res.Steps[2].Skipped {
+		t.Error("test should be skipped after the lint failure")
+	}
+	if s := res.Summary(); !strings.Contains(s, "lint FAILED") || !strings.Contains(s, "test skipped") {
+		t.Errorf("Summary = %q", s)
+	}
+	if md := res.Markdown("gh-1 (app)"); !strings.Contains(md, "main.go:3: unused") {
+		t.Errorf("report lacks the lint output:\n%s", md)
+	}
+
+	res = Run(t.Context(), dir, Options{Test: "echo 'coverage: 41.3% of statements'", MinCoverage: 55})
+	if res.OK() || !strings.Contains(res.Summary(), "coverage 41.3% is below 55.0%") {
+		t.Errorf("low coverage: OK = %v, Summary = %q", res.OK(), res.Summary())
+	}
+	res = Run(t.Context(), dir, Options{Test: "echo done", MinCoverage: 55})
+	if res.OK() || !strings.Contains(res.Summary(), "no coverage found") {
+		t.Errorf("missing coverage: OK = %v, Summary = %q", res.OK(), res.Summary())
+	}
+
+	res = Run(t.Context(), dir, Options{Build: "sleep 5", Timeout: 100 * time.Millisecond})
+	if res.OK() || !strings.Contains(res.Summary(), "timed out") {
+		t.Errorf("timeout: OK = %v, Summary = %q", res.OK(), res.Summary())
+	}
+}
+
+func TestParseCoverage(t *testing.T) {
+	for _, tt := range []struct {
+		out  string
+		want float64
+		ok   bool
+	}{
+		{"ok  \tgithub.com/x/y\t0.2s\tcoverage: 63.2% of statements\n", 63.2, true},
+		{"github.com/x/y/a.go:10:\tFoo\t100.0%\ntotal:\t(statements)\t58%\n", 58, true},
+		{"PASS\nok  \tgithub.com/x/y\t0.2s\n", 0, false},
+	} {
+		got, ok := ParseCoverage(tt.out)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseCoverage(%q) = %v, %v, want %v, %v", tt.out, got, ok, tt.want, tt.ok)
+		}
+	}
+}
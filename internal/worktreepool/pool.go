@@ -0,0 +1,305 @@
+// Package worktreepool keeps a number of spare git worktrees ready, checked
+// out at develop and optionally bootstrapped (dependencies downloaded), so
+// that a new team gets a working tree without waiting for checkout and
+// dependency installation.
+package worktreepool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/git"
+	"github.com/ytnobody/madflow/internal/shell"
+)
+
+// Dir is the directory under a repository's .worktrees/ that holds the pool.
+// The leading dot keeps it apart from GitHub logins and team-N worktrees.
+const Dir = ".pool"
+
+const (
+	entryPrefix = "wt-"
+	readySuffix = ".ready"
+	remote      = "origin"
+)
+
+// ErrEmpty is returned by Take when no worktree is ready.
+var ErrEmpty = errors.New("worktree pool is empty")
+
+// Options configure a Pool.
+type Options struct {
+	// Name identifies the repository in log messages.
+	Name string
+	// Size is the number of worktrees kept ready.
+	Size int
+	// Bootstrap is a shell command run in each new worktree, e.g.
+	// "go mod download". Empty means none.
+	Bootstrap string
+	// BootstrapTimeout bounds the bootstrap command. Default 10 minutes.
+	BootstrapTimeout time.Duration
+	// MaxAge is how long a ready worktree is kept before it is rebuilt from
+	// a newer develop. Default 24 hours.
+	MaxAge time.Duration
+	// Develop and Main are the branch names; pool worktrees start at
+	// origin/<develop> when the remote has it, otherwise at <develop>,
+	// which is created from <main> if needed.
+	Develop string
+	Main    string
+}
+
+// Pool is the worktree pool of one repository. Ready worktrees live at
+// .worktrees/.pool/wt-<n> with a wt-<n>.ready marker next to them; a
+// worktree without a marker is still being built or was left half-built.
+type Pool struct {
+	repo *git.Repo
+	opts Options
+	dir  string
+
+	mu      sync.Mutex
+	ctx     context.Context
+	ready   []string        // paths of ready worktrees, oldest first
+	busy    map[string]bool // paths being built or handed over
+	filling bool
+	wg      sync.WaitGroup
+}
+
+// New returns the pool of repo. It does nothing until Start is called.
+func New(repo *git.Repo, opts Options) *Pool {
+	if opts.BootstrapTimeout <= 0 {
+		opts.BootstrapTimeout = 10 * time.Minute
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if opts.Name == "" {
+		opts.Name = filepath.Base(repo.Path())
+	}
+	return &Pool{
+		repo: repo,
+		opts: opts,
+		dir:  filepath.Join(repo.Path(), ".worktrees", Dir),
+		ctx:  context.Background(),
+		busy: make(map[string]bool),
+	}
+}
+
+// Start adopts the ready worktrees left by a previous run, removes broken
+// ones and starts filling the pool in the background. Bootstrap commands
+// are cancelled when ctx is done.
+func (p *Pool) Start(ctx context.Context) {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.ready = nil
+	if entries, err := os.ReadDir(p.dir); err == nil {
+		for _, e := range entries {
+			if e.IsDir() && strings.HasPrefix(e.Name(), entryPrefix) && p.hasMarker(filepath.Join(p.dir, e.Name())) {
+				p.ready = append(p.ready, filepath.Join(p.dir, e.Name()))
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if removed := p.Prune(); len(removed) > 0 {
+		log.Printf("[worktree-pool] %s: removed %d worktree(s): %v", p.opts.Name, len(removed), removed)
+	}
+	p.Fill()
+}
+
+// Ready returns the number of worktrees ready to be taken.
+func (p *Pool) Ready() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.ready)
+}
+
+// Take hands a ready worktree over: branch is created at base inside it and
+// the worktree is moved to dst. It returns ErrEmpty when no worktree is
+// ready, in which case the caller creates the worktree itself. The pool is
+// refilled in the background.
+func (p *Pool) Take(dst, branch, base string) error {
+	p.mu.Lock()
+	if len(p.ready) == 0 {
+		p.mu.Unlock()
+		return ErrEmpty
+	}
+	path := p.ready[0]
+	p.ready = p.ready[1:]
+	p.busy[path] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.busy, path)
+		p.mu.Unlock()
+		p.Fill()
+	}()
+
+	if err := git.ValidateSafeBranchName(branch); err != nil {
+		p.remove(path)
+		return fmt.Errorf("invalid branch name: %w", err)
+	}
+	if err := git.NewRepo(path).CheckoutNewBranch(branch, base); err != nil {
+		p.remove(path)
+		return err
+	}
+	os.Remove(path + readySuffix)
+	if err := p.repo.MoveWorktree(path, dst); err != nil {
+		p.remove(path)
+		return err
+	}
+	return nil
+}
+
+// Fill builds worktrees in the background until Size are ready. Worktrees
+// are built one at a time; calling Fill while the pool is filling does
+// nothing.
+func (p *Pool) Fill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.filling || len(p.ready) >= p.opts.Size || p.ctx.Err() != nil {
+		return
+	}
+	p.filling = true
+	p.wg.Add(1)
+	go p.fill()
+}
+
+func (p *Pool) fill() {
+	defer p.wg.Done()
+	base := p.base()
+	for {
+		p.mu.Lock()
+		if len(p.ready) >= p.opts.Size || p.ctx.Err() != nil {
+			p.filling = false
+			p.mu.Unlock()
+			return
+		}
+		path := filepath.Join(p.dir, fmt.Sprintf("%s%d", entryPrefix, time.Now().UnixNano()))
+		p.busy[path] = true
+		p.mu.Unlock()
+
+		err := p.build(path, base)
+
+		p.mu.Lock()
+		if err == nil {
+			p.ready = append(p.ready, path)
+			delete(p.busy, path)
+		}
+		p.mu.Unlock()
+		if err != nil {
+			// Stop rather than retry in a loop; the next Fill (e.g. from the
+			// worktree cleanup) tries again.
+			log.Printf("[worktree-pool] %s: %v", p.opts.Name, err)
+			p.remove(path)
+			p.mu.Lock()
+			delete(p.busy, path)
+			p.filling = false
+			p.mu.Unlock()
+			return
+		}
+		log.Printf("[worktree-pool] %s: worktree %s ready", p.opts.Name, filepath.Base(path))
+	}
+}
+
+// Wait blocks until the background filling has stopped.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// Prune removes pool worktrees that are not usable: half-built ones, ones
+// older than MaxAge, ones beyond Size and stray markers. It returns the
+// names of the removed entries.
+func (p *Pool) Prune() []string {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	var drop []string
+	keep := p.ready[:0]
+	for _, path := range p.ready {
+		info, err := os.Stat(path + readySuffix)
+		if err != nil || time.Since(info.ModTime()) > p.opts.MaxAge || len(keep) >= p.opts.Size {
+			drop = append(drop, path)
+			continue
+		}
+		keep = append(keep, path)
+	}
+	p.ready = keep
+	for _, e := range entries {
+		path := filepath.Join(p.dir, e.Name())
+		if e.IsDir() {
+			if !p.busy[path] && !slices.Contains(p.ready, path) && !slices.Contains(drop, path) {
+				drop = append(drop, path)
+			}
+			continue
+		}
+		if dir, ok := strings.CutSuffix(path, readySuffix); ok {
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				os.Remove(path)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	var removed []string
+	for _, path := range drop {
+		p.remove(path)
+		removed = append(removed, filepath.Base(path))
+	}
+	return removed
+}
+
+// base returns the revision new worktrees start at.
+func (p *Pool) base() string {
+	if p.repo.HasRemote(remote) && p.repo.Fetch(remote) == nil && p.repo.BranchExists(remote+"/"+p.opts.Develop) {
+		return remote + "/" + p.opts.Develop
+	}
+	if err := p.repo.EnsureBranch(p.opts.Develop, p.opts.Main); err != nil {
+		log.Printf("[worktree-pool] %s: ensure %s: %v", p.opts.Name, p.opts.Develop, err)
+	}
+	return p.opts.Develop
+}
+
+// build creates a worktree at path, runs the bootstrap command in it and
+// marks it ready.
+func (p *Pool) build(path, base string) error {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return fmt.Errorf("create pool directory: %w", err)
+	}
+	if err := p.repo.AddDetachedWorktree(path, base); err != nil {
+		return err
+	}
+	if p.opts.Bootstrap != "" {
+		ctx, cancel := context.WithTimeout(p.ctx, p.opts.BootstrapTimeout)
+		defer cancel()
+		if out, err := shell.Command(ctx, path, p.opts.Bootstrap).CombinedOutput(); err != nil {
+			return fmt.Errorf("bootstrap %q in %s: %v\n%s", p.opts.Bootstrap, filepath.Base(path), err, out)
+		}
+	}
+	if err := os.WriteFile(path+readySuffix, []byte(base+"\n"), 0644); err != nil {
+		return fmt.Errorf("mark %s ready: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func (p *Pool) hasMarker(path string) bool {
+	_, err := os.Stat(path + readySuffix)
+	return err == nil
+}
+
+// remove deletes a pool worktree and its marker.
+func (p *Pool) remove(path string) {
+	if err := p.repo.RemoveWorktree(path); err != nil {
+		p.repo.PruneWorktrees()
+		os.RemoveAll(path)
+	}
+	os.Remove(path + readySuffix)
+}
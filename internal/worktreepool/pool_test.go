@@ -0,0 +1,117 @@
+package worktreepool
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ytnobody/madflow/internal/git"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// initRepo creates a repository with main and develop.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial commit")
+	runGit(t, dir, "branch", "develop")
+	return dir
+}
+
+func startPool(t *testing.T, dir string, opts Options) *Pool {
+	t.Helper()
+	opts.Develop, opts.Main = "develop", "main"
+	p := New(git.NewRepo(dir), opts)
+	p.Start(t.Context())
+	p.Wait()
+	t.Cleanup(p.Wait)
+	return p
+}
+
+func TestPoolTake(t *testing.T) {
+	dir := initRepo(t)
+	p := startPool(t, dir, Options{Size: 2, Bootstrap: "echo ok > bootstrapped"})
+	if n := p.Ready(); n != 2 {
+		t.Fatalf("Ready = %d, want 2", n)
+	}
+
+	dst := filepath.Join(dir, ".worktrees", "alice", "issue-local-001")
+	if err := p.Take(dst, "feature/issue-local-001", "develop"); err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if got := runGit(t, dst, "rev-parse", "--abbrev-ref", "HEAD"); got != "feature/issue-local-001" {
+		t.Errorf("taken worktree is on %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "bootstrapped")); err != nil {
+		t.Errorf("bootstrap output not carried over: %v", err)
+	}
+
+	// The pool refills in the background.
+	p.Wait()
+	if n := p.Ready(); n != 2 {
+		t.Errorf("Ready after refill = %d, want 2", n)
+	}
+}
+
+func TestPoolTakeEmpty(t *testing.T) {
+	dir := initRepo(t)
+	p := startPool(t, dir, Options{Size: 1, Bootstrap: "exit 3"})
+	if n := p.Ready(); n != 0 {
+		t.Fatalf("failed bootstrap should leave the pool empty, Ready = %d", n)
+	}
+	if err := p.Take(filepath.Join(dir, ".worktrees", "team-1"), "feature/x", "develop"); err != ErrEmpty {
+		t.Errorf("Take = %v, want ErrEmpty", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, ".worktrees", Dir)); len(entries) != 0 {
+		t.Errorf("failed worktree left behind: %v", entries)
+	}
+}
+
+func TestPoolRestartAndPrune(t *testing.T) {
+	dir := initRepo(t)
+	p := startPool(t, dir, Options{Size: 2})
+	poolDir := filepath.Join(dir, ".worktrees", Dir)
+
+	// A half-built worktree (no marker) and a stale one are removed on the
+	// next start; the others are adopted.
+	runGit(t, dir, "worktree", "add", "--detach", filepath.Join(poolDir, "wt-1"), "develop")
+	stale := p.ready[0]
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(stale+readySuffix, old, old)
+
+	p2 := startPool(t, dir, Options{Size: 2})
+	if n := p2.Ready(); n != 2 {
+		t.Errorf("Ready = %d, want 2", n)
+	}
+	for _, path := range []string{filepath.Join(poolDir, "wt-1"), stale} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been pruned", filepath.Base(path))
+		}
+	}
+
+	// Shrinking the pool drops the surplus.
+	p3 := New(git.NewRepo(dir), Options{Size: 1, Develop: "develop", Main: "main"})
+	p3.ready = p2.ready
+	if removed := p3.Prune(); len(removed) != 1 {
+		t.Errorf("Prune removed %v, want one worktree", removed)
+	}
+	if list := runGit(t, dir, "worktree", "list"); strings.Count(list, "\n")+1 != 2 {
+		t.Errorf("want the main worktree and one pool worktree:\n%s", list)
+	}
+}